@@ -10,9 +10,9 @@ var log = logging.MustGetLogger("cli")
 
 // Constants for available command names and options
 const (
-	EnvSubCmdCount             = 5
+	EnvSubCmdCount             = 8
 	SingleAliasIndex           = 0
-	SvcSubCmdCount             = 7
+	SvcSubCmdCount             = 10
 	SvcShowFormatFlagIndex     = 0
 	SvcLogFlagCount            = 3
 	EnvLogFollowFlagIndex      = 0
@@ -98,24 +98,109 @@ const (
 	UndeployCmd                = "undeploy"
 	SvcUndeployCmdUsage        = "undeploy service from environment"
 	SvcUndeployArgsUsage       = "<environment> [<service>]"
+
+	SvcExeWaitFlagUsage         = "wait for the command to finish, stream its logs and exit with its exit code"
+	SvcExeCPUFlagUsage          = "cpu units to override for the command"
+	SvcExeMemoryFlagUsage       = "memory (in MiB) to override for the command"
+	SvcExeEnvVarFlagUsage       = "environment variable to set for the command (e.g. KEY=VALUE)"
+	SvcExeTaskRoleFlagUsage     = "iam role arn to run the command with"
+	SvcPushBuildArgFlagUsage    = "build arg for the image, as KEY=VALUE or KEY to take the value from the environment"
+	SvcPushTargetFlagUsage      = "stage of a multi-stage Dockerfile to build"
+	SvcPushCacheFromFlagUsage   = "image to use as build cache (default: the previous image in ECR)"
+	SvcPushLabelFlagUsage       = "label for the image, as KEY=VALUE"
+	SvcPushPlatformFlagUsage    = "platform to build the image for (e.g. linux/arm64)"
+	SvcRestartMaxUnhealthyUsage = "number of tasks that may fail to restart before aborting"
+	SvcRestartTimeoutUsage      = "time to wait for each batch of replacement tasks to become healthy"
+	SvcRestartDryRunUsage       = "show the tasks that would be restarted without restarting them"
+	SvcPromoteRetagFlagUsage    = "tag the promoted image in ECR with the name of the target environment"
+	SvcShellServiceFlagUsage    = "service name to open a shell in"
+	SvcShellTaskFlagUsage       = "id of the task (or name of the pod) to open a shell in, defaults to the first running one"
+	SvcShellContainerFlagUsage  = "name of the container to open a shell in, defaults to the service container"
+	SvcScheduleRunWaitFlagUsage = "wait for the command to finish, stream its logs and exit with its exit code"
+	SvcTaskWaitTimeoutUsage     = "time to wait for the command to finish when waiting for it"
+	ValidateProviderFlagUsage   = "provider to check the service config against (default: the providers of the environments in the config)"
+	UnsupportedFieldWarning     = "service field '%s' isn't supported by provider '%s' and will be ignored"
+	UnsupportedEnvFieldWarning  = "service field '%s' isn't supported by provider '%s' of environment '%s' and will be ignored"
+	BuildArg                    = "build-arg"
+	BuildArgFlag                = "build-arg"
+	Target                      = "target"
+	TargetFlag                  = "target"
+	CacheFrom                   = "cache-from"
+	CacheFromFlag               = "cache-from"
+	Label                       = "label"
+	LabelFlag                   = "label"
+	Platform                    = "platform"
+	PlatformFlag                = "platform"
+	UpgradeCmd                  = "upgrade"
+	UpgradeUsage                = "upgrade the kubernetes version of an environment to 'cluster.kubernetesVersion'"
+	UpgradeTimeoutUsage         = "time to wait for the pods to be rescheduled after each node group is rolled"
+	RefreshAMICmd               = "refresh-ami"
+	RefreshAMIUsage             = "replace the instances of an environment with the latest AMI"
+	RefreshAMIMaxInFlightUsage  = "number of instances to replace concurrently"
+	RefreshAMITimeoutUsage      = "time to wait for each batch of instances to drain and for their tasks to be rescheduled"
+	RBACCmd                     = "rbac"
+	RBACUsage                   = "show the rbac bindings of an environment"
+	PromoteCmd                  = "promote"
+	PromoteUsage                = "deploy the artifacts of a service in one environment to another"
+	PromoteArgs                 = "<from environment> <to environment>"
+	ShellCmd                    = "shell"
+	ShellUsage                  = "open an interactive shell in a running task of a service"
+	ShellArgs                   = "<environment> [-- <command>...]"
+	SchedulesCmd                = "schedules"
+	SchedulesUsage              = "list the schedules of a service in an environment"
+	ScheduleRunCmd              = "run"
+	ScheduleRunUsage            = "run the command of a schedule now (ECS and EKS only)"
+	ScheduleEnableCmd           = "enable"
+	ScheduleEnableUsage         = "enable a schedule until the next deploy"
+	ScheduleDisableCmd          = "disable"
+	ScheduleDisableUsage        = "disable a schedule until the next deploy"
+	ScheduleArgs                = "<environment> <schedule>"
+	MaxUnhealthy                = "max-unhealthy"
+	MaxUnhealthyFlag            = "max-unhealthy, u"
+	Timeout                     = "timeout"
+	TimeoutFlag                 = "timeout"
+	MaxInFlight                 = "max-in-flight"
+	MaxInFlightFlag             = "max-in-flight"
+	DefaultRestartTimeoutValue  = 10 * time.Minute
+	DefaultTaskWaitTimeoutValue = 1 * time.Hour
+	Retag                       = "retag"
+	RetagFlag                   = "retag"
+	DryRun                      = "dryrun"
+	DryRunFlag                  = "dryrun"
+	ContainerFlagName           = "container"
+	ContainerFlag               = "container, c"
+	Wait                        = "wait"
+	WaitFlag                    = "wait, w"
+	CPU                         = "cpu"
+	CPUFlag                     = "cpu"
+	Memory                      = "memory"
+	MemoryFlag                  = "memory"
+	EnvVar                      = "env"
+	EnvVarFlag                  = "env, e"
+	TaskRole                    = "task-role"
+	TaskRoleFlag                = "task-role"
 )
 
 // Constants to prevent multiple updates when making changes.
 const (
-	Zero               = 0
-	Space              = " "
-	Spaces             = "   "
-	NoEnvValidation    = "environment must be provided"
-	AllEnvValidation   = "environment must NOT be provided"
-	NoCmdValidation    = "command must be provided"
-	EmptyCmdValidation = "command must not be an empty string"
+	Zero                    = 0
+	Space                   = " "
+	Spaces                  = "   "
+	NoEnvValidation         = "environment must be provided"
+	AllEnvValidation        = "environment must NOT be provided"
+	NoCmdValidation         = "command must be provided"
+	NoPromoteEnvValidation  = "source and target environments must be provided"
+	NoScheduleValidation    = "environment and schedule must be provided"
+	EmptyCmdValidation      = "command must not be an empty string"
+	InvalidEnvVarValidation = "environment variable '%s' must be in the form KEY=VALUE"
+	InvalidLabelValidation  = "label '%s' must be in the form KEY=VALUE"
 )
 
 // Constants used during testing
 const (
 	EnvAliasCount    = 1
 	SvcAliasCount    = 1
-	SvcFlagsCount    = 3
+	SvcFlagsCount    = 8
 	FailExitCode     = 1
 	Test             = "test"
 	TestEnv          = "fooenv"
@@ -22,6 +22,9 @@ func newEnvironmentsCommand(ctx *common.Context) *cli.Command {
 			*newEnvironmentsShowCommand(ctx),
 			*newEnvironmentsUpsertCommand(ctx),
 			*newEnvironmentsTerminateCommand(ctx),
+			*newEnvironmentsUpgradeCommand(ctx),
+			*newEnvironmentsRefreshAMICommand(ctx),
+			*newEnvironmentsRBACCommand(ctx),
 			*newEnvironmentsLogsCommand(ctx),
 		},
 	}
@@ -148,6 +151,82 @@ func newEnvironmentsTerminateCommand(ctx *common.Context) *cli.Command {
 	return cmd
 }
 
+func newEnvironmentsUpgradeCommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      UpgradeCmd,
+		Usage:     UpgradeUsage,
+		ArgsUsage: EnvArgUsage,
+		Flags: []cli.Flag{
+			cli.DurationFlag{
+				Name:  TimeoutFlag,
+				Usage: UpgradeTimeoutUsage,
+				Value: DefaultRestartTimeoutValue,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			if len(environmentName) == Zero {
+				cli.ShowCommandHelp(c, UpgradeCmd)
+				return errors.New(NoEnvValidation)
+			}
+			workflow := workflows.NewEnvironmentUpgrader(ctx, environmentName, c.Duration(Timeout))
+			return workflow()
+		},
+	}
+
+	return cmd
+}
+
+func newEnvironmentsRefreshAMICommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      RefreshAMICmd,
+		Usage:     RefreshAMIUsage,
+		ArgsUsage: EnvArgUsage,
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  MaxInFlightFlag,
+				Usage: RefreshAMIMaxInFlightUsage,
+				Value: 1,
+			},
+			cli.DurationFlag{
+				Name:  TimeoutFlag,
+				Usage: RefreshAMITimeoutUsage,
+				Value: DefaultRestartTimeoutValue,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			if len(environmentName) == Zero {
+				cli.ShowCommandHelp(c, RefreshAMICmd)
+				return errors.New(NoEnvValidation)
+			}
+			workflow := workflows.NewEnvironmentAMIRefresher(ctx, environmentName, c.Int(MaxInFlight), c.Duration(Timeout))
+			return workflow()
+		},
+	}
+
+	return cmd
+}
+
+func newEnvironmentsRBACCommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      RBACCmd,
+		Usage:     RBACUsage,
+		ArgsUsage: EnvArgUsage,
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			if len(environmentName) == Zero {
+				cli.ShowCommandHelp(c, RBACCmd)
+				return errors.New(NoEnvValidation)
+			}
+			workflow := workflows.NewEnvironmentRBACViewer(ctx, environmentName, os.Stdout)
+			return workflow()
+		},
+	}
+
+	return cmd
+}
+
 func newEnvironmentsLogsCommand(ctx *common.Context) *cli.Command {
 	cmd := &cli.Command{
 		Name:  LogsCmd,
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -20,10 +21,13 @@ func newServicesCommand(ctx *common.Context) *cli.Command {
 			*newServicesShowCommand(ctx),
 			*newServicesPushCommand(ctx),
 			*newServicesDeployCommand(ctx),
+			*newServicesPromoteCommand(ctx),
 			*newServicesUndeployCommand(ctx),
 			*newServicesLogsCommand(ctx),
 			*newServicesExecuteCommand(ctx),
 			*newServicesRestartCommand(ctx),
+			*newServicesShellCommand(ctx),
+			*newServicesSchedulesCommand(ctx),
 		},
 	}
 
@@ -88,12 +92,36 @@ func newServicesPushCommand(ctx *common.Context) *cli.Command {
 				Name:  KmsKeyFlagName,
 				Usage: SvcPushKmsKeyFlagUsage,
 			},
+			cli.StringSliceFlag{
+				Name:  BuildArgFlag,
+				Usage: SvcPushBuildArgFlagUsage,
+			},
+			cli.StringFlag{
+				Name:  TargetFlag,
+				Usage: SvcPushTargetFlagUsage,
+			},
+			cli.StringSliceFlag{
+				Name:  CacheFromFlag,
+				Usage: SvcPushCacheFromFlagUsage,
+			},
+			cli.StringSliceFlag{
+				Name:  LabelFlag,
+				Usage: SvcPushLabelFlagUsage,
+			},
+			cli.StringFlag{
+				Name:  PlatformFlag,
+				Usage: SvcPushPlatformFlagUsage,
+			},
 		},
 		Action: func(c *cli.Context) error {
 			tag := c.String(Tag)
 			provider := c.String(Provider)
 			kmsKey := c.String(KmsKey)
-			workflow := workflows.NewServicePusher(ctx, tag, provider, kmsKey, ctx.DockerOut)
+			buildOverrides, err := newBuildOverrides(c)
+			if err != nil {
+				return err
+			}
+			workflow := workflows.NewServicePusher(ctx, tag, provider, kmsKey, *buildOverrides, ctx.DockerOut)
 			return workflow()
 		},
 	}
@@ -101,6 +129,36 @@ func newServicesPushCommand(ctx *common.Context) *cli.Command {
 	return cmd
 }
 
+func newBuildOverrides(c *cli.Context) (*common.ServiceBuild, error) {
+	buildArgs := map[string]string{}
+	for _, buildArg := range c.StringSlice(BuildArg) {
+		parts := strings.SplitN(buildArg, "=", 2)
+		if len(parts) == 1 {
+			// same as docker, a bare key takes its value from the environment
+			buildArgs[parts[0]] = os.Getenv(parts[0])
+		} else {
+			buildArgs[parts[0]] = parts[1]
+		}
+	}
+
+	labels := map[string]string{}
+	for _, label := range c.StringSlice(Label) {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == Zero {
+			return nil, fmt.Errorf(InvalidLabelValidation, label)
+		}
+		labels[parts[0]] = parts[1]
+	}
+
+	return &common.ServiceBuild{
+		Args:      buildArgs,
+		Target:    c.String(Target),
+		CacheFrom: c.StringSlice(CacheFrom),
+		Labels:    labels,
+		Platform:  c.String(Platform),
+	}, nil
+}
+
 func newServicesDeployCommand(ctx *common.Context) *cli.Command {
 	cmd := &cli.Command{
 		Name:      DeployCmd,
@@ -162,6 +220,20 @@ func newServicesRestartCommand(ctx *common.Context) *cli.Command {
 				Usage: SvcRestartBatchFlagUsage,
 				Value: 1,
 			},
+			cli.IntFlag{
+				Name:  MaxUnhealthyFlag,
+				Usage: SvcRestartMaxUnhealthyUsage,
+				Value: 1,
+			},
+			cli.DurationFlag{
+				Name:  TimeoutFlag,
+				Usage: SvcRestartTimeoutUsage,
+				Value: DefaultRestartTimeoutValue,
+			},
+			cli.BoolFlag{
+				Name:  DryRunFlag,
+				Usage: SvcRestartDryRunUsage,
+			},
 		},
 		Action: func(c *cli.Context) error {
 			environmentName := c.Args().First()
@@ -172,8 +244,155 @@ func newServicesRestartCommand(ctx *common.Context) *cli.Command {
 
 			serviceName := c.String(SvcCmd)
 			batchSize := c.Int(BatchSize)
+			maxUnhealthy := c.Int(MaxUnhealthy)
+			timeout := c.Duration(Timeout)
+			dryRun := c.Bool(DryRun) || ctx.Config.DryRun
+
+			workflow := workflows.NewServiceRestarter(ctx, environmentName, serviceName, batchSize, maxUnhealthy, timeout, dryRun)
+			return workflow()
+		},
+	}
+	return cmd
+}
+
+func newServicesPromoteCommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      PromoteCmd,
+		Usage:     PromoteUsage,
+		ArgsUsage: PromoteArgs,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  RetagFlag,
+				Usage: SvcPromoteRetagFlagUsage,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			fromEnvironmentName := c.Args().First()
+			toEnvironmentName := c.Args().Get(1)
+			if len(fromEnvironmentName) == Zero || len(toEnvironmentName) == Zero {
+				cli.ShowCommandHelp(c, PromoteCmd)
+				return errors.New(NoPromoteEnvValidation)
+			}
+
+			workflow := workflows.NewServicePromoter(ctx, fromEnvironmentName, toEnvironmentName, c.Bool(Retag))
+			return workflow()
+		},
+	}
+	return cmd
+}
+
+func newServicesShellCommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      ShellCmd,
+		Usage:     ShellUsage,
+		ArgsUsage: ShellArgs,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  ServiceFlag,
+				Usage: SvcShellServiceFlagUsage,
+			},
+			cli.StringFlag{
+				Name:  TaskFlag,
+				Usage: SvcShellTaskFlagUsage,
+			},
+			cli.StringFlag{
+				Name:  ContainerFlag,
+				Usage: SvcShellContainerFlagUsage,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			if len(environmentName) == Zero {
+				cli.ShowCommandHelp(c, ShellCmd)
+				return errors.New(NoEnvValidation)
+			}
+
+			// everything after the environment is the command, e.g. `mu svc shell dev -- ls -l`
+			command := c.Args().Tail()
+			if len(command) > Zero && command[0] == "--" {
+				command = command[1:]
+			}
 
-			workflow := workflows.NewServiceRestarter(ctx, environmentName, serviceName, batchSize)
+			workflow := workflows.NewServiceShell(ctx, environmentName, c.String(SvcCmd), c.String(TaskFlagName), c.String(ContainerFlagName), command)
+			return workflow()
+		},
+	}
+	return cmd
+}
+
+func newServicesSchedulesCommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      SchedulesCmd,
+		Usage:     SchedulesUsage,
+		ArgsUsage: EnvArgUsage,
+		Subcommands: []cli.Command{
+			*newServicesScheduleRunCommand(ctx),
+			*newServicesScheduleStateCommand(ctx, ScheduleEnableCmd, ScheduleEnableUsage, true),
+			*newServicesScheduleStateCommand(ctx, ScheduleDisableCmd, ScheduleDisableUsage, false),
+		},
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			if len(environmentName) == Zero {
+				cli.ShowCommandHelp(c, SchedulesCmd)
+				return errors.New(NoEnvValidation)
+			}
+
+			workflow := workflows.NewServiceSchedulesViewer(ctx, environmentName, os.Stdout)
+			return workflow()
+		},
+	}
+	return cmd
+}
+
+func newServicesScheduleRunCommand(ctx *common.Context) *cli.Command {
+	cmd := &cli.Command{
+		Name:      ScheduleRunCmd,
+		Usage:     ScheduleRunUsage,
+		ArgsUsage: ScheduleArgs,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  WaitFlag,
+				Usage: SvcScheduleRunWaitFlagUsage,
+			},
+			cli.DurationFlag{
+				Name:  TimeoutFlag,
+				Usage: SvcTaskWaitTimeoutUsage,
+				Value: DefaultTaskWaitTimeoutValue,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			scheduleName := c.Args().Get(1)
+			if len(environmentName) == Zero || len(scheduleName) == Zero {
+				cli.ShowCommandHelp(c, ScheduleRunCmd)
+				return errors.New(NoScheduleValidation)
+			}
+
+			workflow := workflows.NewServiceScheduleRunner(ctx, environmentName, scheduleName, c.Bool(Wait), c.Duration(Timeout), os.Stdout)
+			err := workflow()
+			if exitErr, ok := err.(*common.TaskExitError); ok {
+				return cli.NewExitError(exitErr.Error(), exitErr.ExitCode)
+			}
+			return err
+		},
+	}
+	return cmd
+}
+
+func newServicesScheduleStateCommand(ctx *common.Context, name string, usage string, enabled bool) *cli.Command {
+	cmd := &cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: ScheduleArgs,
+		Action: func(c *cli.Context) error {
+			environmentName := c.Args().First()
+			scheduleName := c.Args().Get(1)
+			if len(environmentName) == Zero || len(scheduleName) == Zero {
+				cli.ShowCommandHelp(c, name)
+				return errors.New(NoScheduleValidation)
+			}
+
+			workflow := workflows.NewServiceScheduleStateUpdater(ctx, environmentName, scheduleName, enabled)
 			return workflow()
 		},
 	}
@@ -255,6 +474,31 @@ func newServicesExecuteCommand(ctx *common.Context) *cli.Command {
 				Usage:  SvcExeClusterFlagUsage,
 				Hidden: ClusterFlagVisible,
 			},
+			cli.BoolFlag{
+				Name:  WaitFlag,
+				Usage: SvcExeWaitFlagUsage,
+			},
+			cli.DurationFlag{
+				Name:  TimeoutFlag,
+				Usage: SvcTaskWaitTimeoutUsage,
+				Value: DefaultTaskWaitTimeoutValue,
+			},
+			cli.IntFlag{
+				Name:  CPUFlag,
+				Usage: SvcExeCPUFlagUsage,
+			},
+			cli.IntFlag{
+				Name:  MemoryFlag,
+				Usage: SvcExeMemoryFlagUsage,
+			},
+			cli.StringSliceFlag{
+				Name:  EnvVarFlag,
+				Usage: SvcExeEnvVarFlagUsage,
+			},
+			cli.StringFlag{
+				Name:  TaskRoleFlag,
+				Usage: SvcExeTaskRoleFlagUsage,
+			},
 		},
 		Action: func(c *cli.Context) error {
 			task, err := newTask(c)
@@ -262,8 +506,13 @@ func newServicesExecuteCommand(ctx *common.Context) *cli.Command {
 				return err
 			}
 
-			workflow := workflows.NewServiceExecutor(ctx, *task)
-			return workflow()
+			workflow := workflows.NewServiceExecutor(ctx, *task, c.Bool(Wait), c.Duration(Timeout), os.Stdout)
+			err = workflow()
+			if exitErr, ok := err.(*common.TaskExitError); ok {
+				// exit with the code of the command so scripts can act on it
+				return cli.NewExitError(exitErr.Error(), exitErr.ExitCode)
+			}
+			return err
 		},
 	}
 	return cmd
@@ -276,11 +525,25 @@ func newTask(c *cli.Context) (*common.Task, error) {
 	}
 	environmentName := c.Args().First()
 	command := c.Args()[ExeArgsCmdIndex:]
+
+	envVars := map[string]string{}
+	for _, envVar := range c.StringSlice(EnvVar) {
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == Zero {
+			return nil, fmt.Errorf(InvalidEnvVarValidation, envVar)
+		}
+		envVars[parts[0]] = parts[1]
+	}
+
 	return &common.Task{
 		Environment:    environmentName,
 		Command:        command,
 		Service:        c.String(SvcCmd),
 		TaskDefinition: c.String(TaskFlagName),
 		Cluster:        c.String(ClusterFlagName),
+		Cpu:            c.Int(CPU),
+		Memory:         c.Int(Memory),
+		EnvVars:        envVars,
+		TaskRoleArn:    c.String(TaskRole),
 	}, nil
 }
@@ -91,7 +91,33 @@ func TestNewServiceRestartCommand(t *testing.T) {
 
 	assertion.Equal(RestartCmd, command.Name, NameMessage)
 	assertion.Equal(EnvArgUsage, command.ArgsUsage, ArgsUsageMessage)
-	assertion.Equal(2, len(command.Flags), FlagLenMessage)
+	assertion.Equal(5, len(command.Flags), FlagLenMessage)
+	assertion.NotNil(command.Action)
+}
+
+func TestNewServicePromoteCommand(t *testing.T) {
+	assertion := assert.New(t)
+
+	ctx := common.NewContext()
+
+	command := newServicesPromoteCommand(ctx)
+
+	assertion.Equal(PromoteCmd, command.Name, NameMessage)
+	assertion.Equal(PromoteArgs, command.ArgsUsage, ArgsUsageMessage)
+	assertion.Equal(1, len(command.Flags), FlagLenMessage)
+	assertion.NotNil(command.Action)
+}
+
+func TestNewServiceShellCommand(t *testing.T) {
+	assertion := assert.New(t)
+
+	ctx := common.NewContext()
+
+	command := newServicesShellCommand(ctx)
+
+	assertion.Equal(ShellCmd, command.Name, NameMessage)
+	assertion.Equal(ShellArgs, command.ArgsUsage, ArgsUsageMessage)
+	assertion.Equal(3, len(command.Flags), FlagLenMessage)
 	assertion.NotNil(command.Action)
 }
 
@@ -175,3 +201,21 @@ func getTestExecuteContext(args cli.Args) *cli.Context {
 
 	return cli.NewContext(app, set, nil)
 }
+
+func TestNewServiceSchedulesCommand(t *testing.T) {
+	assertion := assert.New(t)
+
+	ctx := common.NewContext()
+
+	command := newServicesSchedulesCommand(ctx)
+
+	assertion.Equal(SchedulesCmd, command.Name, NameMessage)
+	assertion.Equal(EnvArgUsage, command.ArgsUsage, ArgsUsageMessage)
+	assertion.Equal(3, len(command.Subcommands), SubCmdLenMessage)
+	assertion.Equal(ScheduleRunCmd, command.Subcommands[0].Name, NameMessage)
+	assertion.Equal(1, len(command.Subcommands[0].Flags), FlagLenMessage)
+	assertion.Equal(ScheduleEnableCmd, command.Subcommands[1].Name, NameMessage)
+	assertion.Equal(ScheduleDisableCmd, command.Subcommands[2].Name, NameMessage)
+	assertion.Equal(ScheduleArgs, command.Subcommands[2].ArgsUsage, ArgsUsageMessage)
+	assertion.NotNil(command.Action)
+}
@@ -10,7 +10,29 @@ func newValidateCommand(ctx *common.Context) *cli.Command {
 	cmd := &cli.Command{
 		Name:  "validate",
 		Usage: "validate mu config",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  ProviderFlagName,
+				Usage: ValidateProviderFlagUsage,
+			},
+		},
 		Action: func(c *cli.Context) error {
+			if provider := c.String(Provider); provider != "" {
+				for _, field := range ctx.Config.Service.UnsupportedFields(common.EnvProvider(provider)) {
+					log.Warningf(UnsupportedFieldWarning, field, provider)
+				}
+				return nil
+			}
+
+			for _, environment := range ctx.Config.Environments {
+				provider := environment.Provider
+				if provider == "" {
+					provider = common.EnvProviderEcs
+				}
+				for _, field := range ctx.Config.Service.UnsupportedFields(provider) {
+					log.Warningf(UnsupportedEnvFieldWarning, field, provider, environment.Name)
+				}
+			}
 			return nil
 		},
 	}
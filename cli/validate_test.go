@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,3 +16,14 @@ func TestValidateCommand(t *testing.T) {
 	app.Run(os.Args)
 	assert.NotNil(app)
 }
+
+func TestNewValidateCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := common.NewContext()
+
+	command := newValidateCommand(ctx)
+	assert.Equal("validate", command.Name)
+	assert.Equal(1, len(command.Flags))
+	assert.NotNil(command.Action)
+}
@@ -19,9 +19,15 @@ type ArtifactGetter interface {
 	GetArtifact(uri string, etag string) (io.ReadCloser, string, error)
 }
 
+// ArtifactChecker for checking if artifacts exist
+type ArtifactChecker interface {
+	ArtifactExists(uri string) (bool, error)
+}
+
 // ArtifactManager composite of all artifact capabilities
 type ArtifactManager interface {
 	ArtifactCreator
 	ArtifactGetter
+	ArtifactChecker
 	BucketEmptier
 }
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"time"
+)
+
+// ScalingActivity describes a change in the capacity of a service
+type ScalingActivity struct {
+	Description string
+	Status      string
+	StartTime   time.Time
+}
+
+// ScalingState describes the capacity and scaling policies of a service
+type ScalingState struct {
+	MinCapacity      int64
+	MaxCapacity      int64
+	Policies         []string
+	ScheduledActions []string
+	LastActivity     *ScalingActivity
+}
+
+// ScalingStateGetter for getting the scaling state of an ECS service
+type ScalingStateGetter interface {
+	GetScalingState(clusterName string, serviceName string) (*ScalingState, error)
+}
+
+// AutoscalingManager composite of all autoscaling capabilities
+type AutoscalingManager interface {
+	ScalingStateGetter
+}
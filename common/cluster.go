@@ -10,6 +10,24 @@ type ClusterInstanceLister interface {
 	ListInstances(clusterName string) ([]ContainerInstance, error)
 }
 
+// ClusterInstanceDrainer for draining the tasks off cluster instances
+type ClusterInstanceDrainer interface {
+	DrainInstances(clusterName string, containerInstanceArns ...string) error
+}
+
+// ClusterService describes the tasks of an ECS service
+type ClusterService struct {
+	Name         string
+	DesiredCount int64
+	RunningCount int64
+	PendingCount int64
+}
+
+// ClusterServiceLister for getting the services of a cluster
+type ClusterServiceLister interface {
+	ListServices(clusterName string) ([]ClusterService, error)
+}
+
 // RepositoryAuthenticator auths for a repo
 type RepositoryAuthenticator interface {
 	AuthenticateRepository(repoURL string) (string, error)
@@ -20,9 +38,29 @@ type RepositoryDeleter interface {
 	DeleteRepository(repoName string) error
 }
 
+// RepositoryTagLister lists the tags of the images in a repo
+type RepositoryTagLister interface {
+	ListRepositoryTags(repoURL string) ([]string, error)
+}
+
+// RepositoryImageResolver resolves the digest of an image in a repo
+type RepositoryImageResolver interface {
+	ResolveImageDigest(imageURL string) (string, error)
+}
+
+// RepositoryImageTagger adds a tag to an image in a repo
+type RepositoryImageTagger interface {
+	TagImage(imageURL string, tag string) error
+}
+
 // ClusterManager composite of all cluster capabilities
 type ClusterManager interface {
 	ClusterInstanceLister
+	ClusterInstanceDrainer
+	ClusterServiceLister
 	RepositoryAuthenticator
 	RepositoryDeleter
+	RepositoryTagLister
+	RepositoryImageResolver
+	RepositoryImageTagger
 }
@@ -246,6 +246,94 @@ func (ctx *Context) InitializeContext() error {
 	return nil
 }
 
+// ContextInitializer initializes the managers of a context for a region, in the account reached through account
+type ContextInitializer func(ctx *Context, region string, account EnvironmentAccount) error
+
+// SetContextInitializer sets how the contexts for other regions and accounts are initialized
+func (ctx *Context) SetContextInitializer(initializer ContextInitializer) {
+	ctx.contextInitializer = initializer
+}
+
+// ForRegion returns the context for a region, in the account of ctx
+func (ctx *Context) ForRegion(region string) (*Context, error) {
+	return ctx.ForAccount(region, EnvironmentAccount{})
+}
+
+// ForEnvironment returns the context for the region and account of an environment
+func (ctx *Context) ForEnvironment(environmentName string) (*Context, error) {
+	return ctx.ForAccount(ctx.Config.GetEnvironmentRegion(environmentName), ctx.Config.GetEnvironmentAccount(environmentName))
+}
+
+// ForAccount returns the context for a region and account.  An empty region is the region of ctx, and an
+// empty account is the account of ctx.  The managers of any other region or account are initialized the first
+// time it is used and reused after that
+func (ctx *Context) ForAccount(region string, account EnvironmentAccount) (*Context, error) {
+	if region == Empty {
+		region = ctx.Region
+	}
+	if region == ctx.Region && account == (EnvironmentAccount{}) {
+		return ctx, nil
+	}
+
+	ctx.contextsLock.Lock()
+	defer ctx.contextsLock.Unlock()
+
+	key := fmt.Sprintf("%s|%s|%s|%s", region, account.Profile, account.RoleArn, account.ExternalID)
+	if accountCtx, ok := ctx.contexts[key]; ok {
+		return accountCtx, nil
+	}
+	if ctx.contextInitializer == nil {
+		return nil, fmt.Errorf("Unable to initialize managers for region '%s'", region)
+	}
+
+	log.Debugf("Initializing managers for region '%s' profile '%s' role '%s'", region, account.Profile, account.RoleArn)
+	accountCtx := &Context{
+		Config:               ctx.Config,
+		LocalPipelineManager: ctx.LocalPipelineManager,
+		DockerManager:        ctx.DockerManager,
+		DockerOut:            ctx.DockerOut,
+		ExtensionsManager:    ctx.ExtensionsManager,
+	}
+	err := ctx.contextInitializer(accountCtx, region, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.contexts == nil {
+		ctx.contexts = make(map[string]*Context)
+	}
+	ctx.contexts[key] = accountCtx
+	return accountCtx, nil
+}
+
+// GetEnvironmentRegion returns the region of an environment, from `service.environmentConfig.<env>.region`
+// or the `region` of the environment.  An empty region is the region mu was run in
+func (config *Config) GetEnvironmentRegion(environmentName string) string {
+	if envConfig, ok := config.Service.EnvironmentConfig[environmentName]; ok && envConfig.Region != Empty {
+		return envConfig.Region
+	}
+	for _, environment := range config.Environments {
+		if strings.EqualFold(environment.Name, environmentName) {
+			return environment.Region
+		}
+	}
+	return Empty
+}
+
+// GetEnvironmentAccount returns the account of an environment, from `service.environmentConfig.<env>.account`
+// or the `account` of the environment.  An empty account is the account mu was run in
+func (config *Config) GetEnvironmentAccount(environmentName string) EnvironmentAccount {
+	if envConfig, ok := config.Service.EnvironmentConfig[environmentName]; ok && envConfig.Account != (EnvironmentAccount{}) {
+		return envConfig.Account
+	}
+	for _, environment := range config.Environments {
+		if strings.EqualFold(environment.Name, environmentName) {
+			return environment.Account
+		}
+	}
+	return EnvironmentAccount{}
+}
+
 func loadYamlConfig(config *Config, yamlReader io.Reader) error {
 	yamlBuffer := new(bytes.Buffer)
 	yamlBuffer.ReadFrom(yamlReader)
@@ -275,10 +363,18 @@ type EnvironmentVariableEvaluator struct {
 	Pattern *regexp.Regexp
 }
 
+var environmentVariablePattern = regexp.MustCompile("\\${env:[a-zA-Z0-9_]*}")
+
 func newEnvironmentReplacer(input io.Reader) io.Reader {
 	scanner := bufio.NewScanner(input)
-	pattern := regexp.MustCompile("\\${env:[a-zA-Z0-9_]*}")
-	return &EnvironmentVariableEvaluator{scanner, pattern}
+	return &EnvironmentVariableEvaluator{scanner, environmentVariablePattern}
+}
+
+// ResolveEnvironmentVariables replaces the `${env:NAME}` references in a value with the environment variables they name
+func ResolveEnvironmentVariables(value string) string {
+	return environmentVariablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		return os.Getenv(match[6 : len(match)-1])
+	})
 }
 
 // Read implements the reader interface
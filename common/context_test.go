@@ -126,3 +126,143 @@ func TestSubstituteEnvironmentVariablessAsStream(t *testing.T) {
 	assert.NotContains(outputString, "junkymcjunkface")
 	assert.Contains(outputString, "prejunk//postjunk")
 }
+
+func TestResolveEnvironmentVariables(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv("MU_TEST_BUILD_ARG", "bar")
+	defer os.Unsetenv("MU_TEST_BUILD_ARG")
+
+	assert.Equal("foo-bar", ResolveEnvironmentVariables("foo-${env:MU_TEST_BUILD_ARG}"))
+	assert.Equal("foo-", ResolveEnvironmentVariables("foo-${env:junkymcjunkface}"))
+	assert.Equal("foo", ResolveEnvironmentVariables("foo"))
+}
+
+func TestContext_ForRegion(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := NewContext()
+	ctx.Region = "us-east-1"
+	ctx.Config.Namespace = "mu"
+
+	initialized := make([]string, 0)
+	ctx.SetContextInitializer(func(regionCtx *Context, region string, account EnvironmentAccount) error {
+		initialized = append(initialized, region)
+		regionCtx.Region = region
+		return nil
+	})
+
+	homeCtx, err := ctx.ForRegion("")
+	assert.Nil(err)
+	assert.True(homeCtx == ctx)
+
+	homeCtx, err = ctx.ForRegion("us-east-1")
+	assert.Nil(err)
+	assert.True(homeCtx == ctx)
+
+	westCtx, err := ctx.ForRegion("us-west-2")
+	assert.Nil(err)
+	assert.Equal("us-west-2", westCtx.Region)
+	assert.Equal("mu", westCtx.Config.Namespace)
+
+	// the managers of a region are only initialized once
+	westCtx2, err := ctx.ForRegion("us-west-2")
+	assert.Nil(err)
+	assert.True(westCtx == westCtx2)
+	assert.Equal([]string{"us-west-2"}, initialized)
+}
+
+func TestContext_ForRegionNoInitializer(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := NewContext()
+	ctx.Region = "us-east-1"
+
+	regionCtx, err := ctx.ForRegion("us-west-2")
+	assert.NotNil(err)
+	assert.Nil(regionCtx)
+}
+
+func TestConfig_GetEnvironmentRegion(t *testing.T) {
+	assert := assert.New(t)
+
+	config := Config{
+		Environments: []Environment{
+			{Name: "dev"},
+			{Name: "prod", Region: "eu-west-1"},
+		},
+		Service: Service{
+			EnvironmentConfig: map[string]ServiceEnvironmentConfig{
+				"staging": {Region: "us-west-2"},
+			},
+		},
+	}
+
+	assert.Equal("", config.GetEnvironmentRegion("dev"))
+	assert.Equal("eu-west-1", config.GetEnvironmentRegion("prod"))
+	assert.Equal("us-west-2", config.GetEnvironmentRegion("staging"))
+	assert.Equal("", config.GetEnvironmentRegion("unknown"))
+}
+
+func TestContext_ForEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := NewContext()
+	ctx.Region = "us-east-1"
+	ctx.AccountID = "111111111111"
+	ctx.Config.Environments = []Environment{
+		{Name: "dev"},
+		{Name: "prod", Account: EnvironmentAccount{RoleArn: "arn:aws:iam::222222222222:role/mu", ExternalID: "x1"}},
+		{Name: "prod-eu", Region: "eu-west-1", Account: EnvironmentAccount{RoleArn: "arn:aws:iam::222222222222:role/mu", ExternalID: "x1"}},
+	}
+
+	initialized := make([]EnvironmentAccount, 0)
+	ctx.SetContextInitializer(func(accountCtx *Context, region string, account EnvironmentAccount) error {
+		initialized = append(initialized, account)
+		accountCtx.Region = region
+		accountCtx.AccountID = "222222222222"
+		return nil
+	})
+
+	devCtx, err := ctx.ForEnvironment("dev")
+	assert.Nil(err)
+	assert.True(devCtx == ctx)
+
+	prodCtx, err := ctx.ForEnvironment("prod")
+	assert.Nil(err)
+	assert.Equal("us-east-1", prodCtx.Region)
+	assert.Equal("222222222222", prodCtx.AccountID)
+
+	prodEuCtx, err := ctx.ForEnvironment("prod-eu")
+	assert.Nil(err)
+	assert.Equal("eu-west-1", prodEuCtx.Region)
+	assert.False(prodEuCtx == prodCtx)
+
+	prodCtx2, err := ctx.ForEnvironment("prod")
+	assert.Nil(err)
+	assert.True(prodCtx == prodCtx2)
+	assert.Len(initialized, 2)
+	assert.Equal("x1", initialized[0].ExternalID)
+}
+
+func TestConfig_GetEnvironmentAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	config := Config{
+		Environments: []Environment{
+			{Name: "dev"},
+			{Name: "prod", Account: EnvironmentAccount{RoleArn: "arn:aws:iam::222222222222:role/mu"}},
+		},
+		Service: Service{
+			EnvironmentConfig: map[string]ServiceEnvironmentConfig{
+				"prod":    {Region: "eu-west-1"},
+				"staging": {Account: EnvironmentAccount{Profile: "staging"}},
+			},
+		},
+	}
+
+	assert.Equal(EnvironmentAccount{}, config.GetEnvironmentAccount("dev"))
+	assert.Equal("arn:aws:iam::222222222222:role/mu", config.GetEnvironmentAccount("prod").RoleArn)
+	assert.Equal("staging", config.GetEnvironmentAccount("staging").Profile)
+	assert.Equal(EnvironmentAccount{}, config.GetEnvironmentAccount("unknown"))
+}
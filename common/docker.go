@@ -3,8 +3,10 @@ package common
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/builder/dockerignore"
 	"github.com/docker/docker/client"
@@ -13,11 +15,21 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// DockerBuildOptions for the optional settings of an image build
+type DockerBuildOptions struct {
+	BuildArgs map[string]string
+	Target    string
+	CacheFrom []string
+	Labels    map[string]string
+	Platform  string
+}
+
 // DockerImageBuilder for creating docker images
 type DockerImageBuilder interface {
-	ImageBuild(contextDir string, serviceName string, relDockerfile string, tags []string, registryAuthConfig map[string]types.AuthConfig, dockerOut io.Writer) error
+	ImageBuild(contextDir string, serviceName string, relDockerfile string, tags []string, buildOptions DockerBuildOptions, registryAuthConfig map[string]types.AuthConfig, dockerOut io.Writer) error
 }
 
 // DockerImagePusher for pushing docker images
@@ -47,13 +59,31 @@ func newClientDockerManager() (DockerManager, error) {
 	}, nil
 }
 
-func (d *clientDockerManager) ImageBuild(contextDir string, serviceName string, relDockerfile string, tags []string, registryAuthConfig map[string]types.AuthConfig, dockerOut io.Writer) error {
+func (d *clientDockerManager) ImageBuild(contextDir string, serviceName string, relDockerfile string, tags []string, buildOptions DockerBuildOptions, registryAuthConfig map[string]types.AuthConfig, dockerOut io.Writer) error {
+	labels := map[string]string{}
+	for key, value := range buildOptions.Labels {
+		labels[key] = value
+	}
+	labels["SERVICE_NAME"] = serviceName
+
+	buildArgs := map[string]*string{}
+	for key, value := range buildOptions.BuildArgs {
+		buildArgs[key] = aws.String(value)
+	}
+
 	options := types.ImageBuildOptions{
 		Tags:        tags,
-		Labels:      map[string]string{"SERVICE_NAME": serviceName},
+		Labels:      labels,
+		BuildArgs:   buildArgs,
+		Target:      buildOptions.Target,
+		CacheFrom:   buildOptions.CacheFrom,
+		Platform:    buildOptions.Platform,
 		AuthConfigs: registryAuthConfig,
 	}
 
+	// the daemon only uses cache images that are already present locally
+	d.pullCacheImages(buildOptions.CacheFrom, registryAuthConfig)
+
 	buildContext, err := createBuildContext(contextDir, relDockerfile)
 	if err != nil {
 		return err
@@ -61,7 +91,7 @@ func (d *clientDockerManager) ImageBuild(contextDir string, serviceName string,
 
 	defer buildContext.Close()
 
-	log.Debugf("Creating image from context dir '%s' with tag '%s'", contextDir, tags)
+	log.Debugf("Creating image from context dir '%s' with tag '%s' target '%s' platform '%s'", contextDir, tags, buildOptions.Target, buildOptions.Platform)
 	resp, err := d.dockerClient.ImageBuild(context.Background(), buildContext, options)
 	if err != nil {
 		return err
@@ -70,6 +100,62 @@ func (d *clientDockerManager) ImageBuild(contextDir string, serviceName string,
 	return handleDockerResponse(resp.Body, dockerOut)
 }
 
+func (d *clientDockerManager) pullCacheImages(images []string, registryAuthConfig map[string]types.AuthConfig) {
+	for _, image := range images {
+		pullOptions := types.ImagePullOptions{}
+		if authConfig, ok := registryAuthConfig[RegistryHost(image)]; ok {
+			encodedAuth, err := json.Marshal(authConfig)
+			if err == nil {
+				pullOptions.RegistryAuth = base64.URLEncoding.EncodeToString(encodedAuth)
+			}
+		}
+
+		log.Debugf("Pulling cache image '%s'", image)
+		resp, err := d.dockerClient.ImagePull(context.Background(), image, pullOptions)
+		if err != nil {
+			log.Debugf("Unable to pull cache image '%s': %v", image, err)
+			continue
+		}
+		if err := handleDockerResponse(resp, nil); err != nil {
+			log.Debugf("Unable to pull cache image '%s': %v", image, err)
+		}
+	}
+}
+
+// ImageRepository strips the tag or digest from an image reference, leaving the port of a registry host in place
+func ImageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	nameStart := strings.LastIndex(image, "/") + 1
+	if i := strings.LastIndex(image[nameStart:], ":"); i >= 0 {
+		return image[:nameStart+i]
+	}
+	return image
+}
+
+// ReadIgnorePatterns reads the exclude patterns of a file in dockerignore syntax, like
+// `.dockerignore` or `.muignore`.  A missing file excludes nothing.
+func ReadIgnorePatterns(ignoreFile string) ([]string, error) {
+	f, err := os.Open(ignoreFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return dockerignore.ReadAll(f)
+}
+
+// IsIgnored returns true if a path, relative to the dir of the ignore file, matches the exclude patterns
+func IsIgnored(relPath string, excludes []string) (bool, error) {
+	if len(excludes) == 0 {
+		return false, nil
+	}
+	return fileutils.Matches(filepath.ToSlash(relPath), excludes)
+}
+
 func createBuildContext(contextDir string, relDockerfile string) (io.ReadCloser, error) {
 	log.Debugf("Creating archive for build context dir '%s' with relative dockerfile '%s'", contextDir, relDockerfile)
 
@@ -79,19 +165,10 @@ func createBuildContext(contextDir string, relDockerfile string) (io.ReadCloser,
 		return nil, fmt.Errorf("cannot canonicalize dockerfile path %s: %v", relDockerfile, err)
 	}
 
-	f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
-	if err != nil && !os.IsNotExist(err) {
+	excludes, err := ReadIgnorePatterns(filepath.Join(contextDir, ".dockerignore"))
+	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	var excludes []string
-	if err == nil {
-		excludes, err = dockerignore.ReadAll(f)
-		if err != nil {
-			return nil, err
-		}
-	}
 
 	// If .dockerignore mentions .dockerignore or the Dockerfile
 	// then make sure we send both files over to the daemon
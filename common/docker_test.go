@@ -0,0 +1,16 @@
+package common
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestImageRepository(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("1234.dkr.ecr.us-west-2.amazonaws.com/foo", ImageRepository("1234.dkr.ecr.us-west-2.amazonaws.com/foo:latest"))
+	assert.Equal("localhost:5000/foo/bar", ImageRepository("localhost:5000/foo/bar:cache"))
+	assert.Equal("localhost:5000/foo", ImageRepository("localhost:5000/foo"))
+	assert.Equal("foo", ImageRepository("foo"))
+	assert.Equal("localhost:5000/foo", ImageRepository("localhost:5000/foo@sha256:abc"))
+}
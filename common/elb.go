@@ -12,7 +12,13 @@ type ElbRuleLister interface {
 	ListRules(listenerArn string) ([]ElbRule, error)
 }
 
+// ElbTargetHealthCounter for counting the healthy targets of a target group
+type ElbTargetHealthCounter interface {
+	CountHealthyTargets(targetGroupArn string) (int, error)
+}
+
 // ElbManager composite of all cluster capabilities
 type ElbManager interface {
 	ElbRuleLister
+	ElbTargetHealthCounter
 }
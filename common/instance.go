@@ -9,10 +9,16 @@ type InstanceLister interface {
 	ListInstances(instanceIds ...string) ([]Instance, error)
 }
 
+// InstanceTerminator for terminating instances
+type InstanceTerminator interface {
+	TerminateInstances(instanceIds ...string) error
+}
+
 // Instance represents and EC2 instance
 type Instance *ec2.Instance
 
 // InstanceManager composite of all instance capabilities
 type InstanceManager interface {
 	InstanceLister
+	InstanceTerminator
 }
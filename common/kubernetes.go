@@ -4,14 +4,21 @@ import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 // KubernetesResourceManagerProvider for providing kubernetes client
 type KubernetesResourceManagerProvider interface {
+	KubernetesClusterVersionGetter
 	GetResourceManager(name string) (KubernetesResourceManager, error)
 }
 
+// KubernetesClusterVersionGetter for getting the kubernetes version of the control plane of a cluster
+type KubernetesClusterVersionGetter interface {
+	GetClusterVersion(name string) (string, error)
+}
+
 // KubernetesResourceManager for managing kubernetes resources
 type KubernetesResourceManager interface {
 	KubernetesResourceUpserter
 	KubernetesResourceLister
 	KubernetesResourceDeleter
+	KubernetesPodExecutor
 }
 
 // KubernetesResourceUpserter for upserting kubernetes resources
@@ -28,3 +35,8 @@ type KubernetesResourceLister interface {
 type KubernetesResourceDeleter interface {
 	DeleteResource(apiVersion string, kind string, namespace string, name string) error
 }
+
+// KubernetesPodExecutor for running commands in the containers of a pod
+type KubernetesPodExecutor interface {
+	ExecPod(namespace string, podName string, containerName string, command []string) error
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var nonAlphaNumeric = regexp.MustCompile("[^a-zA-Z0-9]+")
+
+// ResourceName returns the name of the CloudFormation resource of the node group, like `NodeGroupSpotPool` for `spot-pool`
+func (nodeGroup NodeGroup) ResourceName() string {
+	var b strings.Builder
+	b.WriteString("NodeGroup")
+	for _, part := range nonAlphaNumeric.Split(nodeGroup.Name, -1) {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// CapacityType returns the EKS capacity type of the nodes in the node group
+func (nodeGroup NodeGroup) CapacityType() string {
+	if nodeGroup.Spot {
+		return "SPOT"
+	}
+	return "ON_DEMAND"
+}
+
+// EksEffect converts the kubernetes taint effect, like `NoSchedule`, into the EKS effect, like `NO_SCHEDULE`
+func (taint NodeTaint) EksEffect() string {
+	switch taint.Effect {
+	case "", "NoSchedule":
+		return "NO_SCHEDULE"
+	case "NoExecute":
+		return "NO_EXECUTE"
+	case "PreferNoSchedule":
+		return "PREFER_NO_SCHEDULE"
+	}
+	return strings.ToUpper(taint.Effect)
+}
+
+// ParseKubernetesVersion returns the major and minor parts of a kubernetes version, like `1.14` or `v1.14.9-eks-c0eccc`
+func ParseKubernetesVersion(version string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("Invalid kubernetes version '%s'", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid kubernetes version '%s'", version)
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid kubernetes version '%s'", version)
+	}
+	return major, minor, nil
+}
+
+// KubernetesMinorVersionSkew returns how many minor versions 'version' is ahead of 'base', negative when it is behind
+func KubernetesMinorVersionSkew(base string, version string) (int, error) {
+	baseMajor, baseMinor, err := ParseKubernetesVersion(base)
+	if err != nil {
+		return 0, err
+	}
+	major, minor, err := ParseKubernetesVersion(version)
+	if err != nil {
+		return 0, err
+	}
+	if major != baseMajor {
+		return 0, fmt.Errorf("Unable to compare kubernetes versions '%s' and '%s' of different major versions", base, version)
+	}
+	return minor - baseMinor, nil
+}
+
+// NextKubernetesVersion returns the minor version after 'version', like `1.15` for `1.14`
+func NextKubernetesVersion(version string) (string, error) {
+	major, minor, err := ParseKubernetesVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d", major, minor+1), nil
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeGroup_ResourceName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("NodeGroupSpotPool", NodeGroup{Name: "spot-pool"}.ResourceName())
+	assert.Equal("NodeGroupDefault", NodeGroup{Name: "default"}.ResourceName())
+	assert.Equal("NodeGroupGpu2", NodeGroup{Name: "gpu_2"}.ResourceName())
+}
+
+func TestNodeGroup_CapacityType(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("ON_DEMAND", NodeGroup{}.CapacityType())
+	assert.Equal("SPOT", NodeGroup{Spot: true}.CapacityType())
+}
+
+func TestNodeTaint_EksEffect(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("NO_SCHEDULE", NodeTaint{}.EksEffect())
+	assert.Equal("NO_EXECUTE", NodeTaint{Effect: "NoExecute"}.EksEffect())
+	assert.Equal("PREFER_NO_SCHEDULE", NodeTaint{Effect: "PreferNoSchedule"}.EksEffect())
+	assert.Equal("NO_SCHEDULE", NodeTaint{Effect: "NO_SCHEDULE"}.EksEffect())
+}
+
+func TestKubernetesVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	major, minor, err := ParseKubernetesVersion("v1.14.9-eks-c0eccc")
+	assert.Nil(err)
+	assert.Equal(1, major)
+	assert.Equal(14, minor)
+
+	_, _, err = ParseKubernetesVersion("latest")
+	assert.NotNil(err)
+
+	skew, err := KubernetesMinorVersionSkew("1.15", "v1.13.12-eks-c500e1")
+	assert.Nil(err)
+	assert.Equal(-2, skew)
+
+	next, err := NextKubernetesVersion("1.14")
+	assert.Nil(err)
+	assert.Equal("1.15", next)
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Environment variables read by the `env` registry credentials provider
+const (
+	RegistryUsernameEnv = "MU_REGISTRY_USERNAME"
+	RegistryPasswordEnv = "MU_REGISTRY_PASSWORD"
+)
+
+const dockerHubRegistry = "https://index.docker.io/v1/"
+
+// NewRegistryAuthenticator returns the authenticator for the registry of a service.  The
+// tokens of every authenticator are base64 encoded `username:password` pairs, like ECR tokens.
+func NewRegistryAuthenticator(registry ServiceRegistry, ecrAuthenticator RepositoryAuthenticator, paramGetter ParamGetter) (RepositoryAuthenticator, error) {
+	switch registry.Credentials {
+	case "", RegistryCredentialsEcr:
+		return ecrAuthenticator, nil
+	case RegistryCredentialsDocker:
+		return &dockerConfigAuthenticator{configDir: dockerConfigDir()}, nil
+	case RegistryCredentialsEnv:
+		return &envAuthenticator{}, nil
+	case RegistryCredentialsSsm:
+		if registry.Username == "" || registry.PasswordParameter == "" {
+			return nil, fmt.Errorf("registry credentials from ssm require a username and passwordParameter")
+		}
+		return &paramAuthenticator{
+			paramGetter:       paramGetter,
+			username:          registry.Username,
+			passwordParameter: registry.PasswordParameter,
+		}, nil
+	}
+	return nil, fmt.Errorf("Unknown registry credentials provider '%s'", registry.Credentials)
+}
+
+// IsPrivateRegistry returns true if the images of a service are in a registry other than ECR
+func IsPrivateRegistry(registry ServiceRegistry) bool {
+	return registry.Credentials != "" && registry.Credentials != RegistryCredentialsEcr
+}
+
+// RegistryHost returns the host of the registry for an image, as used in docker config files
+func RegistryHost(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0]
+	}
+	return dockerHubRegistry
+}
+
+func encodeRegistryToken(username string, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+}
+
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".docker")
+}
+
+type dockerConfigAuthenticator struct {
+	configDir string
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore string `json:"credsStore"`
+}
+
+// AuthenticateRepository reads the credentials `docker login` saved for the registry of the repo
+func (auth *dockerConfigAuthenticator) AuthenticateRepository(repoURL string) (string, error) {
+	configFile := filepath.Join(auth.configDir, "config.json")
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return Empty, err
+	}
+
+	config := dockerConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Empty, err
+	}
+
+	host := RegistryHost(repoURL)
+	for registry, entry := range config.Auths {
+		if strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://") == strings.TrimPrefix(host, "https://") && entry.Auth != "" {
+			return entry.Auth, nil
+		}
+	}
+	if config.CredsStore != "" {
+		return Empty, fmt.Errorf("credentials for '%s' are in the '%s' credential store, which isn't supported", host, config.CredsStore)
+	}
+	return Empty, fmt.Errorf("unable to find credentials for '%s' in %s", host, configFile)
+}
+
+type envAuthenticator struct{}
+
+// AuthenticateRepository reads the registry credentials from the environment
+func (auth *envAuthenticator) AuthenticateRepository(repoURL string) (string, error) {
+	username := os.Getenv(RegistryUsernameEnv)
+	password := os.Getenv(RegistryPasswordEnv)
+	if username == "" || password == "" {
+		return Empty, fmt.Errorf("%s and %s must be set to authenticate to '%s'", RegistryUsernameEnv, RegistryPasswordEnv, RegistryHost(repoURL))
+	}
+	return encodeRegistryToken(username, password), nil
+}
+
+type paramAuthenticator struct {
+	paramGetter       ParamGetter
+	username          string
+	passwordParameter string
+}
+
+// AuthenticateRepository reads the registry password from an SSM parameter
+func (auth *paramAuthenticator) AuthenticateRepository(repoURL string) (string, error) {
+	password, err := auth.paramGetter.GetParam(auth.passwordParameter)
+	if err != nil {
+		return Empty, err
+	}
+	return encodeRegistryToken(auth.username, password), nil
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockedParamGetter struct {
+	mock.Mock
+}
+
+func (m *mockedParamGetter) GetParam(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+func TestRegistryHost(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("ghcr.io", RegistryHost("ghcr.io/org/foo:latest"))
+	assert.Equal("localhost:5000", RegistryHost("localhost:5000/foo"))
+	assert.Equal(dockerHubRegistry, RegistryHost("org/foo"))
+	assert.Equal(dockerHubRegistry, RegistryHost("nginx"))
+}
+
+func TestNewRegistryAuthenticator(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewRegistryAuthenticator(ServiceRegistry{Credentials: "bogus"}, nil, nil)
+	assert.NotNil(err)
+
+	_, err = NewRegistryAuthenticator(ServiceRegistry{Credentials: RegistryCredentialsSsm}, nil, nil)
+	assert.NotNil(err)
+
+	paramGetter := new(mockedParamGetter)
+	paramGetter.On("GetParam", "/registry/password").Return("secret", nil)
+	authenticator, err := NewRegistryAuthenticator(ServiceRegistry{
+		Credentials:       RegistryCredentialsSsm,
+		Username:          "bot",
+		PasswordParameter: "/registry/password",
+	}, nil, paramGetter)
+	assert.Nil(err)
+
+	token, err := authenticator.AuthenticateRepository("ghcr.io/org/foo")
+	assert.Nil(err)
+	assert.Equal(base64.StdEncoding.EncodeToString([]byte("bot:secret")), token)
+}
+
+func TestDockerConfigAuthenticator(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "docker-config")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths": {"https://ghcr.io": {"auth": "Ym90OnNlY3JldA=="}}}`), 0600)
+	assert.Nil(err)
+
+	authenticator := &dockerConfigAuthenticator{configDir: dir}
+	token, err := authenticator.AuthenticateRepository("ghcr.io/org/foo:latest")
+	assert.Nil(err)
+	assert.Equal("Ym90OnNlY3JldA==", token)
+
+	_, err = authenticator.AuthenticateRepository("quay.io/org/foo")
+	assert.NotNil(err)
+}
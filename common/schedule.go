@@ -0,0 +1,320 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleState describes the CloudWatch Events rule of a service schedule
+type ScheduleState struct {
+	RuleName       string
+	Expression     string
+	Enabled        bool
+	LastInvocation *time.Time
+}
+
+// ScheduleStateGetter for getting the state of a schedule rule
+type ScheduleStateGetter interface {
+	GetScheduleState(ruleName string) (*ScheduleState, error)
+}
+
+// ScheduleStateSetter for enabling or disabling a schedule rule
+type ScheduleStateSetter interface {
+	SetScheduleState(ruleName string, enabled bool) error
+}
+
+// ScheduleManager composite of all schedule capabilities
+type ScheduleManager interface {
+	ScheduleStateGetter
+	ScheduleStateSetter
+}
+
+// IsEnabled returns whether the schedule should fire, schedules are enabled unless disabled in the config
+func (schedule Schedule) IsEnabled() bool {
+	return schedule.Enabled == nil || *schedule.Enabled
+}
+
+// maximum time to search ahead for the next fire time of a cron expression
+const scheduleSearchLimit = 5 * 366 * 24 * time.Hour
+
+var (
+	cronMonthNames     = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	cronDayOfWeekNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+// NextScheduleTimes computes the next times after 'after' that a CloudWatch schedule expression, like
+// `cron(0 12 * * ? *)` or `rate(5 minutes)`, fires in the location.  Rate expressions fire relative to
+// when their rule was created, so the times for them are counted from 'after'.
+func NextScheduleTimes(expression string, location *time.Location, after time.Time, count int) ([]time.Time, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	expression = strings.TrimSpace(expression)
+
+	if strings.HasPrefix(expression, "rate(") && strings.HasSuffix(expression, ")") {
+		interval, err := scheduleRateInterval(expression)
+		if err != nil {
+			return nil, err
+		}
+		times := []time.Time{}
+		for i := 1; i <= count; i++ {
+			times = append(times, after.Add(time.Duration(i)*interval).In(location))
+		}
+		return times, nil
+	}
+
+	fields, err := scheduleCronFields(expression)
+	if err != nil {
+		return nil, err
+	}
+	ranges := []struct {
+		min   int
+		max   int
+		names []string
+	}{{0, 59, nil}, {0, 23, nil}, {1, 31, nil}, {1, 12, cronMonthNames}, {1, 7, cronDayOfWeekNames}, {1970, 2199, nil}}
+	values := make([]map[int]bool, len(fields))
+	for idx, field := range fields {
+		values[idx], err = parseCronField(field, ranges[idx].min, ranges[idx].max, ranges[idx].names)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cron expression '%s': %v", expression, err)
+		}
+	}
+	minutes, hours, daysOfMonth, months, daysOfWeek, years := values[0], values[1], values[2], values[3], values[4], values[5]
+
+	times := []time.Time{}
+	t := after.In(location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(scheduleSearchLimit)
+	for len(times) < count && t.Before(limit) {
+		var next time.Time
+		switch {
+		case !cronFieldMatches(years, t.Year()):
+			next = time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, location)
+		case !cronFieldMatches(months, int(t.Month())):
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !cronFieldMatches(daysOfMonth, t.Day()) || !cronFieldMatches(daysOfWeek, int(t.Weekday())+1):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case !cronFieldMatches(hours, t.Hour()):
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+		case !cronFieldMatches(minutes, t.Minute()):
+			next = t.Add(time.Minute)
+		default:
+			times = append(times, t)
+			next = t.Add(time.Minute)
+		}
+
+		// daylight saving changes can map a local time back onto an earlier instant
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return times, nil
+}
+
+// ScheduleExpressionUTC converts a cron expression written for a timezone into the equivalent UTC expression
+// that CloudWatch Events expects, using the offset of the timezone at 'now'.  Rate expressions and expressions
+// without a timezone are returned unchanged.
+func ScheduleExpressionUTC(expression string, timezone string, now time.Time) (string, error) {
+	expression = strings.TrimSpace(expression)
+	if timezone == "" || strings.HasPrefix(expression, "rate(") {
+		return expression, nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return "", fmt.Errorf("Invalid timezone '%s': %v", timezone, err)
+	}
+	fields, err := scheduleCronFields(expression)
+	if err != nil {
+		return "", err
+	}
+
+	_, offset := now.In(location).Zone()
+	shift := -offset / 60
+	if shift == 0 {
+		return expression, nil
+	}
+
+	minutes, err := cronNumberList(fields[0], 0, 59)
+	if err != nil {
+		if shift%60 != 0 {
+			return "", fmt.Errorf("Timezone '%s' needs explicit minutes in cron expression '%s'", timezone, expression)
+		}
+		minutes = nil
+	}
+	hours, err := cronNumberList(fields[1], 0, 23)
+	if err != nil {
+		if fields[1] != "*" {
+			return "", fmt.Errorf("Timezone '%s' needs explicit hours in cron expression '%s'", timezone, expression)
+		}
+		hours = nil
+	}
+
+	// shift the minutes, all of them must carry the same number of hours
+	carry := 0
+	if minutes != nil {
+		carry = floorDiv(minutes[0]+shift, 60)
+		for idx, minute := range minutes {
+			if floorDiv(minute+shift, 60) != carry {
+				return "", fmt.Errorf("Unable to convert cron expression '%s' from timezone '%s' to UTC", expression, timezone)
+			}
+			minutes[idx] = floorMod(minute+shift, 60)
+		}
+		fields[0] = joinInts(minutes)
+	} else {
+		carry = shift / 60
+	}
+
+	// shift the hours, all of them must carry the same number of days
+	if hours != nil {
+		days := floorDiv(hours[0]+carry, 24)
+		for idx, hour := range hours {
+			if floorDiv(hour+carry, 24) != days {
+				return "", fmt.Errorf("Unable to convert cron expression '%s' from timezone '%s' to UTC", expression, timezone)
+			}
+			hours[idx] = floorMod(hour+carry, 24)
+		}
+		if days != 0 && (!cronWildcard(fields[2]) || !cronWildcard(fields[4])) {
+			return "", fmt.Errorf("Unable to convert cron expression '%s' from timezone '%s' to UTC, the days would change", expression, timezone)
+		}
+		fields[1] = joinInts(hours)
+	}
+
+	return fmt.Sprintf("cron(%s)", strings.Join(fields, " ")), nil
+}
+
+func scheduleRateInterval(expression string) (time.Duration, error) {
+	fields := strings.Fields(expression[len("rate(") : len(expression)-1])
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("Invalid rate expression '%s'", expression)
+	}
+	value, err := strconv.Atoi(fields[0])
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("Invalid rate expression '%s'", expression)
+	}
+	switch strings.TrimSuffix(fields[1], "s") {
+	case "minute":
+		return time.Duration(value) * time.Minute, nil
+	case "hour":
+		return time.Duration(value) * time.Hour, nil
+	case "day":
+		return time.Duration(value) * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("Invalid rate expression '%s'", expression)
+}
+
+func scheduleCronFields(expression string) ([]string, error) {
+	if !strings.HasPrefix(expression, "cron(") || !strings.HasSuffix(expression, ")") {
+		return nil, fmt.Errorf("Invalid schedule expression '%s'", expression)
+	}
+	fields := strings.Fields(expression[len("cron(") : len(expression)-1])
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("Invalid cron expression '%s'", expression)
+	}
+	return fields, nil
+}
+
+// parseCronField returns the values a field matches, or nil when it matches any value
+func parseCronField(field string, min int, max int, names []string) (map[int]bool, error) {
+	if cronWildcard(field) {
+		return nil, nil
+	}
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		stepped := false
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in '%s'", part)
+			}
+			part = part[:idx]
+			stepped = true
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			start, err = cronFieldValue(bounds[0], min, max, names)
+			if err != nil {
+				return nil, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				end, err = cronFieldValue(bounds[1], min, max, names)
+				if err != nil {
+					return nil, err
+				}
+			} else if stepped {
+				end = max
+			}
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid range '%s'", part)
+		}
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+func cronFieldValue(value string, min int, max int, names []string) (int, error) {
+	for idx, name := range names {
+		if strings.EqualFold(value, name) {
+			return min + idx, nil
+		}
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil && strings.ContainsAny(value, "LW#") {
+		return 0, fmt.Errorf("L, W and # aren't supported in '%s'", value)
+	}
+	if err != nil || number < min || number > max {
+		return 0, fmt.Errorf("invalid value '%s'", value)
+	}
+	return number, nil
+}
+
+func cronFieldMatches(values map[int]bool, value int) bool {
+	return values == nil || values[value]
+}
+
+func cronWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// cronNumberList parses a field that is a plain list of numbers, like `0,30`
+func cronNumberList(field string, min int, max int) ([]int, error) {
+	numbers := []int{}
+	for _, part := range strings.Split(field, ",") {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < min || number > max {
+			return nil, fmt.Errorf("invalid value '%s'", part)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+func joinInts(numbers []int) string {
+	parts := make([]string, len(numbers))
+	for idx, number := range numbers {
+		parts[idx] = strconv.Itoa(number)
+	}
+	return strings.Join(parts, ",")
+}
+
+func floorDiv(a int, b int) int {
+	if a < 0 && a%b != 0 {
+		return a/b - 1
+	}
+	return a / b
+}
+
+func floorMod(a int, b int) int {
+	return a - floorDiv(a, b)*b
+}
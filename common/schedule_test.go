@@ -0,0 +1,106 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextScheduleTimes(t *testing.T) {
+	assert := assert.New(t)
+
+	// a friday
+	after := time.Date(2018, time.June, 1, 10, 0, 0, 0, time.UTC)
+
+	times, err := NextScheduleTimes("cron(0 12 * * ? *)", time.UTC, after, 3)
+	assert.Nil(err)
+	assert.Equal([]time.Time{
+		time.Date(2018, time.June, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2018, time.June, 2, 12, 0, 0, 0, time.UTC),
+		time.Date(2018, time.June, 3, 12, 0, 0, 0, time.UTC),
+	}, times)
+
+	times, err = NextScheduleTimes("cron(30 9 ? * MON-FRI *)", time.UTC, after, 2)
+	assert.Nil(err)
+	assert.Equal([]time.Time{
+		time.Date(2018, time.June, 4, 9, 30, 0, 0, time.UTC),
+		time.Date(2018, time.June, 5, 9, 30, 0, 0, time.UTC),
+	}, times)
+
+	times, err = NextScheduleTimes("cron(0/20 * 15 JUL ? 2018)", time.UTC, after, 4)
+	assert.Nil(err)
+	assert.Equal([]time.Time{
+		time.Date(2018, time.July, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2018, time.July, 15, 0, 20, 0, 0, time.UTC),
+		time.Date(2018, time.July, 15, 0, 40, 0, 0, time.UTC),
+		time.Date(2018, time.July, 15, 1, 0, 0, 0, time.UTC),
+	}, times)
+
+	times, err = NextScheduleTimes("rate(5 minutes)", time.UTC, after, 2)
+	assert.Nil(err)
+	assert.Equal([]time.Time{after.Add(5 * time.Minute), after.Add(10 * time.Minute)}, times)
+
+	eastern := time.FixedZone("EDT", -4*60*60)
+	times, err = NextScheduleTimes("cron(0 9 * * ? *)", eastern, after, 1)
+	assert.Nil(err)
+	assert.Equal(time.Date(2018, time.June, 1, 13, 0, 0, 0, time.UTC), times[0].UTC())
+
+	times, err = NextScheduleTimes("cron(0 12 * * ? 2017)", time.UTC, after, 1)
+	assert.Nil(err)
+	assert.Empty(times)
+
+	_, err = NextScheduleTimes("cron(0 12 L * ? *)", time.UTC, after, 1)
+	assert.NotNil(err)
+	_, err = NextScheduleTimes("cron(0 12 * *)", time.UTC, after, 1)
+	assert.NotNil(err)
+	_, err = NextScheduleTimes("rate(5 weeks)", time.UTC, after, 1)
+	assert.NotNil(err)
+	_, err = NextScheduleTimes("every day", time.UTC, after, 1)
+	assert.NotNil(err)
+}
+
+func TestScheduleExpressionUTC(t *testing.T) {
+	assert := assert.New(t)
+
+	summer := time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	expression, err := ScheduleExpressionUTC("cron(0 9 * * ? *)", "", summer)
+	assert.Nil(err)
+	assert.Equal("cron(0 9 * * ? *)", expression)
+
+	expression, err = ScheduleExpressionUTC("rate(1 hour)", "America/New_York", summer)
+	assert.Nil(err)
+	assert.Equal("rate(1 hour)", expression)
+
+	expression, err = ScheduleExpressionUTC("cron(0 9 * * ? *)", "America/New_York", summer)
+	assert.Nil(err)
+	assert.Equal("cron(0 13 * * ? *)", expression)
+
+	expression, err = ScheduleExpressionUTC("cron(0 22 * * ? *)", "America/New_York", summer)
+	assert.Nil(err)
+	assert.Equal("cron(0 2 * * ? *)", expression)
+
+	expression, err = ScheduleExpressionUTC("cron(0 9 * * ? *)", "Asia/Kolkata", summer)
+	assert.Nil(err)
+	assert.Equal("cron(30 3 * * ? *)", expression)
+
+	expression, err = ScheduleExpressionUTC("cron(0/15 * * * ? *)", "America/New_York", summer)
+	assert.Nil(err)
+	assert.Equal("cron(0/15 * * * ? *)", expression)
+
+	// the day of the week would change
+	_, err = ScheduleExpressionUTC("cron(0 22 ? * MON-FRI *)", "America/New_York", summer)
+	assert.NotNil(err)
+
+	_, err = ScheduleExpressionUTC("cron(0 9 * * ? *)", "Nowhere/Special", summer)
+	assert.NotNil(err)
+}
+
+func TestSchedule_IsEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	disabled := false
+	assert.True(Schedule{}.IsEnabled())
+	assert.False(Schedule{Enabled: &disabled}.IsEnabled())
+}
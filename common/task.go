@@ -1,6 +1,8 @@
 package common
 
 import (
+	"fmt"
+
 	"github.com/aws/aws-sdk-go/service/ecs"
 )
 
@@ -22,9 +24,40 @@ type TaskCommandExecutor interface {
 	ExecuteCommand(namespace string, task Task) (ECSRunTaskResult, error)
 }
 
+// TaskRunStatus describes the state of a task started by ExecuteCommand
+type TaskRunStatus struct {
+	TaskArn    string
+	Status     string
+	StopReason string
+	ExitCode   *int64
+	LogStream  string
+}
+
+// TaskRunDescriber for checking on tasks started by ExecuteCommand
+type TaskRunDescriber interface {
+	DescribeTaskRun(cluster string, taskArn string) (*TaskRunStatus, error)
+}
+
+// TaskShellOpener for opening interactive sessions in running tasks
+type TaskShellOpener interface {
+	OpenShell(namespace string, environment string, taskName string, containerName string, command []string) error
+}
+
 // TaskManager composite of all task capabilities
 type TaskManager interface {
 	TaskContainerLister
 	TaskStopper
 	TaskCommandExecutor
+	TaskRunDescriber
+	TaskShellOpener
+}
+
+// TaskExitError is returned when a command exits with a non-zero exit code
+type TaskExitError struct {
+	TaskName string
+	ExitCode int
+}
+
+func (e *TaskExitError) Error() string {
+	return fmt.Sprintf("Task %s exited with code %d", e.TaskName, e.ExitCode)
 }
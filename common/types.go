@@ -3,6 +3,7 @@ package common
 import (
 	"fmt"
 	"io"
+	"sync"
 	"time"
 )
 
@@ -12,10 +13,13 @@ type Context struct {
 	AccountID                         string
 	Partition                         string
 	Region                            string
+	AssumeRole                        string // role of `--assume-role`, which replaces the accounts of the environments
 	StackManager                      StackManager
 	ClusterManager                    ClusterManager
 	InstanceManager                   InstanceManager
 	ElbManager                        ElbManager
+	AutoscalingManager                AutoscalingManager
+	ScheduleManager                   ScheduleManager
 	RdsManager                        RdsManager
 	ParamManager                      ParamManager
 	LocalPipelineManager              PipelineManager // instance that ignores region/profile/role
@@ -30,6 +34,10 @@ type Context struct {
 	RolesetManager                    RolesetManager
 	ExtensionsManager                 ExtensionsManager
 	CatalogManager                    CatalogManager
+
+	contextInitializer ContextInitializer
+	contexts           map[string]*Context
+	contextsLock       sync.Mutex
 }
 
 // Config defines the structure of the yml file for the mu config
@@ -71,10 +79,21 @@ type Catalog struct {
 
 // RoleBinding defines how to map k8s roles to subjects
 type RoleBinding struct {
-	Role         RBACRole `yaml:"role,omitempty"`
-	Environments []string `yaml:"environments,omitempty"`
-	Users        []string `yaml:"users,omitempty"`
-	Services     []string `yaml:"services,omitempty"`
+	Role         RBACRole   `yaml:"role,omitempty"`
+	Rules        []RBACRule `yaml:"rules,omitempty"`
+	Namespaces   []string   `yaml:"namespaces,omitempty"`
+	Environments []string   `yaml:"environments,omitempty"`
+	Users        []string   `yaml:"users,omitempty"`
+	IAMRoles     []string   `yaml:"iamRoles,omitempty"`
+	Services     []string   `yaml:"services,omitempty"`
+}
+
+// RBACRule defines the structure of the yml file for a rule of a custom k8s role
+type RBACRule struct {
+	APIGroups     []string `yaml:"apiGroups,omitempty"`
+	Resources     []string `yaml:"resources,omitempty"`
+	ResourceNames []string `yaml:"resourceNames,omitempty"`
+	Verbs         []string `yaml:"verbs,omitempty"`
 }
 
 // RBACRole describes possible rbac roles
@@ -95,10 +114,12 @@ type Extension struct {
 
 // Environment defines the structure of the yml file for an environment
 type Environment struct {
-	Name         string       `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
-	Provider     EnvProvider  `yaml:"provider,omitempty"`
-	Loadbalancer Loadbalancer `yaml:"loadbalancer,omitempty"`
-	Cluster      Cluster      `yaml:"cluster,omitempty"`
+	Name         string             `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	Provider     EnvProvider        `yaml:"provider,omitempty"`
+	Region       string             `yaml:"region,omitempty"`
+	Account      EnvironmentAccount `yaml:"account,omitempty"`
+	Loadbalancer Loadbalancer       `yaml:"loadbalancer,omitempty"`
+	Cluster      Cluster            `yaml:"cluster,omitempty"`
 	Discovery    struct {
 		Provider string `yaml:"provider,omitempty"`
 		Name     string `yaml:"name,omitempty"`
@@ -107,13 +128,21 @@ type Environment struct {
 	Roles     EnvironmentRoles `yaml:"roles,omitempty"`
 }
 
+// EnvironmentAccount defines how to reach an environment in another AWS account
+type EnvironmentAccount struct {
+	RoleArn    string `yaml:"roleArn,omitempty" validate:"validateRoleARN"`
+	ExternalID string `yaml:"externalId,omitempty"`
+	Profile    string `yaml:"profile,omitempty"`
+}
+
 // Loadbalancer defines the scructure of the yml file for a loadbalancer
 type Loadbalancer struct {
-	HostedZone  string `yaml:"hostedzone,omitempty" validate:"validateURL"`
-	Name        string `yaml:"name,omitempty"  validate:"validateLeadingAlphaNumericDash=32"`
-	Certificate string `yaml:"certificate,omitempty"`
-	Internal    bool   `yaml:"internal,omitempty"`
-	AccessLogs  struct {
+	HostedZone        string            `yaml:"hostedzone,omitempty" validate:"validateURL"`
+	Name              string            `yaml:"name,omitempty"  validate:"validateLeadingAlphaNumericDash=32"`
+	Certificate       string            `yaml:"certificate,omitempty"`
+	Internal          bool              `yaml:"internal,omitempty"`
+	IngressController IngressController `yaml:"ingressController,omitempty"`
+	AccessLogs        struct {
 		S3BucketName string `yaml:"s3BucketName,omitempty"`
 		S3Prefix     string `yaml:"s3Prefix,omitempty"`
 	} `yaml:"accessLogs,omitempty"`
@@ -121,19 +150,66 @@ type Loadbalancer struct {
 
 // Cluster defines the scructure of the yml file for a cluster of EC2 instance AWS::AutoScaling::LaunchConfiguration
 type Cluster struct {
-	InstanceType            string          `yaml:"instanceType,omitempty" validate:"validateInstanceType"`
-	ImageID                 string          `yaml:"imageId,omitempty" validate:"validateResourceID=ami"`
-	ImageOsType             string          `yaml:"osType,omitempty"`
-	InstanceTenancy         InstanceTenancy `yaml:"instanceTenancy,omitempty"`
-	DesiredCapacity         int             `yaml:"desiredCapacity,omitempty"`
-	MinSize                 int             `yaml:"minSize,omitempty"`
-	MaxSize                 int             `yaml:"maxSize,omitempty"`
-	KeyName                 string          `yaml:"keyName,omitempty"`
-	SSHAllow                string          `yaml:"sshAllow,omitempty" validate:"validateCIDR"`
-	TargetCPUReservation    int             `yaml:"targetCPUReservation,omitempty" validate:"max=100"`
-	TargetMemoryReservation int             `yaml:"targetMemoryReservation,omitempty" validate:"max=100"`
-	HTTPProxy               string          `yaml:"httpProxy,omitempty"  validate:"validateURL"`
-	ExtraUserData           string          `yaml:"extraUserData,omitempty"`
+	InstanceType            string                   `yaml:"instanceType,omitempty" validate:"validateInstanceType"`
+	ImageID                 string                   `yaml:"imageId,omitempty" validate:"validateResourceID=ami"`
+	ImageOsType             string                   `yaml:"osType,omitempty"`
+	InstanceTenancy         InstanceTenancy          `yaml:"instanceTenancy,omitempty"`
+	DesiredCapacity         int                      `yaml:"desiredCapacity,omitempty"`
+	MinSize                 int                      `yaml:"minSize,omitempty"`
+	MaxSize                 int                      `yaml:"maxSize,omitempty"`
+	KeyName                 string                   `yaml:"keyName,omitempty"`
+	SSHAllow                string                   `yaml:"sshAllow,omitempty" validate:"validateCIDR"`
+	TargetCPUReservation    int                      `yaml:"targetCPUReservation,omitempty" validate:"max=100"`
+	TargetMemoryReservation int                      `yaml:"targetMemoryReservation,omitempty" validate:"max=100"`
+	HTTPProxy               string                   `yaml:"httpProxy,omitempty"  validate:"validateURL"`
+	ExtraUserData           string                   `yaml:"extraUserData,omitempty"`
+	InstanceTypes           []string                 `yaml:"instanceTypes,omitempty"`
+	Spot                    *ClusterSpot             `yaml:"spot,omitempty"`
+	CapacityProvider        *ClusterCapacityProvider `yaml:"capacityProvider,omitempty"`
+	KubernetesVersion       string                   `yaml:"kubernetesVersion,omitempty"`
+	NodeGroups              []NodeGroup              `yaml:"nodeGroups,omitempty"`
+}
+
+// ClusterSpot defines the structure of the yml file for the spot capacity of a cluster
+type ClusterSpot struct {
+	OnDemandBase       int                    `yaml:"onDemandBase,omitempty"`
+	OnDemandPercent    int                    `yaml:"onDemandPercent,omitempty" validate:"max=100"`
+	AllocationStrategy SpotAllocationStrategy `yaml:"allocationStrategy,omitempty"`
+}
+
+// SpotAllocationStrategy describes how spot instances are allocated across the instance types
+type SpotAllocationStrategy string
+
+// List of valid spot allocation strategies
+const (
+	SpotAllocationStrategyCapacityOptimized SpotAllocationStrategy = "capacity-optimized"
+	SpotAllocationStrategyLowestPrice                              = "lowest-price"
+)
+
+// ClusterCapacityProvider defines the structure of the yml file for the managed scaling of an ECS cluster
+type ClusterCapacityProvider struct {
+	TargetCapacity         int `yaml:"targetCapacity,omitempty" validate:"max=100"`
+	MinimumScalingStepSize int `yaml:"minimumScalingStepSize,omitempty"`
+	MaximumScalingStepSize int `yaml:"maximumScalingStepSize,omitempty"`
+}
+
+// NodeGroup defines the structure of the yml file for a pool of EKS managed nodes
+type NodeGroup struct {
+	Name          string            `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	InstanceTypes []string          `yaml:"instanceTypes,omitempty"`
+	DesiredSize   int               `yaml:"desiredSize,omitempty"`
+	MinSize       int               `yaml:"minSize,omitempty"`
+	MaxSize       int               `yaml:"maxSize,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Taints        []NodeTaint       `yaml:"taints,omitempty"`
+	Spot          bool              `yaml:"spot,omitempty"`
+}
+
+// NodeTaint defines the structure of the yml file for a taint of the nodes in a node group
+type NodeTaint struct {
+	Key    string `yaml:"key,omitempty"`
+	Value  string `yaml:"value,omitempty"`
+	Effect string `yaml:"effect,omitempty"`
 }
 
 // VpcTarget defines the structure of the yml file for a cluster VPC
@@ -153,40 +229,153 @@ type EnvironmentRoles struct {
 
 // Service defines the structure of the yml file for a service
 type Service struct {
-	Name                 string                 `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
-	DeploymentStrategy   DeploymentStrategy     `yaml:"deploymentStrategy,omitempty"`
-	DesiredCount         int                    `yaml:"desiredCount,omitempty"`
-	MinSize              int                    `yaml:"minSize,omitempty"`
-	MaxSize              int                    `yaml:"maxSize,omitempty"`
-	Dockerfile           string                 `yaml:"dockerfile,omitempty"`
-	ImageRepository      string                 `yaml:"imageRepository,omitempty"`
-	Port                 int                    `yaml:"port,omitempty" validate:"max=65535"`
-	Protocol             ServiceProtocol        `yaml:"protocol,omitempty"`
-	HealthEndpoint       string                 `yaml:"healthEndpoint,omitempty" validate:"validateURL"`
-	CPU                  int                    `yaml:"cpu,omitempty"`
-	Memory               int                    `yaml:"memory,omitempty"`
-	NetworkMode          NetworkMode            `yaml:"networkMode,omitempty"`
-	AssignPublicIP       bool                   `yaml:"assignPublicIp,omitempty"`
-	Links                []string               `yaml:"links,omitempty"`
-	Environment          map[string]interface{} `yaml:"environment,omitempty"`
-	PathPatterns         []string               `yaml:"pathPatterns,omitempty"`
-	HostPatterns         []string               `yaml:"hostPatterns,omitempty"`
-	Priority             int                    `yaml:"priority,omitempty" validate:"max=50000"`
-	Pipeline             Pipeline               `yaml:"pipeline,omitempty"`
-	Database             Database               `yaml:"database,omitempty"`
-	Schedule             []Schedule             `yaml:"schedules,omitempty"`
-	TargetCPUUtilization int                    `yaml:"targetCPUUtilization,omitempty" validate:"max=100"`
-	DiscoveryTTL         string                 `yaml:"discoveryTTL,omitempty"`
-	Roles                struct {
+	Name                     string                              `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	DeploymentStrategy       DeploymentStrategy                  `yaml:"deploymentStrategy,omitempty"`
+	DesiredCount             int                                 `yaml:"desiredCount,omitempty"`
+	MinSize                  int                                 `yaml:"minSize,omitempty"`
+	MaxSize                  int                                 `yaml:"maxSize,omitempty"`
+	Dockerfile               string                              `yaml:"dockerfile,omitempty"`
+	Build                    ServiceBuild                        `yaml:"build,omitempty"`
+	ImageRepository          string                              `yaml:"imageRepository,omitempty"`
+	Registry                 ServiceRegistry                     `yaml:"registry,omitempty"`
+	Port                     int                                 `yaml:"port,omitempty" validate:"max=65535"`
+	Protocol                 ServiceProtocol                     `yaml:"protocol,omitempty"`
+	ProtocolVersion          ProtocolVersion                     `yaml:"protocolVersion,omitempty"`
+	HealthEndpoint           string                              `yaml:"healthEndpoint,omitempty" validate:"validateURL"`
+	HealthCheck              ServiceHealthCheck                  `yaml:"healthCheck,omitempty"`
+	Ports                    []ServicePort                       `yaml:"ports,omitempty"`
+	CPU                      int                                 `yaml:"cpu,omitempty"`
+	Memory                   int                                 `yaml:"memory,omitempty"`
+	NetworkMode              NetworkMode                         `yaml:"networkMode,omitempty"`
+	AssignPublicIP           bool                                `yaml:"assignPublicIp,omitempty"`
+	Links                    []string                            `yaml:"links,omitempty"`
+	Environment              map[string]interface{}              `yaml:"environment,omitempty"`
+	Secrets                  map[string]string                   `yaml:"secrets,omitempty"`
+	PathPatterns             []string                            `yaml:"pathPatterns,omitempty"`
+	HostPatterns             []string                            `yaml:"hostPatterns,omitempty"`
+	Priority                 int                                 `yaml:"priority,omitempty" validate:"max=50000"`
+	Pipeline                 Pipeline                            `yaml:"pipeline,omitempty"`
+	Database                 Database                            `yaml:"database,omitempty"`
+	Schedule                 []Schedule                          `yaml:"schedules,omitempty"`
+	Sidecars                 []Sidecar                           `yaml:"sidecars,omitempty"`
+	TargetCPUUtilization     int                                 `yaml:"targetCPUUtilization,omitempty" validate:"max=100"`
+	Autoscaling              ServiceAutoscaling                  `yaml:"autoscaling,omitempty"`
+	DiscoveryTTL             string                              `yaml:"discoveryTTL,omitempty"`
+	EnableExec               bool                                `yaml:"enableExec,omitempty"`
+	CapacityProviderStrategy []CapacityProviderStrategyItem      `yaml:"capacityProviderStrategy,omitempty"`
+	EnvironmentConfig        map[string]ServiceEnvironmentConfig `yaml:"environmentConfig,omitempty"`
+	Roles                    struct {
 		Ec2Instance            string `yaml:"ec2Instance,omitempty" validate:"validateRoleARN"`
 		CodeDeploy             string `yaml:"codeDeploy,omitempty" validate:"validateRoleARN"`
 		EcsEvents              string `yaml:"ecsEvents,omitempty" validate:"validateRoleARN"`
+		Ec2Events              string `yaml:"ec2Events,omitempty" validate:"validateRoleARN"`
 		EcsService             string `yaml:"ecsService,omitempty" validate:"validateRoleARN"`
 		EcsTask                string `yaml:"ecsTask,omitempty" validate:"validateRoleARN"`
+		EksPod                 string `yaml:"eksPod,omitempty" validate:"validateRoleARN"`
 		ApplicationAutoScaling string `yaml:"applicationAutoScaling,omitempty" validate:"validateRoleARN"`
 	} `yaml:"roles,omitempty"`
 }
 
+// ServiceEnvironmentConfig defines the settings of a service for one environment
+type ServiceEnvironmentConfig struct {
+	Region  string             `yaml:"region,omitempty"`
+	Account EnvironmentAccount `yaml:"account,omitempty"`
+}
+
+// CapacityProviderStrategyItem defines how many tasks of a service are placed on a capacity provider
+type CapacityProviderStrategyItem struct {
+	CapacityProvider CapacityProvider `yaml:"capacityProvider,omitempty"`
+	Base             int              `yaml:"base,omitempty"`
+	Weight           int              `yaml:"weight,omitempty"`
+}
+
+// CapacityProvider describes the capacity the tasks of a service are placed on
+type CapacityProvider string
+
+// List of valid capacity providers
+const (
+	CapacityProviderEc2         CapacityProvider = "EC2"
+	CapacityProviderFargate                      = "FARGATE"
+	CapacityProviderFargateSpot                  = "FARGATE_SPOT"
+)
+
+// ServiceBuild defines how the docker image of a service is built by `mu svc push`
+type ServiceBuild struct {
+	Args      map[string]string `yaml:"args,omitempty"`
+	Target    string            `yaml:"target,omitempty"`
+	CacheFrom []string          `yaml:"cacheFrom,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+	Platform  string            `yaml:"platform,omitempty"`
+}
+
+// ServiceRegistry defines the credentials of a private registry the images of a service
+// are pushed to and pulled from, when `imageRepository` isn't in ECR
+type ServiceRegistry struct {
+	Credentials       RegistryCredentialsProvider `yaml:"credentials,omitempty"`
+	Username          string                      `yaml:"username,omitempty"`
+	PasswordParameter string                      `yaml:"passwordParameter,omitempty"`
+	PullSecretArn     string                      `yaml:"pullSecretArn,omitempty"`
+}
+
+// ServiceAutoscaling defines the scaling policies of a service in addition to `targetCPUUtilization`
+type ServiceAutoscaling struct {
+	TargetMemoryUtilization int                      `yaml:"targetMemoryUtilization,omitempty" validate:"max=100"`
+	TargetRequestCount      int                      `yaml:"targetRequestCount,omitempty"`
+	ScaleInCooldown         int                      `yaml:"scaleInCooldown,omitempty"`
+	ScaleOutCooldown        int                      `yaml:"scaleOutCooldown,omitempty"`
+	StepScaling             []StepScalingPolicy      `yaml:"stepScaling,omitempty"`
+	Schedules               []ScheduledScalingAction `yaml:"schedules,omitempty"`
+}
+
+// StepScalingPolicy adjusts the capacity of a service when a CloudWatch alarm on a metric is breached
+type StepScalingPolicy struct {
+	Name               string            `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	Namespace          string            `yaml:"namespace,omitempty"`
+	MetricName         string            `yaml:"metricName,omitempty"`
+	Dimensions         map[string]string `yaml:"dimensions,omitempty"`
+	Statistic          string            `yaml:"statistic,omitempty"`
+	Period             int               `yaml:"period,omitempty"`
+	EvaluationPeriods  int               `yaml:"evaluationPeriods,omitempty"`
+	Threshold          float64           `yaml:"threshold,omitempty"`
+	ComparisonOperator string            `yaml:"comparisonOperator,omitempty"`
+	Adjustment         int               `yaml:"adjustment,omitempty"`
+	Cooldown           int               `yaml:"cooldown,omitempty"`
+}
+
+// ScheduledScalingAction changes the capacity of a service on a schedule
+type ScheduledScalingAction struct {
+	Name       string `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	Expression string `yaml:"expression,omitempty"`
+	MinSize    *int   `yaml:"minSize,omitempty"`
+	MaxSize    *int   `yaml:"maxSize,omitempty"`
+}
+
+// ServiceHealthCheck defines how the load balancer checks the health of a service
+type ServiceHealthCheck struct {
+	Interval            int    `yaml:"interval,omitempty" validate:"max=300"`
+	Timeout             int    `yaml:"timeout,omitempty" validate:"max=120"`
+	HealthyThreshold    int    `yaml:"healthyThreshold,omitempty" validate:"max=10"`
+	UnhealthyThreshold  int    `yaml:"unhealthyThreshold,omitempty" validate:"max=10"`
+	Matcher             string `yaml:"matcher,omitempty"`
+	GracePeriod         int    `yaml:"gracePeriod,omitempty"`
+	DeregistrationDelay int    `yaml:"deregistrationDelay,omitempty" validate:"max=3600"`
+	SlowStart           int    `yaml:"slowStart,omitempty" validate:"max=900"`
+	Stickiness          bool   `yaml:"stickiness,omitempty"`
+	StickinessDuration  int    `yaml:"stickinessDuration,omitempty" validate:"max=604800"`
+}
+
+// ServicePort defines an additional port of a service that is routed to its own target group
+type ServicePort struct {
+	Name            string          `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	Port            int             `yaml:"port,omitempty" validate:"max=65535"`
+	Protocol        ServiceProtocol `yaml:"protocol,omitempty"`
+	ProtocolVersion ProtocolVersion `yaml:"protocolVersion,omitempty"`
+	HealthEndpoint  string          `yaml:"healthEndpoint,omitempty" validate:"validateURL"`
+	PathPatterns    []string        `yaml:"pathPatterns,omitempty"`
+	HostPatterns    []string        `yaml:"hostPatterns,omitempty"`
+	Priority        int             `yaml:"priority,omitempty" validate:"max=50000"`
+}
+
 // Database definition
 type Database struct {
 	DatabaseConfig    `yaml:",inline"`
@@ -241,9 +430,51 @@ func (database *Database) GetDatabaseConfig(environmentName string) *DatabaseCon
 type Schedule struct {
 	Name       string   `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
 	Expression string   `yaml:"expression,omitempty"`
+	Timezone   string   `yaml:"timezone,omitempty"`
+	Enabled    *bool    `yaml:"enabled,omitempty"`
 	Command    []string `yaml:"command,omitempty"`
 }
 
+// Sidecar defines an additional container that runs alongside the service container
+type Sidecar struct {
+	Name        string                `yaml:"name,omitempty" validate:"validateLeadingAlphaNumericDash"`
+	Image       string                `yaml:"image,omitempty" validate:"validateDockerImage"`
+	Dockerfile  string                `yaml:"dockerfile,omitempty"`
+	CPU         int                   `yaml:"cpu,omitempty"`
+	Memory      int                   `yaml:"memory,omitempty"`
+	Environment map[string]string     `yaml:"environment,omitempty"`
+	Ports       []int                 `yaml:"ports,omitempty"`
+	Essential   bool                  `yaml:"essential,omitempty"`
+	DependsOn   []ContainerDependency `yaml:"dependsOn,omitempty"`
+	HealthCheck *ContainerHealthCheck `yaml:"healthCheck,omitempty"`
+}
+
+// ContainerDependency defines the condition a container waits on before starting
+type ContainerDependency struct {
+	Container string                       `yaml:"container,omitempty"`
+	Condition ContainerDependencyCondition `yaml:"condition,omitempty"`
+}
+
+// ContainerDependencyCondition describes the state a dependency must reach
+type ContainerDependencyCondition string
+
+// List of supported container dependency conditions
+const (
+	ContainerDependencyStart    ContainerDependencyCondition = "START"
+	ContainerDependencyComplete ContainerDependencyCondition = "COMPLETE"
+	ContainerDependencySuccess  ContainerDependencyCondition = "SUCCESS"
+	ContainerDependencyHealthy  ContainerDependencyCondition = "HEALTHY"
+)
+
+// ContainerHealthCheck defines a command based health check for a container
+type ContainerHealthCheck struct {
+	Command     []string `yaml:"command,omitempty"`
+	Interval    int      `yaml:"interval,omitempty"`
+	Timeout     int      `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod int      `yaml:"startPeriod,omitempty"`
+}
+
 // Pipeline definition
 type Pipeline struct {
 	Catalog struct {
@@ -404,6 +635,7 @@ const (
 	TemplatePipeline                = "cloudformation/pipeline.yml"
 	TemplateRepo                    = "cloudformation/repo.yml"
 	TemplateSchedule                = "cloudformation/schedule.yml"
+	TemplateScheduleEC2             = "cloudformation/schedule-ec2.yml"
 	TemplateServiceEC2              = "cloudformation/service-ec2.yml"
 	TemplateServiceECS              = "cloudformation/service-ecs.yml"
 	TemplateServiceIAM              = "cloudformation/service-iam.yml"
@@ -413,6 +645,13 @@ const (
 	TemplateK8sDeployment           = "kubernetes/deployment.yml"
 	TemplateK8sDatabase             = "kubernetes/database.yml"
 	TemplateK8sIngress              = "kubernetes/ingress.yml"
+	TemplateK8sAlbIngress           = "kubernetes/ingress-alb.yml"
+	TemplateK8sSecrets              = "kubernetes/secrets.yml"
+	TemplateK8sRegistry             = "kubernetes/registry.yml"
+	TemplateK8sRestart              = "kubernetes/restart.yml"
+	TemplateK8sJob                  = "kubernetes/job.yml"
+	TemplateK8sCronJob              = "kubernetes/cronjob.yml"
+	TemplateK8sSuspend              = "kubernetes/cronjob-suspend.yml"
 	TemplateArtifactPipeline        = "cloudformation/artifact-pipeline.yml"
 )
 
@@ -448,6 +687,26 @@ const (
 	InstanceTenancyDefault   = "default"
 )
 
+// IngressController describes the supported ingress controllers of kubernetes environments
+type IngressController string
+
+// List of valid ingress controllers
+const (
+	IngressControllerNginx IngressController = "nginx"
+	IngressControllerAlb                     = "alb"
+)
+
+// RegistryCredentialsProvider describes where the credentials of an image registry come from
+type RegistryCredentialsProvider string
+
+// List of valid registry credentials providers
+const (
+	RegistryCredentialsEcr    RegistryCredentialsProvider = "ecr"
+	RegistryCredentialsDocker                             = "docker"
+	RegistryCredentialsEnv                                = "env"
+	RegistryCredentialsSsm                                = "ssm"
+)
+
 // ArtifactProvider describes supported artifact strategies
 type ArtifactProvider string
 
@@ -466,6 +725,16 @@ const (
 	ServiceProtocolHTTPS = "HTTPS"
 )
 
+// ProtocolVersion describes the protocol version used between the load balancer and a service
+type ProtocolVersion string
+
+// List of supported protocol versions
+const (
+	ProtocolVersionHTTP1 = "HTTP1"
+	ProtocolVersionHTTP2 = "HTTP2"
+	ProtocolVersionGRPC  = "GRPC"
+)
+
 // NetworkMode describes the ecs docker network mode
 type NetworkMode string
 
@@ -512,6 +781,10 @@ type Task struct {
 	Cluster        string
 	Command        []string
 	Containers     []Container
+	Cpu            int
+	Memory         int
+	EnvVars        map[string]string
+	TaskRoleArn    string
 }
 
 // JSONOutput common json definition
@@ -16,6 +16,49 @@ func (config *Config) Validate() error {
 	return validator.Validate(config)
 }
 
+// UnsupportedFields returns the fields of the service config that are ignored on environments of the provider
+func (service *Service) UnsupportedFields(provider EnvProvider) []string {
+	fields := []string{}
+	unsupported := func(set bool, field string) {
+		if set {
+			fields = append(fields, field)
+		}
+	}
+
+	autoscaling := service.Autoscaling
+	switch provider {
+	case EnvProviderEc2:
+		unsupported(service.CPU != 0, "cpu")
+		unsupported(service.Memory != 0, "memory")
+		unsupported(service.NetworkMode != "", "networkMode")
+		unsupported(service.AssignPublicIP, "assignPublicIp")
+		unsupported(len(service.Links) > 0, "links")
+		unsupported(len(service.Sidecars) > 0, "sidecars")
+		unsupported(service.DiscoveryTTL != "", "discoveryTTL")
+		unsupported(service.EnableExec, "enableExec")
+		unsupported(autoscaling.TargetMemoryUtilization != 0, "autoscaling.targetMemoryUtilization")
+		unsupported(autoscaling.TargetRequestCount != 0, "autoscaling.targetRequestCount")
+		unsupported(len(autoscaling.StepScaling) > 0, "autoscaling.stepScaling")
+		unsupported(len(autoscaling.Schedules) > 0, "autoscaling.schedules")
+	case EnvProviderEks, EnvProviderEksFargate:
+		unsupported(service.NetworkMode != "", "networkMode")
+		unsupported(service.AssignPublicIP, "assignPublicIp")
+		unsupported(len(service.Links) > 0, "links")
+		unsupported(service.Priority != 0, "priority")
+		unsupported(service.DiscoveryTTL != "", "discoveryTTL")
+		unsupported(service.EnableExec, "enableExec")
+		unsupported(autoscaling.TargetRequestCount != 0, "autoscaling.targetRequestCount")
+		unsupported(autoscaling.ScaleInCooldown != 0, "autoscaling.scaleInCooldown")
+		unsupported(autoscaling.ScaleOutCooldown != 0, "autoscaling.scaleOutCooldown")
+		unsupported(len(autoscaling.StepScaling) > 0, "autoscaling.stepScaling")
+		unsupported(len(autoscaling.Schedules) > 0, "autoscaling.schedules")
+	case EnvProviderEcsFargate:
+		unsupported(service.NetworkMode != "" && service.NetworkMode != NetworkModeAwsVpc, "networkMode")
+		unsupported(len(service.Links) > 0, "links")
+	}
+	return fields
+}
+
 // Validators registers the custom validators with the default validator
 func validators() {
 	validator.SetValidationFunc("validateRoleARN", validateRoleARN)
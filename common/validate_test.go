@@ -370,3 +370,22 @@ func TestValidateConfigEnvironmentRoles(t *testing.T) {
 	assert.Nil(configEmpty.Validate())
 	assert.Nil(config.Validate())
 }
+
+func TestService_UnsupportedFields(t *testing.T) {
+	assert := assert.New(t)
+
+	service := &Service{
+		CPU:          512,
+		NetworkMode:  NetworkModeBridge,
+		Links:        []string{"db"},
+		DiscoveryTTL: "10",
+		Autoscaling: ServiceAutoscaling{
+			TargetRequestCount: 100,
+		},
+	}
+
+	assert.Empty(service.UnsupportedFields(EnvProviderEcs))
+	assert.Equal([]string{"networkMode", "links"}, service.UnsupportedFields(EnvProviderEcsFargate))
+	assert.Equal([]string{"cpu", "networkMode", "links", "discoveryTTL", "autoscaling.targetRequestCount"}, service.UnsupportedFields(EnvProviderEc2))
+	assert.Equal([]string{"networkMode", "links", "discoveryTTL", "autoscaling.targetRequestCount"}, service.UnsupportedFields(EnvProviderEks))
+}
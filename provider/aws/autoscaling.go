@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/stelligent/mu/common"
+)
+
+type autoscalingManager struct {
+	autoscalingAPI applicationautoscalingiface.ApplicationAutoScalingAPI
+}
+
+func newAutoscalingManager(sess *session.Session) (common.AutoscalingManager, error) {
+	log.Debug("Connecting to Application Auto Scaling service")
+	autoscalingAPI := applicationautoscaling.New(sess)
+
+	return &autoscalingManager{
+		autoscalingAPI: autoscalingAPI,
+	}, nil
+}
+
+// GetScalingState get the capacity, policies and last activity of an ECS service
+func (autoscalingMgr *autoscalingManager) GetScalingState(clusterName string, serviceName string) (*common.ScalingState, error) {
+	autoscalingAPI := autoscalingMgr.autoscalingAPI
+
+	resourceID := fmt.Sprintf("service/%s/%s", clusterName, serviceName)
+	log.Debugf("Searching for scaling state of '%s'", resourceID)
+
+	targets, err := autoscalingAPI.DescribeScalableTargets(&applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace: aws.String(applicationautoscaling.ServiceNamespaceEcs),
+		ResourceIds:      []*string{aws.String(resourceID)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(targets.ScalableTargets) == 0 {
+		return nil, fmt.Errorf("No scalable target found for '%s'", resourceID)
+	}
+
+	state := &common.ScalingState{
+		MinCapacity:      aws.Int64Value(targets.ScalableTargets[0].MinCapacity),
+		MaxCapacity:      aws.Int64Value(targets.ScalableTargets[0].MaxCapacity),
+		Policies:         []string{},
+		ScheduledActions: []string{},
+	}
+
+	policies, err := autoscalingAPI.DescribeScalingPolicies(&applicationautoscaling.DescribeScalingPoliciesInput{
+		ServiceNamespace: aws.String(applicationautoscaling.ServiceNamespaceEcs),
+		ResourceId:       aws.String(resourceID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range policies.ScalingPolicies {
+		state.Policies = append(state.Policies, aws.StringValue(policy.PolicyName))
+	}
+
+	actions, err := autoscalingAPI.DescribeScheduledActions(&applicationautoscaling.DescribeScheduledActionsInput{
+		ServiceNamespace: aws.String(applicationautoscaling.ServiceNamespaceEcs),
+		ResourceId:       aws.String(resourceID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, action := range actions.ScheduledActions {
+		state.ScheduledActions = append(state.ScheduledActions, fmt.Sprintf("%s (%s)", aws.StringValue(action.ScheduledActionName), aws.StringValue(action.Schedule)))
+	}
+
+	activities, err := autoscalingAPI.DescribeScalingActivities(&applicationautoscaling.DescribeScalingActivitiesInput{
+		ServiceNamespace: aws.String(applicationautoscaling.ServiceNamespaceEcs),
+		ResourceId:       aws.String(resourceID),
+		MaxResults:       aws.Int64(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(activities.ScalingActivities) > 0 {
+		activity := activities.ScalingActivities[0]
+		state.LastActivity = &common.ScalingActivity{
+			Description: aws.StringValue(activity.Description),
+			Status:      aws.StringValue(activity.StatusCode),
+			StartTime:   aws.TimeValue(activity.StartTime),
+		}
+	}
+
+	return state, nil
+}
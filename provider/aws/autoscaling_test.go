@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockedApplicationAutoScaling struct {
+	mock.Mock
+	applicationautoscalingiface.ApplicationAutoScalingAPI
+}
+
+func (m *mockedApplicationAutoScaling) DescribeScalableTargets(input *applicationautoscaling.DescribeScalableTargetsInput) (*applicationautoscaling.DescribeScalableTargetsOutput, error) {
+	args := m.Called(aws.StringValue(input.ResourceIds[0]))
+	return args.Get(0).(*applicationautoscaling.DescribeScalableTargetsOutput), args.Error(1)
+}
+
+func (m *mockedApplicationAutoScaling) DescribeScalingPolicies(input *applicationautoscaling.DescribeScalingPoliciesInput) (*applicationautoscaling.DescribeScalingPoliciesOutput, error) {
+	args := m.Called()
+	return args.Get(0).(*applicationautoscaling.DescribeScalingPoliciesOutput), args.Error(1)
+}
+
+func (m *mockedApplicationAutoScaling) DescribeScheduledActions(input *applicationautoscaling.DescribeScheduledActionsInput) (*applicationautoscaling.DescribeScheduledActionsOutput, error) {
+	args := m.Called()
+	return args.Get(0).(*applicationautoscaling.DescribeScheduledActionsOutput), args.Error(1)
+}
+
+func (m *mockedApplicationAutoScaling) DescribeScalingActivities(input *applicationautoscaling.DescribeScalingActivitiesInput) (*applicationautoscaling.DescribeScalingActivitiesOutput, error) {
+	args := m.Called()
+	return args.Get(0).(*applicationautoscaling.DescribeScalingActivitiesOutput), args.Error(1)
+}
+
+func TestAutoscalingManager_GetScalingState(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(mockedApplicationAutoScaling)
+	m.On("DescribeScalableTargets", "service/mu-cluster/mu-foo-dev").Return(
+		&applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []*applicationautoscaling.ScalableTarget{
+				{MinCapacity: aws.Int64(1), MaxCapacity: aws.Int64(4)},
+			},
+		}, nil)
+	m.On("DescribeScalingPolicies").Return(
+		&applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []*applicationautoscaling.ScalingPolicy{
+				{PolicyName: aws.String("cpu-utilization")},
+			},
+		}, nil)
+	m.On("DescribeScheduledActions").Return(
+		&applicationautoscaling.DescribeScheduledActionsOutput{
+			ScheduledActions: []*applicationautoscaling.ScheduledAction{
+				{ScheduledActionName: aws.String("night"), Schedule: aws.String("cron(0 22 * * ? *)")},
+			},
+		}, nil)
+	m.On("DescribeScalingActivities").Return(
+		&applicationautoscaling.DescribeScalingActivitiesOutput{
+			ScalingActivities: []*applicationautoscaling.ScalingActivity{
+				{Description: aws.String("Setting desired count to 2."), StatusCode: aws.String("Successful"), StartTime: aws.Time(time.Now())},
+			},
+		}, nil)
+
+	autoscalingMgr := autoscalingManager{
+		autoscalingAPI: m,
+	}
+
+	state, err := autoscalingMgr.GetScalingState("mu-cluster", "mu-foo-dev")
+	assert.Nil(err)
+	assert.Equal(int64(1), state.MinCapacity)
+	assert.Equal(int64(4), state.MaxCapacity)
+	assert.Equal([]string{"cpu-utilization"}, state.Policies)
+	assert.Equal([]string{"night (cron(0 22 * * ? *))"}, state.ScheduledActions)
+	assert.Equal("Successful", state.LastActivity.Status)
+
+	m.AssertExpectations(t)
+}
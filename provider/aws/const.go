@@ -16,6 +16,8 @@ const (
 	DescribeContainerInstances  = "DescribeContainerInstances"
 	ECSTaskDefinitionOutputKey  = "MicroserviceTaskDefinitionArn"
 	ECSClusterOutputKey         = "EcsCluster"
+	ECSLogStreamPrefix          = "container"
+	SessionManagerPluginBinary  = "session-manager-plugin"
 	SvcCmdStackLog              = "Getting stack '%s'..."
 	EcsConnectionLog            = "Connecting to ECS service"
 	ExecuteCommandStartLog      = "Executing command '[%s]' on environment '%s' for service '%s'\n"
@@ -27,6 +29,7 @@ const (
 	SvcTaskDetailLog            = "Task Detail: %s"
 	SvcListTasksLog             = "Listing tasks for Environment: %s, Cluster: %s, Service: %s"
 	TaskARNSeparator            = ForwardSlash
+	DescribeTasksBatchSize      = 100
 )
 
 // Constants used during testing
@@ -2,9 +2,11 @@ package aws
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
@@ -67,6 +69,80 @@ func (ecsMgr *ecsClusterManager) ListInstances(clusterName string) ([]common.Con
 	return instances, nil
 }
 
+// DrainInstances sets the container instances to DRAINING, so their tasks are moved to the other instances
+func (ecsMgr *ecsClusterManager) DrainInstances(clusterName string, containerInstanceArns ...string) error {
+	ecsAPI := ecsMgr.ecsAPI
+
+	if ecsMgr.dryrun {
+		log.Infof("  DRYRUN: Skipping draining of %d container instances in cluster '%s'", len(containerInstanceArns), clusterName)
+		return nil
+	}
+
+	// the api accepts up to 10 container instances at a time
+	for start := 0; start < len(containerInstanceArns); start += 10 {
+		end := start + 10
+		if end > len(containerInstanceArns) {
+			end = len(containerInstanceArns)
+		}
+		log.Debugf("Draining container instances %v in cluster '%s'", containerInstanceArns[start:end], clusterName)
+		out, err := ecsAPI.UpdateContainerInstancesState(&ecs.UpdateContainerInstancesStateInput{
+			Cluster:            aws.String(clusterName),
+			ContainerInstances: aws.StringSlice(containerInstanceArns[start:end]),
+			Status:             aws.String(ecs.ContainerInstanceStatusDraining),
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Failures) > 0 {
+			return fmt.Errorf("unable to drain container instance '%s': %s", aws.StringValue(out.Failures[0].Arn), aws.StringValue(out.Failures[0].Reason))
+		}
+	}
+	return nil
+}
+
+// ListServices get the task counts of the services in a cluster
+func (ecsMgr *ecsClusterManager) ListServices(clusterName string) ([]common.ClusterService, error) {
+	ecsAPI := ecsMgr.ecsAPI
+
+	log.Debugf("Searching for services in cluster named '%s'", clusterName)
+
+	var serviceArns []*string
+	err := ecsAPI.ListServicesPages(&ecs.ListServicesInput{
+		Cluster: aws.String(clusterName),
+	}, func(page *ecs.ListServicesOutput, lastPage bool) bool {
+		serviceArns = append(serviceArns, page.ServiceArns...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]common.ClusterService, 0)
+	// the api describes up to 10 services at a time
+	for start := 0; start < len(serviceArns); start += 10 {
+		end := start + 10
+		if end > len(serviceArns) {
+			end = len(serviceArns)
+		}
+		out, err := ecsAPI.DescribeServices(&ecs.DescribeServicesInput{
+			Cluster:  aws.String(clusterName),
+			Services: serviceArns[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range out.Services {
+			services = append(services, common.ClusterService{
+				Name:         aws.StringValue(service.ServiceName),
+				DesiredCount: aws.Int64Value(service.DesiredCount),
+				RunningCount: aws.Int64Value(service.RunningCount),
+				PendingCount: aws.Int64Value(service.PendingCount),
+			})
+		}
+	}
+	return services, nil
+}
+
 func (ecsMgr *ecsClusterManager) AuthenticateRepository(repoURL string) (string, error) {
 	ecrAPI := ecsMgr.ecrAPI
 
@@ -88,6 +164,105 @@ func (ecsMgr *ecsClusterManager) AuthenticateRepository(repoURL string) (string,
 	return common.Empty, fmt.Errorf("unable to find token for repo url:%s", repoURL)
 }
 
+// ListRepositoryTags lists the tags of the images in an ECR repo, most recently pushed first
+func (ecsMgr *ecsClusterManager) ListRepositoryTags(repoURL string) ([]string, error) {
+	ecrAPI := ecsMgr.ecrAPI
+
+	repoName, _ := splitRepositoryImage(common.ImageRepository(repoURL))
+
+	images := []*ecr.ImageDetail{}
+	err := ecrAPI.DescribeImagesPages(&ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repoName),
+		Filter: &ecr.DescribeImagesFilter{
+			TagStatus: aws.String(ecr.TagStatusTagged),
+		},
+	}, func(page *ecr.DescribeImagesOutput, lastPage bool) bool {
+		images = append(images, page.ImageDetails...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		return aws.TimeValue(images[i].ImagePushedAt).After(aws.TimeValue(images[j].ImagePushedAt))
+	})
+
+	tags := []string{}
+	for _, image := range images {
+		tags = append(tags, aws.StringValueSlice(image.ImageTags)...)
+	}
+	return tags, nil
+}
+
+// splitRepositoryImage splits an image url into the name of its ECR repo and the id of the image
+func splitRepositoryImage(imageURL string) (string, *ecr.ImageIdentifier) {
+	repoName := imageURL
+	if idx := strings.Index(repoName, "/"); idx >= 0 {
+		repoName = repoName[idx+1:]
+	}
+	if idx := strings.Index(repoName, "@"); idx >= 0 {
+		return repoName[:idx], &ecr.ImageIdentifier{ImageDigest: aws.String(repoName[idx+1:])}
+	}
+	if idx := strings.LastIndex(repoName, ":"); idx >= 0 {
+		return repoName[:idx], &ecr.ImageIdentifier{ImageTag: aws.String(repoName[idx+1:])}
+	}
+	return repoName, &ecr.ImageIdentifier{ImageTag: aws.String("latest")}
+}
+
+// ResolveImageDigest returns the image url pinned to the digest of the image in ECR
+func (ecsMgr *ecsClusterManager) ResolveImageDigest(imageURL string) (string, error) {
+	ecrAPI := ecsMgr.ecrAPI
+
+	repoName, imageID := splitRepositoryImage(imageURL)
+	out, err := ecrAPI.DescribeImages(&ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repoName),
+		ImageIds:       []*ecr.ImageIdentifier{imageID},
+	})
+	if err != nil {
+		return common.Empty, err
+	}
+	if len(out.ImageDetails) == 0 {
+		return common.Empty, fmt.Errorf("unable to find image '%s'", imageURL)
+	}
+
+	repoURL := imageURL[:strings.Index(imageURL, "/")+1] + repoName
+	return fmt.Sprintf("%s@%s", repoURL, aws.StringValue(out.ImageDetails[0].ImageDigest)), nil
+}
+
+// TagImage adds a tag to an image in ECR by putting its manifest again under the new tag
+func (ecsMgr *ecsClusterManager) TagImage(imageURL string, tag string) error {
+	ecrAPI := ecsMgr.ecrAPI
+
+	repoName, imageID := splitRepositoryImage(imageURL)
+	out, err := ecrAPI.BatchGetImage(&ecr.BatchGetImageInput{
+		RepositoryName: aws.String(repoName),
+		ImageIds:       []*ecr.ImageIdentifier{imageID},
+	})
+	if err != nil {
+		return err
+	}
+	if len(out.Images) == 0 {
+		return fmt.Errorf("unable to find image '%s'", imageURL)
+	}
+
+	if ecsMgr.dryrun {
+		log.Infof("  DRYRUN: Skipping tagging of image '%s' as '%s'", imageURL, tag)
+		return nil
+	}
+	log.Infof("  Tagging image '%s' as '%s'", imageURL, tag)
+	_, err = ecrAPI.PutImage(&ecr.PutImageInput{
+		RepositoryName: aws.String(repoName),
+		ImageManifest:  out.Images[0].ImageManifest,
+		ImageTag:       aws.String(tag),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ecr.ErrCodeImageAlreadyExistsException {
+		// the tag already points at this image
+		return nil
+	}
+	return err
+}
+
 func (ecsMgr *ecsClusterManager) DeleteRepository(repoName string) error {
 	ecrAPI := ecsMgr.ecrAPI
 
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 type mockedECS struct {
@@ -66,6 +68,12 @@ func (m *mockedECR) GetAuthorizationToken(input *ecr.GetAuthorizationTokenInput)
 	args := m.Called()
 	return args.Get(0).(*ecr.GetAuthorizationTokenOutput), args.Error(1)
 }
+func (m *mockedECR) DescribeImagesPages(input *ecr.DescribeImagesInput, cb func(*ecr.DescribeImagesOutput, bool) bool) error {
+	args := m.Called(aws.StringValue(input.RepositoryName))
+	cb(args.Get(0).(*ecr.DescribeImagesOutput), true)
+	return args.Error(1)
+}
+
 func TestEcsClusterManager_AuthenticateRepository(t *testing.T) {
 	assert := assert.New(t)
 
@@ -108,3 +116,75 @@ func TestEcsClusterManager_AuthenticateRepository(t *testing.T) {
 	m.AssertNumberOfCalls(t, "GetAuthorizationToken", 4)
 
 }
+
+func TestEcsClusterManager_ListRepositoryTags(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(mockedECR)
+	m.On("DescribeImagesPages", "mu-foo").Return(
+		&ecr.DescribeImagesOutput{
+			ImageDetails: []*ecr.ImageDetail{
+				{ImageTags: aws.StringSlice([]string{"old"}), ImagePushedAt: aws.Time(time.Unix(100, 0))},
+				{ImageTags: aws.StringSlice([]string{"new"}), ImagePushedAt: aws.Time(time.Unix(300, 0))},
+				{ImageTags: aws.StringSlice([]string{"mid"}), ImagePushedAt: aws.Time(time.Unix(200, 0))},
+			},
+		}, nil)
+
+	clusterManager := ecsClusterManager{
+		ecrAPI: m,
+	}
+
+	tags, err := clusterManager.ListRepositoryTags("123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc")
+	assert.Nil(err)
+	assert.Equal([]string{"new", "mid", "old"}, tags)
+	m.AssertExpectations(t)
+}
+
+func (m *mockedECR) DescribeImages(input *ecr.DescribeImagesInput) (*ecr.DescribeImagesOutput, error) {
+	args := m.Called(aws.StringValue(input.RepositoryName), aws.StringValue(input.ImageIds[0].ImageTag))
+	return args.Get(0).(*ecr.DescribeImagesOutput), args.Error(1)
+}
+
+func TestEcsClusterManager_ResolveImageDigest(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(mockedECR)
+	m.On("DescribeImages", "mu-foo", "abc").Return(
+		&ecr.DescribeImagesOutput{
+			ImageDetails: []*ecr.ImageDetail{{ImageDigest: aws.String("sha256:1234")}},
+		}, nil)
+
+	clusterManager := ecsClusterManager{
+		ecrAPI: m,
+	}
+
+	image, err := clusterManager.ResolveImageDigest("123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc")
+	assert.Nil(err)
+	assert.Equal("123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo@sha256:1234", image)
+	m.AssertExpectations(t)
+}
+
+func (m *mockedECS) UpdateContainerInstancesState(input *ecs.UpdateContainerInstancesStateInput) (*ecs.UpdateContainerInstancesStateOutput, error) {
+	args := m.Called(len(input.ContainerInstances), aws.StringValue(input.Status))
+	return args.Get(0).(*ecs.UpdateContainerInstancesStateOutput), args.Error(1)
+}
+
+func TestEcsClusterManager_DrainInstances(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(mockedECS)
+	m.On("UpdateContainerInstancesState", 10, "DRAINING").Return(&ecs.UpdateContainerInstancesStateOutput{}, nil).Once()
+	m.On("UpdateContainerInstancesState", 2, "DRAINING").Return(&ecs.UpdateContainerInstancesStateOutput{}, nil).Once()
+
+	clusterManager := ecsClusterManager{
+		ecsAPI: m,
+	}
+
+	arns := make([]string, 12)
+	for i := range arns {
+		arns[i] = fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:container-instance/%d", i)
+	}
+	err := clusterManager.DrainInstances("mu-environment-dev", arns...)
+	assert.Nil(err)
+	m.AssertExpectations(t)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,11 +23,13 @@ import (
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/stelligent/mu/common"
 	"github.com/stelligent/mu/templates"
+	"golang.org/x/crypto/ssh/terminal"
 	yaml "gopkg.in/yaml.v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
 )
 
 const (
@@ -43,6 +47,7 @@ type eksKubernetesResourceManagerProvider struct {
 type eksKubernetesResourceManager struct {
 	name              string
 	client            dynamic.Interface
+	restConfig        *rest.Config
 	extensionsManager common.ExtensionsManager
 	dryrunPath        string
 }
@@ -75,6 +80,17 @@ func dryRunRestConfig() dynamic.Interface {
 	return restConfig
 }
 
+// GetClusterVersion get the kubernetes version of the control plane of an eks cluster
+func (eksMgrProvider *eksKubernetesResourceManagerProvider) GetClusterVersion(name string) (string, error) {
+	resp, err := eksMgrProvider.eksAPI.DescribeCluster(&eks.DescribeClusterInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.Cluster.Version), nil
+}
+
 // GetResourceManager get a connection to eks cluster
 func (eksMgrProvider *eksKubernetesResourceManagerProvider) GetResourceManager(name string) (common.KubernetesResourceManager, error) {
 	eksAPI := eksMgrProvider.eksAPI
@@ -129,6 +145,7 @@ func (eksMgrProvider *eksKubernetesResourceManagerProvider) GetResourceManager(n
 	return &eksKubernetesResourceManager{
 		name:              name,
 		client:            client,
+		restConfig:        k8sClientConfig,
 		dryrunPath:        eksMgrProvider.dryrunPath,
 		extensionsManager: eksMgrProvider.extensionsManager,
 	}, nil
@@ -289,6 +306,60 @@ func (eksMgr *eksKubernetesResourceManager) ListResources(apiVersion string, kin
 	return resourceClient.Namespace(namespace).List(metav1.ListOptions{})
 }
 
+// ExecPod runs a command in a container of a pod, attached to the current terminal
+func (eksMgr *eksKubernetesResourceManager) ExecPod(namespace string, podName string, containerName string, command []string) error {
+	if eksMgr.restConfig == nil {
+		return fmt.Errorf("Unable to exec into pod '%s' without a connection to cluster '%s'", podName, eksMgr.name)
+	}
+
+	stdinFd := int(os.Stdin.Fd())
+	tty := terminal.IsTerminal(stdinFd)
+
+	query := url.Values{}
+	query.Set("container", containerName)
+	for _, commandPart := range command {
+		query.Add("command", commandPart)
+	}
+	query.Set("stdin", "true")
+	query.Set("stdout", "true")
+	query.Set("stderr", strconv.FormatBool(!tty))
+	query.Set("tty", strconv.FormatBool(tty))
+
+	execURL, err := url.Parse(fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s/exec?%s",
+		strings.TrimSuffix(eksMgr.restConfig.Host, "/"), namespace, podName, query.Encode()))
+	if err != nil {
+		return err
+	}
+
+	executor, err := remotecommand.NewSPDYExecutor(eksMgr.restConfig, "POST", execURL)
+	if err != nil {
+		return err
+	}
+
+	options := remotecommand.StreamOptions{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Tty:    tty,
+	}
+	if tty {
+		state, err := terminal.MakeRaw(stdinFd)
+		if err != nil {
+			return err
+		}
+		defer terminal.Restore(stdinFd, state)
+
+		sizeQueue := newTerminalSizeQueue(int(os.Stdout.Fd()))
+		defer sizeQueue.stop()
+		options.TerminalSizeQueue = sizeQueue
+	} else {
+		// stderr is multiplexed into stdout for a tty
+		options.Stderr = os.Stderr
+	}
+
+	log.Debugf("Executing %v in container '%s' of pod '%s'", command, containerName, podName)
+	return executor.Stream(options)
+}
+
 func (eksMgr *eksKubernetesResourceManager) getResourceInterface(apiVersion string, kind string) (dynamic.NamespaceableResourceInterface, error) {
 	groupVersion, err := schema.ParseGroupVersion(apiVersion)
 	if err != nil {
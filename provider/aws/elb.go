@@ -43,3 +43,28 @@ func (elbMgr *elbv2Manager) ListRules(listenerArn string) ([]common.ElbRule, err
 
 	return rules, nil
 }
+
+// CountHealthyTargets get the number of healthy targets in a target group
+func (elbMgr *elbv2Manager) CountHealthyTargets(targetGroupArn string) (int, error) {
+	elbAPI := elbMgr.elbAPI
+
+	params := &elbv2.DescribeTargetHealthInput{
+		TargetGroupArn: aws.String(targetGroupArn),
+	}
+
+	log.Debugf("Searching for target health for ARN '%s'", targetGroupArn)
+
+	output, err := elbAPI.DescribeTargetHealth(params)
+	if err != nil {
+		return 0, err
+	}
+
+	healthy := 0
+	for _, target := range output.TargetHealthDescriptions {
+		if target.TargetHealth != nil && aws.StringValue(target.TargetHealth.State) == elbv2.TargetHealthStateEnumHealthy {
+			healthy++
+		}
+	}
+
+	return healthy, nil
+}
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,11 @@ func (m *mockedELB) DescribeRules(input *elbv2.DescribeRulesInput) (*elbv2.Descr
 	return args.Get(0).(*elbv2.DescribeRulesOutput), args.Error(1)
 }
 
+func (m *mockedELB) DescribeTargetHealth(input *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+	args := m.Called()
+	return args.Get(0).(*elbv2.DescribeTargetHealthOutput), args.Error(1)
+}
+
 func TestElbv2Manager_ListRules(t *testing.T) {
 	assert := assert.New(t)
 
@@ -38,3 +44,27 @@ func TestElbv2Manager_ListRules(t *testing.T) {
 	m.AssertExpectations(t)
 	m.AssertNumberOfCalls(t, "DescribeRules", 1)
 }
+
+func TestElbv2Manager_CountHealthyTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(mockedELB)
+	m.On("DescribeTargetHealth").Return(
+		&elbv2.DescribeTargetHealthOutput{
+			TargetHealthDescriptions: []*elbv2.TargetHealthDescription{
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String(elbv2.TargetHealthStateEnumHealthy)}},
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String(elbv2.TargetHealthStateEnumInitial)}},
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String(elbv2.TargetHealthStateEnumHealthy)}},
+			},
+		}, nil)
+
+	elbManager := elbv2Manager{
+		elbAPI: m,
+	}
+
+	healthy, err := elbManager.CountHealthyTargets("foo")
+	assert.Nil(err)
+	assert.Equal(2, healthy)
+
+	m.AssertExpectations(t)
+}
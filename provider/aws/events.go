@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchevents"
+	"github.com/aws/aws-sdk-go/service/cloudwatchevents/cloudwatcheventsiface"
+	"github.com/stelligent/mu/common"
+)
+
+// how far back to look for the last invocation of a schedule, CloudWatch returns at most 1440 datapoints
+const scheduleInvocationLookback = 24 * time.Hour
+
+type scheduleManager struct {
+	eventsAPI     cloudwatcheventsiface.CloudWatchEventsAPI
+	cloudwatchAPI cloudwatchiface.CloudWatchAPI
+}
+
+func newScheduleManager(sess *session.Session) (common.ScheduleManager, error) {
+	log.Debug("Connecting to CloudWatch Events service")
+	eventsAPI := cloudwatchevents.New(sess)
+
+	log.Debug("Connecting to CloudWatch service")
+	cloudwatchAPI := cloudwatch.New(sess)
+
+	return &scheduleManager{
+		eventsAPI:     eventsAPI,
+		cloudwatchAPI: cloudwatchAPI,
+	}, nil
+}
+
+// GetScheduleState get the expression, state and last invocation of a schedule rule
+func (scheduleMgr *scheduleManager) GetScheduleState(ruleName string) (*common.ScheduleState, error) {
+	log.Debugf("Searching for state of schedule rule '%s'", ruleName)
+
+	rule, err := scheduleMgr.eventsAPI.DescribeRule(&cloudwatchevents.DescribeRuleInput{
+		Name: aws.String(ruleName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	state := &common.ScheduleState{
+		RuleName:   ruleName,
+		Expression: aws.StringValue(rule.ScheduleExpression),
+		Enabled:    aws.StringValue(rule.State) == cloudwatchevents.RuleStateEnabled,
+	}
+
+	endTime := time.Now()
+	stats, err := scheduleMgr.cloudwatchAPI.GetMetricStatistics(&cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String("AWS/Events"),
+		MetricName: aws.String("Invocations"),
+		Dimensions: []*cloudwatch.Dimension{
+			{
+				Name:  aws.String("RuleName"),
+				Value: aws.String(ruleName),
+			},
+		},
+		StartTime:  aws.Time(endTime.Add(-scheduleInvocationLookback)),
+		EndTime:    aws.Time(endTime),
+		Period:     aws.Int64(60),
+		Statistics: []*string{aws.String(cloudwatch.StatisticSum)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, datapoint := range stats.Datapoints {
+		if aws.Float64Value(datapoint.Sum) == 0 {
+			continue
+		}
+		timestamp := aws.TimeValue(datapoint.Timestamp)
+		if state.LastInvocation == nil || timestamp.After(*state.LastInvocation) {
+			state.LastInvocation = &timestamp
+		}
+	}
+
+	return state, nil
+}
+
+// SetScheduleState enable or disable a schedule rule
+func (scheduleMgr *scheduleManager) SetScheduleState(ruleName string, enabled bool) error {
+	if enabled {
+		log.Debugf("Enabling schedule rule '%s'", ruleName)
+		_, err := scheduleMgr.eventsAPI.EnableRule(&cloudwatchevents.EnableRuleInput{
+			Name: aws.String(ruleName),
+		})
+		return err
+	}
+
+	log.Debugf("Disabling schedule rule '%s'", ruleName)
+	_, err := scheduleMgr.eventsAPI.DisableRule(&cloudwatchevents.DisableRuleInput{
+		Name: aws.String(ruleName),
+	})
+	return err
+}
@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchevents"
+	"github.com/aws/aws-sdk-go/service/cloudwatchevents/cloudwatcheventsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockedCloudWatchEvents struct {
+	mock.Mock
+	cloudwatcheventsiface.CloudWatchEventsAPI
+}
+
+func (m *mockedCloudWatchEvents) DescribeRule(input *cloudwatchevents.DescribeRuleInput) (*cloudwatchevents.DescribeRuleOutput, error) {
+	args := m.Called(aws.StringValue(input.Name))
+	return args.Get(0).(*cloudwatchevents.DescribeRuleOutput), args.Error(1)
+}
+
+func (m *mockedCloudWatchEvents) EnableRule(input *cloudwatchevents.EnableRuleInput) (*cloudwatchevents.EnableRuleOutput, error) {
+	args := m.Called(aws.StringValue(input.Name))
+	return args.Get(0).(*cloudwatchevents.EnableRuleOutput), args.Error(1)
+}
+
+func (m *mockedCloudWatchEvents) DisableRule(input *cloudwatchevents.DisableRuleInput) (*cloudwatchevents.DisableRuleOutput, error) {
+	args := m.Called(aws.StringValue(input.Name))
+	return args.Get(0).(*cloudwatchevents.DisableRuleOutput), args.Error(1)
+}
+
+type mockedCloudWatch struct {
+	mock.Mock
+	cloudwatchiface.CloudWatchAPI
+}
+
+func (m *mockedCloudWatch) GetMetricStatistics(input *cloudwatch.GetMetricStatisticsInput) (*cloudwatch.GetMetricStatisticsOutput, error) {
+	args := m.Called(aws.StringValue(input.Dimensions[0].Value))
+	return args.Get(0).(*cloudwatch.GetMetricStatisticsOutput), args.Error(1)
+}
+
+func TestScheduleManager_GetScheduleState(t *testing.T) {
+	assert := assert.New(t)
+
+	lastInvocation := time.Date(2018, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	events := new(mockedCloudWatchEvents)
+	events.On("DescribeRule", "mu-schedule-rule").Return(
+		&cloudwatchevents.DescribeRuleOutput{
+			ScheduleExpression: aws.String("cron(0 12 * * ? *)"),
+			State:              aws.String(cloudwatchevents.RuleStateDisabled),
+		}, nil)
+
+	cw := new(mockedCloudWatch)
+	cw.On("GetMetricStatistics", "mu-schedule-rule").Return(
+		&cloudwatch.GetMetricStatisticsOutput{
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: aws.Float64(1), Timestamp: aws.Time(lastInvocation.Add(-time.Hour))},
+				{Sum: aws.Float64(1), Timestamp: aws.Time(lastInvocation)},
+				{Sum: aws.Float64(0), Timestamp: aws.Time(lastInvocation.Add(time.Hour))},
+			},
+		}, nil)
+
+	scheduleMgr := scheduleManager{
+		eventsAPI:     events,
+		cloudwatchAPI: cw,
+	}
+
+	state, err := scheduleMgr.GetScheduleState("mu-schedule-rule")
+	assert.Nil(err)
+	assert.Equal("cron(0 12 * * ? *)", state.Expression)
+	assert.False(state.Enabled)
+	assert.Equal(lastInvocation, *state.LastInvocation)
+
+	events.AssertExpectations(t)
+	cw.AssertExpectations(t)
+}
+
+func TestScheduleManager_SetScheduleState(t *testing.T) {
+	assert := assert.New(t)
+
+	events := new(mockedCloudWatchEvents)
+	events.On("EnableRule", "mu-schedule-rule").Return(&cloudwatchevents.EnableRuleOutput{}, nil)
+	events.On("DisableRule", "mu-schedule-rule").Return(&cloudwatchevents.DisableRuleOutput{}, nil)
+
+	scheduleMgr := scheduleManager{
+		eventsAPI: events,
+	}
+
+	assert.Nil(scheduleMgr.SetScheduleState("mu-schedule-rule", true))
+	assert.Nil(scheduleMgr.SetScheduleState("mu-schedule-rule", false))
+
+	events.AssertNumberOfCalls(t, "EnableRule", 1)
+	events.AssertNumberOfCalls(t, "DisableRule", 1)
+}
@@ -64,7 +64,7 @@ func initializeManagers(sess *session.Session, ctx *common.Context, dryrunPath s
 	}
 
 	// initialize InstanceManager
-	ctx.InstanceManager, err = newInstanceManager(sess)
+	ctx.InstanceManager, err = newInstanceManager(sess, dryrunPath != "")
 	if err != nil {
 		return err
 	}
@@ -75,6 +75,18 @@ func initializeManagers(sess *session.Session, ctx *common.Context, dryrunPath s
 		return err
 	}
 
+	// initialize AutoscalingManager
+	ctx.AutoscalingManager, err = newAutoscalingManager(sess)
+	if err != nil {
+		return err
+	}
+
+	// initialize ScheduleManager
+	ctx.ScheduleManager, err = newScheduleManager(sess)
+	if err != nil {
+		return err
+	}
+
 	// initialize RdsManager
 	ctx.RdsManager, err = newRdsManager(sess)
 	if err != nil {
@@ -129,25 +141,41 @@ func initializeManagers(sess *session.Session, ctx *common.Context, dryrunPath s
 	return err
 }
 
-// InitializeContext loads manager objects
-func InitializeContext(ctx *common.Context, profile string, assumeRole string, region string, dryrunPath string, skipVersionCheck bool, proxy string, allowDataLoss bool) error {
+// newSession creates a session for the region, with the role assumed if one is set
+func newSession(profile string, assumeRole string, region string, proxy string) (*session.Session, error) {
 
 	sessOptions := setupSessOptions(region, proxy, profile)
 
 	log.Debugf("Creating AWS session profile:%s region:%s proxy:%s", profile, region, proxy)
 	sess, err := session.NewSessionWithOptions(sessOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if assumeRole != common.Empty {
-		// Create the credentials from AssumeRoleProvider to assume the role
-		// referenced by the "myRoleARN" ARN.
-		creds := stscreds.NewCredentials(sess, assumeRole)
-		sess, err = session.NewSession(&aws.Config{Region: sess.Config.Region, HTTPClient: sess.Config.HTTPClient, Credentials: creds})
-		if err != nil {
-			return err
+		return assumeRoleSession(sess, assumeRole, common.Empty)
+	}
+	return sess, nil
+}
+
+// assumeRoleSession creates a session with the credentials of a role assumed with sess
+func assumeRoleSession(sess *session.Session, roleArn string, externalID string) (*session.Session, error) {
+	// Create the credentials from AssumeRoleProvider to assume the role
+	// referenced by the "myRoleARN" ARN.
+	creds := stscreds.NewCredentials(sess, roleArn, func(provider *stscreds.AssumeRoleProvider) {
+		if externalID != common.Empty {
+			provider.ExternalID = aws.String(externalID)
 		}
+	})
+	return session.NewSession(&aws.Config{Region: sess.Config.Region, HTTPClient: sess.Config.HTTPClient, Credentials: creds})
+}
+
+// InitializeContext loads manager objects
+func InitializeContext(ctx *common.Context, profile string, assumeRole string, region string, dryrunPath string, skipVersionCheck bool, proxy string, allowDataLoss bool) error {
+
+	sess, err := newSession(profile, assumeRole, region, proxy)
+	if err != nil {
+		return err
 	}
 	err = initializeManagers(sess, ctx, dryrunPath, skipVersionCheck, allowDataLoss)
 	if err != nil {
@@ -168,6 +196,34 @@ func InitializeContext(ctx *common.Context, profile string, assumeRole string, r
 	}
 
 	ctx.DockerOut = os.Stdout
+	ctx.AssumeRole = assumeRole
+
+	// environments in other regions use the same profile and role.  An environment in another account uses
+	// its own profile or role, unless `--assume-role` already picked the account, like in the pipeline
+	ctx.SetContextInitializer(func(envCtx *common.Context, region string, account common.EnvironmentAccount) error {
+		envProfile := profile
+		if assumeRole != common.Empty {
+			account = common.EnvironmentAccount{}
+		} else if account.Profile != common.Empty {
+			envProfile = account.Profile
+		}
+		envSess, err := newSession(envProfile, assumeRole, region, proxy)
+		if err != nil {
+			return err
+		}
+		if account.RoleArn != common.Empty {
+			envSess, err = assumeRoleSession(envSess, account.RoleArn, account.ExternalID)
+			if err != nil {
+				return err
+			}
+		}
+		err = initializeManagers(envSess, envCtx, dryrunPath, skipVersionCheck, allowDataLoss)
+		if err != nil {
+			return err
+		}
+		envCtx.KubernetesResourceManagerProvider, err = newEksKubernetesResourceManagerProvider(envSess, envCtx.ExtensionsManager, dryrunPath)
+		return err
+	})
 
 	return nil
 }
@@ -10,14 +10,16 @@ import (
 
 type ec2InstanceManager struct {
 	ec2API ec2iface.EC2API
+	dryrun bool
 }
 
-func newInstanceManager(sess *session.Session) (common.InstanceManager, error) {
+func newInstanceManager(sess *session.Session, dryrun bool) (common.InstanceManager, error) {
 	log.Debug("Connecting to EC2 service")
 	ec2API := ec2.New(sess)
 
 	return &ec2InstanceManager{
 		ec2API: ec2API,
+		dryrun: dryrun,
 	}, nil
 }
 
@@ -39,3 +41,17 @@ func (ec2Mgr *ec2InstanceManager) ListInstances(instanceIds ...string) ([]common
 
 	return instances, nil
 }
+
+// TerminateInstances terminates instances, the autoscaling group of an instance launches its replacement
+func (ec2Mgr *ec2InstanceManager) TerminateInstances(instanceIds ...string) error {
+	if ec2Mgr.dryrun {
+		log.Infof("  DRYRUN: Skipping termination of instances %v", instanceIds)
+		return nil
+	}
+
+	log.Debugf("Terminating instances %v", instanceIds)
+	_, err := ec2Mgr.ec2API.TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIds),
+	})
+	return err
+}
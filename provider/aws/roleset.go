@@ -2,6 +2,8 @@ package aws
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -65,8 +67,10 @@ func (rolesetMgr *iamRolesetManager) GetServiceRoleset(environmentName string, s
 	overrideRole(roleset, "EC2InstanceProfileArn", rolesetMgr.context.Config.Service.Roles.Ec2Instance)
 	overrideRole(roleset, "CodeDeployRoleArn", rolesetMgr.context.Config.Service.Roles.CodeDeploy)
 	overrideRole(roleset, "EcsEventsRoleArn", rolesetMgr.context.Config.Service.Roles.EcsEvents)
+	overrideRole(roleset, "Ec2EventsRoleArn", rolesetMgr.context.Config.Service.Roles.Ec2Events)
 	overrideRole(roleset, "EcsServiceRoleArn", rolesetMgr.context.Config.Service.Roles.EcsService)
 	overrideRole(roleset, "EcsTaskRoleArn", rolesetMgr.context.Config.Service.Roles.EcsTask)
+	overrideRole(roleset, "EksPodRoleArn", rolesetMgr.context.Config.Service.Roles.EksPod)
 	overrideRole(roleset, "ApplicationAutoScalingRoleArn", rolesetMgr.context.Config.Service.Roles.ApplicationAutoScaling)
 	return roleset, nil
 }
@@ -155,6 +159,7 @@ func (rolesetMgr *iamRolesetManager) UpsertEnvironmentRoleset(environmentName st
 		"EnvironmentName": environmentName,
 		"Provider":        string(environment.Provider),
 	}
+	common.NewMapElementIfNotEmpty(stackParams, "IngressController", string(environment.Loadbalancer.IngressController))
 
 	err := rolesetMgr.context.StackManager.UpsertStack(stackName, common.TemplateEnvIAM, environment, stackParams, stackTags, "", "")
 	if err != nil {
@@ -229,12 +234,44 @@ func (rolesetMgr *iamRolesetManager) UpsertServiceRoleset(environmentName string
 		stackParams["DatabaseName"] = databaseName
 	}
 
+	secretArns, err := rolesetMgr.getSecretArns(rolesetMgr.context.Config.Service.Secrets, stackParams)
+	if err != nil {
+		return err
+	}
+	if pullSecretArn := rolesetMgr.context.Config.Service.Registry.PullSecretArn; pullSecretArn != "" {
+		// the task execution role reads the private registry credentials when pulling images
+		secretArns = append(secretArns, pullSecretArn)
+	}
+	if len(secretArns) > 0 {
+		stackParams["SecretArns"] = strings.Join(secretArns, ",")
+	}
+
+	if rolesetMgr.context.Config.Service.EnableExec {
+		stackParams["EnableExec"] = "true"
+	}
+
+	templateData := map[string]string{
+		"EksOidcIssuer": "",
+	}
+	if envProvider == string(common.EnvProviderEks) || envProvider == string(common.EnvProviderEksFargate) {
+		// the pod role trusts the OIDC provider of the cluster, clusters created before
+		// the provider was added to the environment need to be upserted first
+		envStackName := common.CreateStackName(rolesetMgr.context.Config.Namespace, common.StackTypeEnv, environmentName)
+		envStack := rolesetMgr.context.StackManager.AwaitFinalStatus(envStackName)
+		if envStack != nil && envStack.Outputs["EksOidcProviderArn"] != "" {
+			stackParams["EksOidcProviderArn"] = envStack.Outputs["EksOidcProviderArn"]
+			templateData["EksOidcIssuer"] = envStack.Outputs["EksOidcIssuer"]
+		} else {
+			log.Warningf("Environment '%s' has no OIDC provider, upsert the environment to give service '%s' its own pod role", environmentName, serviceName)
+		}
+	}
+
 	policy, err := templates.GetAsset(common.TemplatePolicyDefault)
 	if err != nil {
 		return err
 	}
 
-	err = rolesetMgr.context.StackManager.UpsertStack(stackName, common.TemplateServiceIAM, rolesetMgr.context.Config.Service, stackParams, stackTags, policy, "")
+	err = rolesetMgr.context.StackManager.UpsertStack(stackName, common.TemplateServiceIAM, templateData, stackParams, stackTags, policy, "")
 	if err != nil {
 		return err
 	}
@@ -251,6 +288,52 @@ func (rolesetMgr *iamRolesetManager) UpsertServiceRoleset(environmentName string
 	return nil
 }
 
+// getSecretArns resolves the IAM resources for the secrets of a service.  Secrets are
+// either SSM parameter names, SSM parameter ARNs or Secrets Manager ARNs, optionally
+// suffixed with a json key, version stage and version id.  References to the stack
+// parameters are resolved like the !Sub of the service template does.
+func (rolesetMgr *iamRolesetManager) getSecretArns(secrets map[string]string, stackParams map[string]string) ([]string, error) {
+	refs := map[string]string{
+		"AWS::Partition": rolesetMgr.context.Partition,
+		"AWS::Region":    rolesetMgr.context.Region,
+		"AWS::AccountId": rolesetMgr.context.AccountID,
+	}
+	for key, value := range stackParams {
+		refs[key] = value
+	}
+	if stackParams["DatabaseName"] != "" {
+		refs["DatabaseMasterPasswordParam"] = fmt.Sprintf("%s-DatabaseMasterPassword",
+			common.CreateStackName(stackParams["Namespace"], common.StackTypeDatabase, stackParams["ServiceName"], stackParams["EnvironmentName"]))
+	}
+
+	arns := []string{}
+	for name, valueFrom := range secrets {
+		var missing []string
+		valueFrom = os.Expand(valueFrom, func(key string) string {
+			if refs[key] == "" {
+				missing = append(missing, key)
+			}
+			return refs[key]
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("Secret '%s' references unknown parameters: %s", name, strings.Join(missing, ", "))
+		}
+
+		if strings.HasPrefix(valueFrom, "arn:") {
+			arnParts := strings.Split(valueFrom, ":")
+			if len(arnParts) > 7 && arnParts[2] == "secretsmanager" {
+				valueFrom = strings.Join(arnParts[:7], ":")
+			}
+			arns = append(arns, valueFrom)
+		} else {
+			arns = append(arns, fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s", rolesetMgr.context.Partition,
+				rolesetMgr.context.Region, rolesetMgr.context.AccountID, strings.TrimPrefix(valueFrom, "/")))
+		}
+	}
+	sort.Strings(arns)
+	return arns, nil
+}
+
 func (rolesetMgr *iamRolesetManager) UpsertPipelineRoleset(serviceName string, pipelineBucket string, codeDeployBucket string) error {
 	if rolesetMgr.context.Config.DisableIAM {
 		log.Infof("Skipping upsert of pipeline IAM roles.")
@@ -297,8 +380,29 @@ func (rolesetMgr *iamRolesetManager) UpsertPipelineRoleset(serviceName string, p
 	if err != nil {
 		return err
 	}
-	stackParams["AcptCloudFormationRoleArn"] = commonRoleset["CloudFormationRoleArn"]
-	stackParams["ProdCloudFormationRoleArn"] = commonRoleset["CloudFormationRoleArn"]
+
+	// the environments may be in other regions or accounts, with the CloudFormation role of their own
+	acptEnv := pipelineConfig.Acceptance.Environment
+	if acptEnv == "" {
+		acptEnv = "acceptance"
+	}
+	acptRegion, acptRoleset, err := rolesetMgr.getEnvironmentCommonRoleset(acptEnv, commonRoleset)
+	if err != nil {
+		return err
+	}
+	stackParams["AcptRegion"] = acptRegion
+	stackParams["AcptCloudFormationRoleArn"] = acptRoleset["CloudFormationRoleArn"]
+
+	prodEnv := pipelineConfig.Production.Environment
+	if prodEnv == "" {
+		prodEnv = "production"
+	}
+	prodRegion, prodRoleset, err := rolesetMgr.getEnvironmentCommonRoleset(prodEnv, commonRoleset)
+	if err != nil {
+		return err
+	}
+	stackParams["ProdRegion"] = prodRegion
+	stackParams["ProdCloudFormationRoleArn"] = prodRoleset["CloudFormationRoleArn"]
 
 	policy, err := templates.GetAsset(common.TemplatePolicyDefault)
 	if err != nil {
@@ -322,6 +426,22 @@ func (rolesetMgr *iamRolesetManager) UpsertPipelineRoleset(serviceName string, p
 	return nil
 }
 
+// getEnvironmentCommonRoleset returns the region of an environment and the common roleset of its region and account
+func (rolesetMgr *iamRolesetManager) getEnvironmentCommonRoleset(environmentName string, commonRoleset common.Roleset) (string, common.Roleset, error) {
+	envCtx, err := rolesetMgr.context.ForEnvironment(environmentName)
+	if err != nil {
+		return "", nil, err
+	}
+	if envCtx == rolesetMgr.context {
+		return rolesetMgr.context.Region, commonRoleset, nil
+	}
+	envRoleset, err := envCtx.RolesetManager.GetCommonRoleset()
+	if err != nil {
+		return "", nil, err
+	}
+	return envCtx.Region, envRoleset, nil
+}
+
 func (rolesetMgr *iamRolesetManager) DeleteCommonRoleset() error {
 	if rolesetMgr.context.Config.DisableIAM {
 		log.Infof("Skipping delete of common IAM roles.")
@@ -161,6 +161,12 @@ func TestIamRolesetManager_GetServiceRoleset(t *testing.T) {
 	stackManagerMock.AssertExpectations(t)
 	assert.Equal(2, len(roleset))
 	assert.Equal("bar3", roleset["EcsServiceRoleArn"])
+
+	i.context.Config.Service.Roles.EksPod = "bar5"
+
+	roleset, err = i.GetServiceRoleset("env1", "s1")
+	assert.Nil(err)
+	assert.Equal("bar5", roleset["EksPodRoleArn"])
 }
 
 func TestIamRolesetManager_GetPipelineRoleset(t *testing.T) {
@@ -338,6 +344,114 @@ func TestIamRolesetManager_UpsertServiceRoleset_SharedEnv(t *testing.T) {
 	stackManagerMock.AssertNumberOfCalls(t, "UpsertStack", 1)
 }
 
+type mockedEksRolesetStackManager struct {
+	mockedRolesetStackManager
+	templateData interface{}
+	parameters   map[string]string
+}
+
+func (m *mockedEksRolesetStackManager) UpsertStack(stackName string, templateName string, templateData interface{}, parameters map[string]string, tags map[string]string, policy string, roleArn string) error {
+	m.templateData = templateData
+	m.parameters = parameters
+	return m.mockedRolesetStackManager.UpsertStack(stackName, templateName, templateData, parameters, tags, policy, roleArn)
+}
+
+func TestIamRolesetManager_UpsertServiceRoleset_EksEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	stackManagerMock := new(mockedEksRolesetStackManager)
+
+	i := iamRolesetManager{
+		context: &common.Context{
+			StackManager: stackManagerMock,
+			Config: common.Config{
+				Namespace: "mu",
+				Environments: []common.Environment{
+					{
+						Name:     "env1",
+						Provider: common.EnvProviderEks,
+					},
+				},
+			},
+		},
+	}
+
+	stackManagerMock.On("AwaitFinalStatus", "mu-environment-env1").Return(&common.Stack{
+		Status: "CREATE_COMPLETE",
+		Outputs: map[string]string{
+			"EksOidcProviderArn": "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-west-2.amazonaws.com/id/ABC",
+			"EksOidcIssuer":      "oidc.eks.us-west-2.amazonaws.com/id/ABC",
+		},
+	})
+	stackManagerMock.On("UpsertStack", "mu-iam-service-sv1-env1").Return(nil)
+	stackManagerMock.On("AwaitFinalStatus", "mu-iam-service-sv1-env1").Return(&common.Stack{Status: "CREATE_COMPLETE"})
+
+	err := i.UpsertServiceRoleset("env1", "sv1", "", "")
+	assert.Nil(err)
+	stackManagerMock.AssertExpectations(t)
+	assert.Equal("arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-west-2.amazonaws.com/id/ABC", stackManagerMock.parameters["EksOidcProviderArn"])
+	assert.Equal(map[string]string{"EksOidcIssuer": "oidc.eks.us-west-2.amazonaws.com/id/ABC"}, stackManagerMock.templateData)
+}
+
+func TestIamRolesetManager_getSecretArns(t *testing.T) {
+	assert := assert.New(t)
+
+	i := iamRolesetManager{
+		context: &common.Context{
+			Partition: "aws",
+			Region:    "us-west-2",
+			AccountID: "123456789012",
+		},
+	}
+
+	stackParams := map[string]string{
+		"Namespace":       "mu",
+		"EnvironmentName": "dev",
+		"ServiceName":     "foo",
+		"DatabaseName":    "foo",
+	}
+
+	arns, err := i.getSecretArns(map[string]string{
+		"API_KEY":     "/myapp/api-key",
+		"TOKEN":       "arn:aws:ssm:us-west-2:123456789012:parameter/token",
+		"DB_PASSWORD": "arn:aws:secretsmanager:us-west-2:123456789012:secret:db-AbCdEf:password::",
+		"DB_ADMIN":    "${DatabaseMasterPasswordParam}",
+		"ENV_KEY":     "/${EnvironmentName}/key",
+	}, stackParams)
+	assert.Nil(err)
+
+	assert.Equal([]string{
+		"arn:aws:secretsmanager:us-west-2:123456789012:secret:db-AbCdEf",
+		"arn:aws:ssm:us-west-2:123456789012:parameter/dev/key",
+		"arn:aws:ssm:us-west-2:123456789012:parameter/mu-database-foo-dev-DatabaseMasterPassword",
+		"arn:aws:ssm:us-west-2:123456789012:parameter/myapp/api-key",
+		"arn:aws:ssm:us-west-2:123456789012:parameter/token",
+	}, arns)
+}
+
+func TestIamRolesetManager_getSecretArns_UnknownReference(t *testing.T) {
+	assert := assert.New(t)
+
+	i := iamRolesetManager{
+		context: &common.Context{
+			Partition: "aws",
+			Region:    "us-west-2",
+			AccountID: "123456789012",
+		},
+	}
+
+	// no database, so there is no password parameter to reference
+	_, err := i.getSecretArns(map[string]string{
+		"DB_ADMIN": "${DatabaseMasterPasswordParam}",
+	}, map[string]string{"Namespace": "mu", "EnvironmentName": "dev", "ServiceName": "foo"})
+	assert.NotNil(err)
+
+	_, err = i.getSecretArns(map[string]string{
+		"API_KEY": "/${ApiKeyPath}",
+	}, map[string]string{"Namespace": "mu", "EnvironmentName": "dev", "ServiceName": "foo"})
+	assert.NotNil(err)
+}
+
 func TestIamRolesetManager_UpsertPipelineRoleset(t *testing.T) {
 	assert := assert.New(t)
 
@@ -90,6 +90,28 @@ func (s3Mgr *s3ArtifactManager) CreateArtifact(body io.ReadSeeker, destURL strin
 	return nil
 }
 
+// ArtifactExists checks if there is an artifact at the url
+func (s3Mgr *s3ArtifactManager) ArtifactExists(uri string) (bool, error) {
+	s3URL, err := url.Parse(uri)
+	if err != nil {
+		return false, err
+	}
+	if s3URL.Scheme != "s3" {
+		return false, fmt.Errorf("uri must have scheme of 's3', received '%s'", s3URL.Scheme)
+	}
+
+	_, err = s3Mgr.s3API.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s3URL.Host),
+		Key:    aws.String(s3URL.Path),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s3Mgr *s3ArtifactManager) getArtifactS3(url *url.URL, etag string) (io.ReadCloser, string, error) {
 	region, err := s3manager.GetBucketRegionWithClient(aws.BackgroundContext(), s3Mgr.s3API, url.Host)
 	if err != nil {
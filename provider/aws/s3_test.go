@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/stretchr/testify/assert"
@@ -36,3 +38,30 @@ func TestS3ArtifactManager_CreateArtifact(t *testing.T) {
 	s3Mock.AssertExpectations(t)
 	s3Mock.AssertNumberOfCalls(t, "PutObject", 1)
 }
+
+func (m *mockedS3) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	args := m.Called(aws.StringValue(input.Key))
+	return args.Get(0).(*s3.HeadObjectOutput), args.Error(1)
+}
+
+func TestS3ArtifactManager_ArtifactExists(t *testing.T) {
+	assertion := assert.New(t)
+	s3Mock := new(mockedS3)
+
+	s3Mock.On("HeadObject", "/foo/abc.zip").Return(&s3.HeadObjectOutput{}, nil)
+	s3Mock.On("HeadObject", "/foo/def.zip").Return(&s3.HeadObjectOutput{}, awserr.New("NotFound", "Not Found", nil))
+
+	artifactManager := s3ArtifactManager{
+		s3API: s3Mock,
+	}
+
+	exists, err := artifactManager.ArtifactExists("s3://bucket/foo/abc.zip")
+	assertion.Nil(err)
+	assertion.True(exists)
+
+	exists, err = artifactManager.ArtifactExists("s3://bucket/foo/def.zip")
+	assertion.Nil(err)
+	assertion.False(exists)
+
+	s3Mock.AssertExpectations(t)
+}
@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// startSessionManagerPlugin hands an ECS Exec session over to the session manager plugin,
+// the same way the AWS CLI does for `aws ecs execute-command`
+func startSessionManagerPlugin(session *ecs.Session, region string, target string) error {
+	pluginPath, err := exec.LookPath(SessionManagerPluginBinary)
+	if err != nil {
+		return fmt.Errorf("Unable to find '%s' on the PATH, see https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html", SessionManagerPluginBinary)
+	}
+
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	parametersJSON, err := json.Marshal(map[string]string{"Target": target})
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(pluginPath,
+		string(sessionJSON),
+		region,
+		"StartSession",
+		"",
+		string(parametersJSON),
+		fmt.Sprintf("https://ssm.%s.amazonaws.com", region))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// ctrl-c belongs to the remote session, the plugin takes care of forwarding it
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	log.Debugf("Starting session for target '%s'", target)
+	return cmd.Run()
+}
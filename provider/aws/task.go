@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -14,6 +16,7 @@ import (
 type ecsTaskManager struct {
 	ecsAPI       ecsiface.ECSAPI
 	stackManager common.StackGetter
+	region       string
 }
 
 func getFlagOrValue(flag string, value string) string {
@@ -34,6 +37,7 @@ func newTaskManager(sess *session.Session, stackManager *common.StackManager) (c
 	return &ecsTaskManager{
 		ecsAPI:       ecsAPI,
 		stackManager: *stackManager,
+		region:       aws.StringValue(sess.Config.Region),
 	}, nil
 }
 
@@ -55,18 +59,35 @@ func (taskMgr *ecsTaskManager) getTaskRunInput(namespace string, task common.Tas
 		command[i] = aws.String(strings.TrimSpace(commandPart))
 	}
 
+	containerOverride := &ecs.ContainerOverride{
+		Name:    aws.String(ecsServiceName),
+		Command: command,
+	}
+	for name, value := range task.EnvVars {
+		containerOverride.Environment = append(containerOverride.Environment, &ecs.KeyValuePair{
+			Name:  aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+
+	taskOverride := &ecs.TaskOverride{
+		ContainerOverrides: []*ecs.ContainerOverride{containerOverride},
+	}
+	if task.Cpu > 0 {
+		taskOverride.Cpu = aws.String(strconv.Itoa(task.Cpu))
+	}
+	if task.Memory > 0 {
+		taskOverride.Memory = aws.String(strconv.Itoa(task.Memory))
+	}
+	if task.TaskRoleArn != "" {
+		taskOverride.TaskRoleArn = aws.String(task.TaskRoleArn)
+	}
+
 	ecsRunTaskInput := &ecs.RunTaskInput{
 		Cluster:        aws.String(ecsCluster),
 		TaskDefinition: aws.String(ecsTaskDefinition),
 		Count:          aws.Int64(1),
-		Overrides: &ecs.TaskOverride{
-			ContainerOverrides: []*ecs.ContainerOverride{
-				{
-					Name:    aws.String(ecsServiceName),
-					Command: command,
-				},
-			},
-		},
+		Overrides:      taskOverride,
 	}
 	log.Debugf(ExecuteECSInputContentsLog, ecsRunTaskInput)
 	return ecsRunTaskInput, nil
@@ -95,35 +116,125 @@ func (taskMgr *ecsTaskManager) ExecuteCommand(namespace string, task common.Task
 	return taskMgr.runTask(ecsRunTaskInput)
 }
 
-// ExecuteCommand runs a command for a specific environment
-func (taskMgr *ecsTaskManager) ListTasks(namespace string, environment string, serviceName string) ([]common.Task, error) {
+// DescribeTaskRun gets the status, exit code and log stream of a task started by ExecuteCommand
+func (taskMgr *ecsTaskManager) DescribeTaskRun(cluster string, taskArn string) (*common.TaskRunStatus, error) {
+	out, err := taskMgr.ecsAPI.DescribeTasks(&ecs.DescribeTasksInput{
+		Cluster: aws.String(cluster),
+		Tasks:   []*string{aws.String(taskArn)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Tasks) == 0 {
+		return nil, fmt.Errorf("Unable to find task '%s' in cluster '%s'", taskArn, cluster)
+	}
+	ecsTask := out.Tasks[0]
+
+	// the command runs in the container named in the overrides
+	containerName := ""
+	if ecsTask.Overrides != nil && len(ecsTask.Overrides.ContainerOverrides) > 0 {
+		containerName = aws.StringValue(ecsTask.Overrides.ContainerOverrides[0].Name)
+	}
+
+	status := &common.TaskRunStatus{
+		TaskArn:    taskArn,
+		Status:     aws.StringValue(ecsTask.LastStatus),
+		StopReason: aws.StringValue(ecsTask.StoppedReason),
+	}
+	for _, container := range ecsTask.Containers {
+		if containerName == "" {
+			containerName = aws.StringValue(container.Name)
+		}
+		if aws.StringValue(container.Name) == containerName {
+			status.ExitCode = container.ExitCode
+			if container.Reason != nil {
+				status.StopReason = aws.StringValue(container.Reason)
+			}
+		}
+	}
+
+	taskID := taskArn[strings.LastIndex(taskArn, "/")+1:]
+	status.LogStream = fmt.Sprintf("%s/%s/%s", ECSLogStreamPrefix, containerName, taskID)
+
+	return status, nil
+}
+
+// OpenShell starts an ECS Exec session in a container of a running task
+func (taskMgr *ecsTaskManager) OpenShell(namespace string, environment string, taskName string, containerName string, command []string) error {
 	cluster := common.CreateStackName(namespace, common.StackTypeEnv, environment)
-	serviceInputParameters := &ecs.ListServicesInput{
+
+	out, err := taskMgr.ecsAPI.DescribeTasks(&ecs.DescribeTasksInput{
 		Cluster: aws.String(cluster),
+		Tasks:   []*string{aws.String(taskName)},
+	})
+	if err != nil {
+		return err
 	}
+	if len(out.Tasks) == 0 {
+		return fmt.Errorf("Unable to find task '%s' in cluster '%s'", taskName, cluster)
+	}
+	ecsTask := out.Tasks[0]
+	if !aws.BoolValue(ecsTask.EnableExecuteCommand) {
+		return fmt.Errorf("Task '%s' was not started with ECS Exec enabled, set 'service.enableExec' and redeploy the service", taskName)
+	}
+
+	runtimeID := ""
+	for _, container := range ecsTask.Containers {
+		if aws.StringValue(container.Name) == containerName {
+			runtimeID = aws.StringValue(container.RuntimeId)
+		}
+	}
+	if runtimeID == "" {
+		return fmt.Errorf("Unable to find container '%s' in task '%s'", containerName, taskName)
+	}
+
+	resp, err := taskMgr.ecsAPI.ExecuteCommand(&ecs.ExecuteCommandInput{
+		Cluster:     aws.String(cluster),
+		Task:        ecsTask.TaskArn,
+		Container:   aws.String(containerName),
+		Command:     aws.String(strings.Join(command, " ")),
+		Interactive: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	taskArn := aws.StringValue(ecsTask.TaskArn)
+	taskID := taskArn[strings.LastIndex(taskArn, TaskARNSeparator)+1:]
+	target := fmt.Sprintf("ecs:%s_%s_%s", cluster, taskID, runtimeID)
+	return startSessionManagerPlugin(resp.Session, taskMgr.region, target)
+}
+
+// ListTasks lists the tasks of a service in a specific environment
+func (taskMgr *ecsTaskManager) ListTasks(namespace string, environment string, serviceName string) ([]common.Task, error) {
+	cluster := common.CreateStackName(namespace, common.StackTypeEnv, environment)
 	tasks := []common.Task{}
 
-	serviceOutput, err := taskMgr.ecsAPI.ListServices(serviceInputParameters)
+	serviceArns, err := taskMgr.listServiceArns(cluster)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, serviceARN := range serviceOutput.ServiceArns {
-		log.Debugf(SvcListTasksLog, environment, cluster, serviceName)
-		listTaskInput := &ecs.ListTasksInput{
-			Cluster:     aws.String(cluster),
-			ServiceName: aws.String(*serviceARN),
+	// only the tasks of the requested service, ECS services are named after the namespace, service and environment
+	serviceSuffix := fmt.Sprintf("/%s-%s-%s", namespace, serviceName, environment)
+	for _, serviceARN := range serviceArns {
+		if len(serviceName) != Zero && !strings.HasSuffix(aws.StringValue(serviceARN), serviceSuffix) {
+			continue
 		}
-		listTaskOutput, err := taskMgr.ecsAPI.ListTasks(listTaskInput)
+		log.Debugf(SvcListTasksLog, environment, cluster, serviceName)
+		taskArns, err := taskMgr.listTaskArns(cluster, serviceARN)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, ecsTask := range listTaskOutput.TaskArns {
+		// DescribeTasks accepts up to 100 tasks per call
+		for start := 0; start < len(taskArns); start += DescribeTasksBatchSize {
+			end := start + DescribeTasksBatchSize
+			if end > len(taskArns) {
+				end = len(taskArns)
+			}
 			describeTaskParams := &ecs.DescribeTasksInput{
-				Tasks: []*string{
-					aws.String(*ecsTask),
-				},
+				Tasks:   taskArns[start:end],
 				Cluster: aws.String(cluster),
 			}
 			taskOutput, err := taskMgr.ecsAPI.DescribeTasks(describeTaskParams)
@@ -143,6 +254,44 @@ func (taskMgr *ecsTaskManager) ListTasks(namespace string, environment string, s
 	return tasks, nil
 }
 
+func (taskMgr *ecsTaskManager) listServiceArns(cluster string) ([]*string, error) {
+	serviceArns := []*string{}
+	serviceInputParameters := &ecs.ListServicesInput{
+		Cluster: aws.String(cluster),
+	}
+	for {
+		serviceOutput, err := taskMgr.ecsAPI.ListServices(serviceInputParameters)
+		if err != nil {
+			return nil, err
+		}
+		serviceArns = append(serviceArns, serviceOutput.ServiceArns...)
+		if serviceOutput.NextToken == nil {
+			return serviceArns, nil
+		}
+		serviceInputParameters.NextToken = serviceOutput.NextToken
+	}
+}
+
+func (taskMgr *ecsTaskManager) listTaskArns(cluster string, serviceARN *string) ([]*string, error) {
+	taskArns := []*string{}
+	listTaskInput := &ecs.ListTasksInput{
+		Cluster:     aws.String(cluster),
+		ServiceName: serviceARN,
+	}
+	for {
+		listTaskOutput, err := taskMgr.ecsAPI.ListTasks(listTaskInput)
+		if err != nil {
+			return nil, err
+		}
+		taskArns = append(taskArns, listTaskOutput.TaskArns...)
+		if listTaskOutput.NextToken == nil {
+			return taskArns, nil
+		}
+		listTaskInput.NextToken = listTaskOutput.NextToken
+	}
+}
+
+// StopTask stops a task in a specific environment
 func (taskMgr *ecsTaskManager) StopTask(namespace string, environment string, task string) error {
 	cluster := common.CreateStackName(namespace, common.StackTypeEnv, environment)
 	stopTaskInput := &ecs.StopTaskInput{
@@ -156,10 +305,12 @@ func (taskMgr *ecsTaskManager) StopTask(namespace string, environment string, ta
 
 func getTaskDetail(ecsTask *ecs.Task, taskMgr *ecsTaskManager, cluster string, environment string, serviceName string) (*common.Task, error) {
 	containers := []common.Container{}
+	// tasks of the service run its container, alongside any sidecars
+	hasServiceContainer := len(serviceName) == Zero
 	if len(ecsTask.Containers) > Zero {
 		for _, container := range ecsTask.Containers {
-			if *container.Name != serviceName && len(serviceName) != Zero {
-				return nil, errors.New(common.Empty)
+			if *container.Name == serviceName {
+				hasServiceContainer = true
 			}
 			if ecsTask.ContainerInstanceArn != nil {
 				containers = append(containers, getContainer(taskMgr, cluster, *ecsTask.ContainerInstanceArn, *container))
@@ -168,8 +319,11 @@ func getTaskDetail(ecsTask *ecs.Task, taskMgr *ecsTaskManager, cluster string, e
 			}
 		}
 	}
+	if !hasServiceContainer {
+		return nil, errors.New(common.Empty)
+	}
 	task := common.Task{
-		Name:        strings.Split(*ecsTask.TaskArn, TaskARNSeparator)[1],
+		Name:        (*ecsTask.TaskArn)[strings.LastIndex(*ecsTask.TaskArn, TaskARNSeparator)+1:],
 		Environment: environment,
 		Service:     serviceName,
 		Status:      aws.StringValue(ecsTask.LastStatus),
@@ -187,3 +187,115 @@ func getTestTask() common.Task {
 		Command:     []string{TestCmd},
 	}
 }
+
+func TestTaskListPages(t *testing.T) {
+	ecsMock := new(mockedECSPages)
+	ecsMock.On("ListServices", "").Return(&ecs.ListServicesOutput{ServiceArns: []*string{aws.String("arn:aws:ecs:us-east-1:123456789012:service/mu-environment-fooenv/mu-foosvc-fooenv")}, NextToken: aws.String("page-2")}, nil)
+	ecsMock.On("ListServices", "page-2").Return(&ecs.ListServicesOutput{ServiceArns: []*string{aws.String("arn:aws:ecs:us-east-1:123456789012:service/mu-environment-fooenv/mu-barsvc-fooenv")}}, nil)
+	ecsMock.On("ListTasks", "").Return(&ecs.ListTasksOutput{TaskArns: []*string{aws.String(TestTaskARN)}}, nil)
+	ecsMock.On(DescribeTasks).Return(&ecs.DescribeTasksOutput{Tasks: []*ecs.Task{{TaskArn: aws.String(TestTaskARN), Containers: []*ecs.Container{{Name: aws.String(TestSvc)}}}}}, nil)
+
+	executeManager := ecsTaskManager{
+		ecsAPI: ecsMock,
+	}
+
+	tasks, err := executeManager.ListTasks("mu", TestEnv, TestSvc)
+	assert.Nil(t, err)
+	assert.Len(t, tasks, 1)
+
+	ecsMock.AssertExpectations(t)
+	ecsMock.AssertNumberOfCalls(t, ListServices, 2)
+	ecsMock.AssertNumberOfCalls(t, ListTasks, 1)
+}
+
+func TestTaskDetailSidecars(t *testing.T) {
+	executeManager := &ecsTaskManager{}
+
+	ecsTask := &ecs.Task{TaskArn: aws.String(TestTaskARN), Containers: []*ecs.Container{{Name: aws.String("envoy")}, {Name: aws.String(TestSvc)}}}
+	task, err := getTaskDetail(ecsTask, executeManager, TestEnv, TestEnv, TestSvc)
+	assert.Nil(t, err)
+	assert.Len(t, task.Containers, 2)
+
+	ecsTask.Containers = ecsTask.Containers[:1]
+	_, err = getTaskDetail(ecsTask, executeManager, TestEnv, TestEnv, TestSvc)
+	assert.NotNil(t, err)
+}
+
+type mockedECSPages struct {
+	mockedECS
+}
+
+func (m *mockedECSPages) ListServices(input *ecs.ListServicesInput) (*ecs.ListServicesOutput, error) {
+	args := m.Called(aws.StringValue(input.NextToken))
+	return args.Get(0).(*ecs.ListServicesOutput), args.Error(1)
+}
+
+func (m *mockedECSPages) ListTasks(input *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+	args := m.Called(aws.StringValue(input.NextToken))
+	return args.Get(0).(*ecs.ListTasksOutput), args.Error(1)
+}
+
+func TestTaskRunInputOverrides(t *testing.T) {
+	assertion := assert.New(t)
+	stackManagerMock := new(mockedStackManager)
+	stackManagerMock.On(GetStackName).Return(&common.Stack{Parameters: map[string]string{ECSServiceNameParameterKey: TestSvc}}, nil)
+
+	executeManager := ecsTaskManager{
+		stackManager: stackManagerMock,
+	}
+	task := getTestTask()
+	task.Cpu = 512
+	task.Memory = 1024
+	task.EnvVars = map[string]string{"FOO": "bar"}
+	task.TaskRoleArn = "arn:aws:iam::123456789012:role/migrate"
+
+	input, err := executeManager.getTaskRunInput("mu", task)
+	assertion.Nil(err)
+	assertion.Equal("512", aws.StringValue(input.Overrides.Cpu))
+	assertion.Equal("1024", aws.StringValue(input.Overrides.Memory))
+	assertion.Equal("arn:aws:iam::123456789012:role/migrate", aws.StringValue(input.Overrides.TaskRoleArn))
+	assertion.Equal(TestSvc, aws.StringValue(input.Overrides.ContainerOverrides[0].Name))
+	assertion.Equal("FOO", aws.StringValue(input.Overrides.ContainerOverrides[0].Environment[0].Name))
+	assertion.Equal("bar", aws.StringValue(input.Overrides.ContainerOverrides[0].Environment[0].Value))
+}
+
+func TestDescribeTaskRun(t *testing.T) {
+	assertion := assert.New(t)
+	ecsMock := new(mockedECS)
+	ecsMock.On(DescribeTasks).Return(&ecs.DescribeTasksOutput{Tasks: []*ecs.Task{{
+		LastStatus: aws.String("STOPPED"),
+		Overrides: &ecs.TaskOverride{
+			ContainerOverrides: []*ecs.ContainerOverride{{Name: aws.String(TestSvc)}},
+		},
+		Containers: []*ecs.Container{
+			{Name: aws.String("sidecar"), ExitCode: aws.Int64(0)},
+			{Name: aws.String(TestSvc), ExitCode: aws.Int64(3)},
+		},
+	}}}, nil)
+
+	executeManager := ecsTaskManager{
+		ecsAPI: ecsMock,
+	}
+	status, err := executeManager.DescribeTaskRun("cluster", "arn:aws:ecs:us-west-2:123456789012:task/abc123")
+	assertion.Nil(err)
+	assertion.Equal("STOPPED", status.Status)
+	assertion.Equal(int64(3), aws.Int64Value(status.ExitCode))
+	assertion.Equal("container/"+TestSvc+"/abc123", status.LogStream)
+}
+
+func TestOpenShellRequiresExec(t *testing.T) {
+	assertion := assert.New(t)
+	ecsMock := new(mockedECS)
+	ecsMock.On(DescribeTasks).Return(&ecs.DescribeTasksOutput{Tasks: []*ecs.Task{{
+		TaskArn:              aws.String("arn:aws:ecs:us-west-2:123456789012:task/mu-environment-dev/abc123"),
+		EnableExecuteCommand: aws.Bool(false),
+		Containers:           []*ecs.Container{{Name: aws.String(TestSvc), RuntimeId: aws.String("abc123-1")}},
+	}}}, nil)
+
+	executeManager := ecsTaskManager{
+		ecsAPI: ecsMock,
+	}
+	err := executeManager.OpenShell("mu", "dev", "abc123", TestSvc, []string{"/bin/sh"})
+	assertion.NotNil(err)
+	ecsMock.AssertNotCalled(t, "ExecuteCommand", mock.Anything)
+}
@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"os"
+	"os/signal"
+
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// terminalSizeQueue reports the size of the local terminal to a remote session whenever it is resized
+type terminalSizeQueue struct {
+	fd      int
+	sizes   chan remotecommand.TerminalSize
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+func newTerminalSizeQueue(fd int) *terminalSizeQueue {
+	queue := &terminalSizeQueue{
+		fd:      fd,
+		sizes:   make(chan remotecommand.TerminalSize, 1),
+		signals: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+	queue.push()
+	notifyResize(queue.signals)
+
+	go func() {
+		for {
+			select {
+			case <-queue.signals:
+				queue.push()
+			case <-queue.done:
+				return
+			}
+		}
+	}()
+	return queue
+}
+
+func (queue *terminalSizeQueue) push() {
+	width, height, err := terminal.GetSize(queue.fd)
+	if err != nil {
+		return
+	}
+	size := remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+
+	// only the latest size matters, replace a pending one
+	select {
+	case <-queue.sizes:
+	default:
+	}
+	queue.sizes <- size
+}
+
+// Next blocks until the terminal is resized, returning nil once the queue is stopped
+func (queue *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-queue.sizes:
+		return &size
+	case <-queue.done:
+		return nil
+	}
+}
+
+func (queue *terminalSizeQueue) stop() {
+	signal.Stop(queue.signals)
+	close(queue.done)
+}
@@ -0,0 +1,9 @@
+package aws
+
+import (
+	"os"
+)
+
+// notifyResize is a no-op, windows consoles don't signal resizes so only the initial size is sent
+func notifyResize(signals chan<- os.Signal) {
+}
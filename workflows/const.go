@@ -24,6 +24,18 @@ var SvcEnvironmentTableHeader = []string{EnvironmentHeader, SvcRevisionHeader, S
 // SvcTaskContainerHeader is the header for container task detail
 var SvcTaskContainerHeader = []string{"Environment", "Container", "Task", "Instance"}
 
+// SvcScalingTableHeader is the header array for the autoscaling table
+var SvcScalingTableHeader = []string{EnvironmentHeader, SvcCapacityHeader, SvcPoliciesHeader, SvcSchedulesHeader, SvcLastActivityHeader}
+
+// SvcImageTableHeader is the header array for the service image table
+var SvcImageTableHeader = []string{EnvironmentHeader, SvcImageTagHeader, SvcImageHeader}
+
+// SvcScheduleTableHeader is the header array for the service schedule table
+var SvcScheduleTableHeader = []string{SvcScheduleHeader, SvcExpressionHeader, SvcStateHeader, SvcLastRunHeader, SvcNextRunsHeader}
+
+// EnvRBACTableHeader is the header array for the environment rbac table
+var EnvRBACTableHeader = []string{RBACRoleHeader, RBACScopeHeader, RBACKindHeader, RBACSubjectHeader}
+
 // PipeLineServiceHeader is the header for the pipeline service table
 var PipeLineServiceHeader = []string{SvcServiceHeader, SvcStackHeader, SvcStatusHeader, SvcLastUpdateHeader}
 
@@ -68,7 +80,16 @@ const (
 	SvcPipelineURLLabel    = "Pipeline URL"
 	SvcDeploymentsLabel    = "Deployments"
 	SvcContainersLabel     = "Containers"
+	SvcScalingLabel        = "Autoscaling"
+	SvcImagesLabel         = "Images"
+	SvcCapacityHeader      = "Min/Max"
+	SvcPoliciesHeader      = "Policies"
+	SvcSchedulesHeader     = "Scheduled Actions"
+	SvcLastActivityHeader  = "Last Activity"
 	BaseURLHeader          = "Base URL"
+	LatestAMIHeader        = "Latest AMI"
+	PinnedAMIHeader        = "Pinned AMI"
+	OutdatedAMIFormat      = "%d of %d instances are not on AMI '%s', run '%s' to replace them\n"
 	EnvTagKey              = "environment"
 	SvcTagKey              = "service"
 	SvcCodePipelineURLKey  = "CodePipelineUrl"
@@ -87,6 +108,7 @@ const (
 	MEMAvail               = "Mem Avail"
 	NumTasks               = "# Tasks"
 	SvcImageURLKey         = "ImageUrl"
+	SvcImageTagKey         = "ImageTag"
 	SvcStageHeader         = "Stage"
 	SvcServiceHeader       = "Service"
 	ServicesHeader         = "Services"
@@ -94,17 +116,40 @@ const (
 	SvcStatusHeader        = "Status"
 	SvcRevisionHeader      = "Revision"
 	SvcImageHeader         = "Image"
+	SvcImageTagHeader      = "Tag"
+	SvcScheduleHeader      = "Schedule"
+	SvcExpressionHeader    = "Expression"
+	SvcStateHeader         = "State"
+	SvcLastRunHeader       = "Last Run"
+	SvcNextRunsHeader      = "Next Runs"
+	SvcScheduleTagKey      = "schedule"
+	SvcScheduleNextRuns    = 3
 	EnvironmentHeader      = "Environment"
+	RBACRoleHeader         = "Role"
+	RBACScopeHeader        = "Scope"
+	RBACKindHeader         = "Kind"
+	RBACSubjectHeader      = "Subject"
+	RBACUserKind           = "IAM User"
+	RBACIAMRoleKind        = "IAM Role"
+	RBACServiceKind        = "Service"
 	SvcStackHeader         = "Stack"
 	SvcLastUpdateHeader    = "Last Update"
 	SvcCmdTaskExecutingLog = "Creating service executor...\n"
 	SvcCmdTaskResultLog    = "Service executor complete with result:\n%s\n"
 	SvcCmdTaskErrorLog     = "The following error has occurred executing the command:  '%v'"
+	SvcCmdTaskWaitingLog   = "Waiting for task %s to complete..."
+	SvcCmdTaskCompleteLog  = "Task %s completed successfully"
+	SvcShellOpeningLog     = "Opening session in %s of service %s in environment %s"
+	DefaultShellCommand    = "/bin/sh"
+	MuIgnoreFile           = ".muignore"
 	ECSAvailabilityZoneKey = "ecs.availability-zone"
 	ECSInstanceTypeKey     = "ecs.instance-type"
 	ECSAMIKey              = "ecs.ami-id"
 )
 
+// defaultSidecarMemory is the memory (in MiB) reserved for sidecars that don't declare any
+const defaultSidecarMemory = 128
+
 // TagInterface used to conform tag structs
 type TagInterface interface{}
 
@@ -130,6 +175,7 @@ type ServiceTags struct {
 // ScheduleTags used to set defaults
 type ScheduleTags struct {
 	Service     string `tag:"service"`
+	Schedule    string `tag:"schedule"`
 	Environment string `tag:"environment"`
 	Type        string `tag:"type"`
 }
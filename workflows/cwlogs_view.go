@@ -15,6 +15,11 @@ type logsWorkflow struct {
 
 // NewEnvironmentLogViewer create a new workflow for following logs environments
 func NewEnvironmentLogViewer(ctx *common.Context, searchDuration time.Duration, follow bool, environmentName string, writer io.Writer, filter string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
 	workflow := new(logsWorkflow)
 
 	logGroup := common.CreateStackName(ctx.Config.Namespace, common.StackTypeEnv, environmentName)
@@ -26,6 +31,11 @@ func NewEnvironmentLogViewer(ctx *common.Context, searchDuration time.Duration,
 
 // NewServiceLogViewer create a new workflow for following logs for services
 func NewServiceLogViewer(ctx *common.Context, searchDuration time.Duration, follow bool, environmentName string, serviceName string, writer io.Writer, filter string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
 	workflow := new(logsWorkflow)
 
 	logGroup := common.CreateStackName(ctx.Config.Namespace, common.StackTypeService, getServiceName(ctx, serviceName), environmentName)
@@ -9,6 +9,10 @@ import (
 
 // NewDatabaseTerminator create a new workflow for terminating a database in an environment
 func NewDatabaseTerminator(ctx *common.Context, serviceName string, environmentName string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(databaseWorkflow)
 
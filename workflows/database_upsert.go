@@ -13,6 +13,10 @@ import (
 
 // NewDatabaseUpserter create a new workflow for deploying a database in an environment
 func NewDatabaseUpserter(ctx *common.Context, environmentName string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(databaseWorkflow)
 	workflow.codeRevision = ctx.Config.Repo.Revision
@@ -13,14 +13,25 @@ type environmentWorkflow struct {
 	repoName                  string
 	cloudFormationRoleArn     string
 	ec2RoleArn                string
+	imageID                   string
 	kubernetesResourceManager common.KubernetesResourceManager
 	rbacUsers                 []*subjectRoleBinding
+	rbacIAMRoles              []*subjectRoleBinding
 	rbacServices              []*subjectRoleBinding
+	rbacRoles                 []*customRole
 }
 
 type subjectRoleBinding struct {
-	Name string
-	Role string
+	Name   string
+	Role   string
+	Arn    string
+	Custom bool
+}
+
+type customRole struct {
+	Name       string
+	Rules      []common.RBACRule
+	Namespaces []string
 }
 
 func colorizeStackStatus(stackStatus string) string {
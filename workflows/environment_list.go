@@ -12,17 +12,36 @@ func NewEnvironmentLister(ctx *common.Context, writer io.Writer) Executor {
 
 	workflow := new(environmentWorkflow)
 
+	// environments in other regions or accounts are listed from the stacks of their own
+	stackListers := []common.StackLister{ctx.StackManager}
+	listed := map[*common.Context]bool{ctx: true}
+	for _, environment := range ctx.Config.Environments {
+		envCtx, err := ctx.ForEnvironment(environment.Name)
+		if err != nil {
+			return newErrorExecutor(err)
+		}
+		if listed[envCtx] {
+			continue
+		}
+		listed[envCtx] = true
+		stackListers = append(stackListers, envCtx.StackManager)
+	}
+
 	return newPipelineExecutor(
-		workflow.environmentLister(ctx.Config.Namespace, ctx.StackManager, writer),
+		workflow.environmentLister(ctx.Config.Namespace, stackListers, writer),
 	)
 }
 
-func (workflow *environmentWorkflow) environmentLister(namespace string, stackLister common.StackLister, writer io.Writer) Executor {
+func (workflow *environmentWorkflow) environmentLister(namespace string, stackListers []common.StackLister, writer io.Writer) Executor {
 
 	return func() error {
-		stacks, err := stackLister.ListStacks(common.StackTypeEnv, namespace)
-		if err != nil {
-			return err
+		stacks := make([]*common.Stack, 0)
+		for _, stackLister := range stackListers {
+			regionStacks, err := stackLister.ListStacks(common.StackTypeEnv, namespace)
+			if err != nil {
+				return err
+			}
+			stacks = append(stacks, regionStacks...)
 		}
 
 		table := CreateTableSection(writer, EnvironmentShowHeader)
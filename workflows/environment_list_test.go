@@ -1,9 +1,11 @@
 package workflows
 
 import (
+	"bytes"
+	"testing"
+
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestNewEnvironmentLister(t *testing.T) {
@@ -12,3 +14,26 @@ func TestNewEnvironmentLister(t *testing.T) {
 	lister := NewEnvironmentLister(ctx, nil)
 	assert.NotNil(lister)
 }
+
+func TestEnvironmentLister_Regions(t *testing.T) {
+	assert := assert.New(t)
+
+	usStackLister := new(mockedStackListerForScaling)
+	usStackLister.On("ListStacks", common.StackTypeEnv, "mu").Return([]*common.Stack{
+		{Name: "mu-environment-dev", Tags: map[string]string{EnvTagKey: "dev"}},
+	}, nil)
+	euStackLister := new(mockedStackListerForScaling)
+	euStackLister.On("ListStacks", common.StackTypeEnv, "mu").Return([]*common.Stack{
+		{Name: "mu-environment-prod", Tags: map[string]string{EnvTagKey: "prod"}},
+	}, nil)
+
+	buf := new(bytes.Buffer)
+	workflow := new(environmentWorkflow)
+	err := workflow.environmentLister("mu", []common.StackLister{usStackLister, euStackLister}, buf)()
+	assert.Nil(err)
+
+	usStackLister.AssertExpectations(t)
+	euStackLister.AssertExpectations(t)
+	assert.Contains(buf.String(), "mu-environment-dev")
+	assert.Contains(buf.String(), "mu-environment-prod")
+}
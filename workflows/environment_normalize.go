@@ -15,6 +15,31 @@ func (workflow *environmentWorkflow) environmentNormalizer() Executor {
 		if workflow.environment.Discovery.Provider == "consul" {
 			return fmt.Errorf("Consul is no longer supported as a service discovery provider.  Check out the mu-consul extension for an alternative: https://github.com/stelligent/mu-consul")
 		}
+
+		nodeGroupNames := make(map[string]bool)
+		for i := range workflow.environment.Cluster.NodeGroups {
+			nodeGroup := &workflow.environment.Cluster.NodeGroups[i]
+			if nodeGroup.Name == "" {
+				return fmt.Errorf("Node group %d of environment '%s' has no name", i+1, workflow.environment.Name)
+			}
+			if nodeGroupNames[nodeGroup.ResourceName()] {
+				return fmt.Errorf("Node group '%s' of environment '%s' is defined more than once", nodeGroup.Name, workflow.environment.Name)
+			}
+			nodeGroupNames[nodeGroup.ResourceName()] = true
+
+			if nodeGroup.MinSize == 0 {
+				nodeGroup.MinSize = 1
+			}
+			if nodeGroup.DesiredSize < nodeGroup.MinSize {
+				nodeGroup.DesiredSize = nodeGroup.MinSize
+			}
+			if nodeGroup.MaxSize == 0 {
+				nodeGroup.MaxSize = 3
+			}
+			if nodeGroup.MaxSize < nodeGroup.DesiredSize {
+				nodeGroup.MaxSize = nodeGroup.DesiredSize
+			}
+		}
 		return nil
 	}
 }
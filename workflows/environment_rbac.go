@@ -0,0 +1,162 @@
+package workflows
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stelligent/mu/common"
+)
+
+const rbacClusterScope = "cluster"
+
+// NewEnvironmentRBACViewer create a new workflow for showing the rbac bindings of an environment
+func NewEnvironmentRBACViewer(ctx *common.Context, environmentName string, writer io.Writer) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(environmentWorkflow)
+
+	return newPipelineExecutor(
+		workflow.environmentFinder(&ctx.Config, environmentName),
+		workflow.environmentRBACViewer(writer),
+	)
+}
+
+func isBuiltinRBACRole(role common.RBACRole) bool {
+	return role == common.RBACRoleAdmin || role == common.RBACRoleView || role == common.RBACRoleDeploy
+}
+
+// rbacBindingResolver resolves the subjects and custom roles of the bindings that apply to an environment
+func (workflow *environmentWorkflow) rbacBindingResolver(bindings []common.RoleBinding, environmentName string) error {
+	workflow.rbacServices = make([]*subjectRoleBinding, 0)
+	workflow.rbacUsers = make([]*subjectRoleBinding, 0)
+	workflow.rbacIAMRoles = make([]*subjectRoleBinding, 0)
+	workflow.rbacRoles = make([]*customRole, 0)
+
+	// custom roles are defined by the binding with the rules, and may be bound by others
+	customRoles := make(map[common.RBACRole]*customRole)
+	for _, binding := range bindings {
+		if len(binding.Rules) == 0 {
+			if len(binding.Namespaces) > 0 {
+				return fmt.Errorf("Binding for role '%s' has namespaces but no rules, namespaces can only be set on custom roles", binding.Role)
+			}
+			continue
+		}
+		if isBuiltinRBACRole(binding.Role) {
+			return fmt.Errorf("Role '%s' is built in and can't define rules", binding.Role)
+		}
+		if _, ok := customRoles[binding.Role]; ok {
+			return fmt.Errorf("Role '%s' has rules defined more than once", binding.Role)
+		}
+		customRoles[binding.Role] = &customRole{
+			Name:       string(binding.Role),
+			Rules:      binding.Rules,
+			Namespaces: binding.Namespaces,
+		}
+	}
+
+	usedRoles := make(map[common.RBACRole]bool)
+	for _, binding := range bindings {
+		if len(binding.Environments) > 0 {
+			found := false
+			for _, env := range binding.Environments {
+				if env == environmentName {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				log.Debugf("Skipping binding %v - unable to match env %v", binding, environmentName)
+				continue
+			}
+		}
+
+		if !isBuiltinRBACRole(binding.Role) {
+			role, ok := customRoles[binding.Role]
+			if !ok {
+				return fmt.Errorf("Unknown rbac role '%s', define its rules or use one of '%s', '%s' or '%s'", binding.Role, common.RBACRoleAdmin, common.RBACRoleView, common.RBACRoleDeploy)
+			}
+			if !usedRoles[binding.Role] {
+				usedRoles[binding.Role] = true
+				workflow.rbacRoles = append(workflow.rbacRoles, role)
+			}
+		}
+
+		for _, service := range binding.Services {
+			log.Debugf("Binding service %s to role %s", service, binding.Role)
+			workflow.rbacServices = append(workflow.rbacServices, &subjectRoleBinding{
+				Name:   service,
+				Role:   string(binding.Role),
+				Custom: !isBuiltinRBACRole(binding.Role),
+			})
+		}
+		for _, user := range binding.Users {
+			log.Debugf("Binding user %s to role %s", user, binding.Role)
+			workflow.rbacUsers = append(workflow.rbacUsers, &subjectRoleBinding{
+				Name: user,
+				Role: string(binding.Role),
+			})
+		}
+		for _, iamRole := range binding.IAMRoles {
+			log.Debugf("Binding IAM role %s to role %s", iamRole, binding.Role)
+			subject := &subjectRoleBinding{
+				Name: iamRole,
+				Role: string(binding.Role),
+			}
+			// roles may be given by ARN, to allow roles with a path or in another account.  aws-auth only matches
+			// the ARN of a role without its path, so the path is dropped
+			if strings.HasPrefix(iamRole, "arn:") {
+				roleIdx := strings.Index(iamRole, ":role/")
+				if roleIdx < 0 {
+					return fmt.Errorf("IAM role '%s' isn't the ARN of a role", iamRole)
+				}
+				subject.Name = iamRole[strings.LastIndex(iamRole, "/")+1:]
+				subject.Arn = fmt.Sprintf("%s:role/%s", iamRole[:roleIdx], subject.Name)
+			}
+			workflow.rbacIAMRoles = append(workflow.rbacIAMRoles, subject)
+		}
+	}
+	return nil
+}
+
+// rbacScope returns where a role applies, either the cluster or a list of namespaces
+func (workflow *environmentWorkflow) rbacScope(role string) string {
+	for _, custom := range workflow.rbacRoles {
+		if custom.Name == role && len(custom.Namespaces) > 0 {
+			return strings.Join(custom.Namespaces, ", ")
+		}
+	}
+	return rbacClusterScope
+}
+
+func (workflow *environmentWorkflow) environmentRBACViewer(writer io.Writer) Executor {
+	return func() error {
+		table := CreateTableSection(writer, EnvRBACTableHeader)
+
+		for _, user := range workflow.rbacUsers {
+			table.Append([]string{Bold(user.Role), workflow.rbacScope(user.Role), RBACUserKind, user.Name})
+		}
+		for _, iamRole := range workflow.rbacIAMRoles {
+			name := iamRole.Name
+			if iamRole.Arn != "" {
+				name = iamRole.Arn
+			}
+			table.Append([]string{Bold(iamRole.Role), workflow.rbacScope(iamRole.Role), RBACIAMRoleKind, name})
+		}
+		for _, service := range workflow.rbacServices {
+			scope := workflow.rbacScope(service.Role)
+			if service.Role == common.RBACRoleDeploy {
+				// services deploy into their own namespace, and can view the rest of the cluster
+				scope = fmt.Sprintf("mu-service-%s", service.Name)
+			}
+			table.Append([]string{Bold(service.Role), scope, RBACServiceKind, service.Name})
+		}
+
+		table.Render()
+		return nil
+	}
+}
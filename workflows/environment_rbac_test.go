@@ -0,0 +1,102 @@
+package workflows
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRBACBindingResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	bindings := []common.RoleBinding{
+		{
+			Role:  "admin",
+			Users: []string{"alice"},
+		},
+		{
+			Role:         "log-reader",
+			Environments: []string{"dev"},
+			Rules: []common.RBACRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get", "list"}},
+			},
+			IAMRoles: []string{"Ops", "arn:aws:iam::123456789012:role/support/Support"},
+		},
+		{
+			Role:       "config-editor",
+			Namespaces: []string{"mu-service-foo"},
+			Rules: []common.RBACRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"*"}},
+			},
+		},
+		{
+			Role:         "config-editor",
+			Environments: []string{"prod"},
+			Users:        []string{"bob"},
+		},
+		{
+			Role:     "deploy",
+			Services: []string{"foo"},
+		},
+	}
+
+	workflow := new(environmentWorkflow)
+	err := workflow.rbacBindingResolver(bindings, "dev")
+	assert.Nil(err)
+
+	assert.Len(workflow.rbacUsers, 1)
+	assert.Equal("alice", workflow.rbacUsers[0].Name)
+	assert.Len(workflow.rbacServices, 1)
+	assert.False(workflow.rbacServices[0].Custom)
+	assert.Len(workflow.rbacIAMRoles, 2)
+	assert.Equal("Ops", workflow.rbacIAMRoles[0].Name)
+	assert.Equal("", workflow.rbacIAMRoles[0].Arn)
+	assert.Equal("Support", workflow.rbacIAMRoles[1].Name)
+	assert.Equal("arn:aws:iam::123456789012:role/Support", workflow.rbacIAMRoles[1].Arn)
+
+	assert.Len(workflow.rbacRoles, 2)
+	assert.Equal("log-reader", workflow.rbacRoles[0].Name)
+	assert.Equal("config-editor", workflow.rbacRoles[1].Name)
+
+	err = workflow.rbacBindingResolver(bindings, "prod")
+	assert.Nil(err)
+	assert.Len(workflow.rbacUsers, 2)
+	assert.Len(workflow.rbacIAMRoles, 0)
+
+	// only the custom roles of bindings in the environment are created
+	assert.Len(workflow.rbacRoles, 1)
+	assert.Equal("config-editor", workflow.rbacRoles[0].Name)
+	assert.Equal([]string{"mu-service-foo"}, workflow.rbacRoles[0].Namespaces)
+
+	buf := new(bytes.Buffer)
+	err = workflow.environmentRBACViewer(buf)()
+	assert.Nil(err)
+	assert.Contains(buf.String(), "mu-service-foo")
+	assert.Contains(buf.String(), "bob")
+}
+
+func TestRBACBindingResolver_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+
+	err := workflow.rbacBindingResolver([]common.RoleBinding{{Role: "unknown", Users: []string{"alice"}}}, "dev")
+	assert.NotNil(err)
+
+	err = workflow.rbacBindingResolver([]common.RoleBinding{{Role: "admin", Rules: []common.RBACRule{{Verbs: []string{"*"}}}}}, "dev")
+	assert.NotNil(err)
+
+	err = workflow.rbacBindingResolver([]common.RoleBinding{{Role: "view", Namespaces: []string{"default"}}}, "dev")
+	assert.NotNil(err)
+
+	err = workflow.rbacBindingResolver([]common.RoleBinding{
+		{Role: "reader", Rules: []common.RBACRule{{Verbs: []string{"get"}}}},
+		{Role: "reader", Rules: []common.RBACRule{{Verbs: []string{"list"}}}},
+	}, "dev")
+	assert.NotNil(err)
+
+	err = workflow.rbacBindingResolver([]common.RoleBinding{{Role: "view", IAMRoles: []string{"arn:aws:iam::123456789012:user/alice"}}}, "dev")
+	assert.NotNil(err)
+}
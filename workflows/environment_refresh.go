@@ -0,0 +1,246 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/stelligent/mu/common"
+)
+
+// NewEnvironmentAMIRefresher create a new workflow for replacing the instances of an environment with the latest AMI
+func NewEnvironmentAMIRefresher(ctx *common.Context, environmentName string, maxInFlight int, timeout time.Duration) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(environmentWorkflow)
+	envStackParams := make(map[string]string)
+	workflow.codeRevision = ctx.Config.Repo.Revision
+	workflow.repoName = ctx.Config.Repo.Slug
+
+	return newPipelineExecutor(
+		workflow.environmentFinder(&ctx.Config, environmentName),
+		workflow.environmentNormalizer(),
+		newConditionalExecutor(workflow.isEcsProvider(),
+			newPipelineExecutor(
+				workflow.environmentRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, envStackParams),
+				workflow.environmentImageRefresher(ctx.Config.Namespace, ctx.StackManager, ctx.StackManager, ctx.StackManager),
+				workflow.environmentInstanceReplacer(ctx.Config.Namespace, maxInFlight, timeout, ctx.ClusterManager, ctx.InstanceManager),
+			),
+			newErrorExecutor(fmt.Errorf("Environment '%s' doesn't use the '%s' provider, run `mu env up %s` to update its instances", environmentName, common.EnvProviderEcs, environmentName)),
+		),
+	)
+}
+
+// environmentImageRefresher updates the launch template of the environment to the latest AMI.  The rolling update
+// of the autoscaling group is turned off, so the instances are left for environmentInstanceReplacer
+func (workflow *environmentWorkflow) environmentImageRefresher(namespace string, imageFinder common.ImageFinder, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) Executor {
+	return func() error {
+		environment := workflow.environment
+		envStackName := common.CreateStackName(namespace, common.StackTypeEnv, environment.Name)
+		envStack := stackWaiter.AwaitFinalStatus(envStackName)
+		if envStack == nil {
+			return fmt.Errorf("Unable to find stack '%s', run `mu env up %s` first", envStackName, environment.Name)
+		}
+
+		workflow.imageID = environment.Cluster.ImageID
+		if workflow.imageID == "" {
+			var err error
+			workflow.imageID, err = imageFinder.FindLatestImageID(ecsImageOwner, ecsImagePattern)
+			if err != nil {
+				return err
+			}
+		} else {
+			log.Warningf("Environment '%s' has 'cluster.imageId' set, the instances will be replaced with image '%s'", environment.Name, workflow.imageID)
+		}
+
+		if envStack.Parameters["ImageId"] == workflow.imageID && envStack.Parameters["RollingUpdate"] == "false" {
+			log.Debugf("Launch template of environment '%s' is already on image '%s'", environment.Name, workflow.imageID)
+			return nil
+		}
+
+		stackParams := make(map[string]string)
+		for key, value := range envStack.Parameters {
+			stackParams[key] = value
+		}
+		stackParams["ImageId"] = workflow.imageID
+		// `mu env up` turns the rolling update back on
+		stackParams["RollingUpdate"] = "false"
+
+		log.Noticef("Updating launch template of environment '%s' to image '%s' ...", environment.Name, workflow.imageID)
+		return workflow.environmentStackUpgrader(envStackName, common.TemplateEnvECS, stackParams, stackUpserter, stackWaiter)
+	}
+}
+
+// environmentInstanceReplacer replaces the instances that aren't on the image of the environment, up to maxInFlight at a time.
+// The tasks are drained off each batch of instances, and rescheduled and running, before the instances are terminated
+func (workflow *environmentWorkflow) environmentInstanceReplacer(namespace string, maxInFlight int, timeout time.Duration, clusterManager common.ClusterManager, instanceManager common.InstanceManager) Executor {
+	return func() error {
+		environment := workflow.environment
+		clusterName := common.CreateStackName(namespace, common.StackTypeEnv, environment.Name)
+
+		containerInstances, err := clusterManager.ListInstances(clusterName)
+		if err != nil {
+			return err
+		}
+
+		instanceImages, err := listInstanceImages(containerInstances, instanceManager)
+		if err != nil {
+			return err
+		}
+
+		activeCount := 0
+		outdated := make([]common.ContainerInstance, 0)
+		for _, containerInstance := range containerInstances {
+			if common.StringValue(containerInstance.Status) != ecs.ContainerInstanceStatusActive {
+				continue
+			}
+			activeCount++
+			if instanceImages[common.StringValue(containerInstance.Ec2InstanceId)] != workflow.imageID {
+				outdated = append(outdated, containerInstance)
+			}
+		}
+
+		if len(outdated) == 0 {
+			log.Noticef("All %d instances of environment '%s' are on image '%s'", activeCount, environment.Name, workflow.imageID)
+			return nil
+		}
+
+		if maxInFlight < 1 {
+			maxInFlight = 1
+		}
+
+		for start := 0; start < len(outdated); start += maxInFlight {
+			end := start + maxInFlight
+			if end > len(outdated) {
+				end = len(outdated)
+			}
+
+			containerInstanceArns := make([]string, 0)
+			instanceIds := make([]string, 0)
+			for _, containerInstance := range outdated[start:end] {
+				containerInstanceArns = append(containerInstanceArns, common.StringValue(containerInstance.ContainerInstanceArn))
+				instanceIds = append(instanceIds, common.StringValue(containerInstance.Ec2InstanceId))
+			}
+
+			log.Noticef("Draining instances %v of environment '%s' ...", instanceIds, environment.Name)
+			if err := clusterManager.DrainInstances(clusterName, containerInstanceArns...); err != nil {
+				return err
+			}
+			err := awaitClusterCondition(timeout, fmt.Sprintf("the tasks to drain off instances %v", instanceIds), func() (bool, error) {
+				return isClusterInstancesDrained(clusterName, containerInstanceArns, clusterManager)
+			})
+			if err != nil {
+				return err
+			}
+
+			log.Noticef("Terminating instances %v of environment '%s' ...", instanceIds, environment.Name)
+			if err := instanceManager.TerminateInstances(instanceIds...); err != nil {
+				return err
+			}
+			err = awaitClusterCondition(timeout, fmt.Sprintf("the replacements of instances %v", instanceIds), func() (bool, error) {
+				return isClusterInstancesActive(clusterName, activeCount, clusterManager)
+			})
+			if err != nil {
+				return err
+			}
+			err = awaitClusterCondition(timeout, "the services to run their desired tasks", func() (bool, error) {
+				return isClusterServicesStable(clusterName, clusterManager)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		log.Noticef("Replaced %d instances of environment '%s' with image '%s'", len(outdated), environment.Name, workflow.imageID)
+		return nil
+	}
+}
+
+// listInstanceImages returns the AMIs of the container instances by instance id
+func listInstanceImages(containerInstances []common.ContainerInstance, instanceLister common.InstanceLister) (map[string]string, error) {
+	instanceImages := make(map[string]string)
+	if len(containerInstances) == 0 {
+		return instanceImages, nil
+	}
+
+	instanceIds := make([]string, len(containerInstances))
+	for i, containerInstance := range containerInstances {
+		instanceIds[i] = common.StringValue(containerInstance.Ec2InstanceId)
+	}
+	instances, err := instanceLister.ListInstances(instanceIds...)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		instanceImages[common.StringValue(instance.InstanceId)] = common.StringValue(instance.ImageId)
+	}
+	return instanceImages, nil
+}
+
+func isClusterInstancesDrained(clusterName string, containerInstanceArns []string, clusterInstanceLister common.ClusterInstanceLister) (bool, error) {
+	containerInstances, err := clusterInstanceLister.ListInstances(clusterName)
+	if err != nil {
+		return false, err
+	}
+	draining := make(map[string]bool)
+	for _, arn := range containerInstanceArns {
+		draining[arn] = true
+	}
+	for _, containerInstance := range containerInstances {
+		if draining[common.StringValue(containerInstance.ContainerInstanceArn)] && common.Int64Value(containerInstance.RunningTasksCount) > 0 {
+			log.Debugf("Cluster: %s, Instance: %s, Running Tasks: %d", clusterName, common.StringValue(containerInstance.Ec2InstanceId), common.Int64Value(containerInstance.RunningTasksCount))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isClusterInstancesActive(clusterName string, activeCount int, clusterInstanceLister common.ClusterInstanceLister) (bool, error) {
+	containerInstances, err := clusterInstanceLister.ListInstances(clusterName)
+	if err != nil {
+		return false, err
+	}
+	active := 0
+	for _, containerInstance := range containerInstances {
+		if common.StringValue(containerInstance.Status) == ecs.ContainerInstanceStatusActive && common.BoolValue(containerInstance.AgentConnected) {
+			active++
+		}
+	}
+	log.Debugf("Cluster: %s, Active Instances: %d of %d", clusterName, active, activeCount)
+	return active >= activeCount, nil
+}
+
+func isClusterServicesStable(clusterName string, clusterServiceLister common.ClusterServiceLister) (bool, error) {
+	services, err := clusterServiceLister.ListServices(clusterName)
+	if err != nil {
+		return false, err
+	}
+	for _, service := range services {
+		if service.RunningCount < service.DesiredCount || service.PendingCount > 0 {
+			log.Debugf("Cluster: %s, Service: %s, Running Tasks: %d of %d", clusterName, service.Name, service.RunningCount, service.DesiredCount)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// awaitClusterCondition polls until check passes, or fails once timeout has passed
+func awaitClusterCondition(timeout time.Duration, description string, check func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out after %v waiting for %s", timeout, description)
+		}
+		time.Sleep(time.Duration(PollDelay) * time.Second)
+	}
+}
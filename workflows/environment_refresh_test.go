@@ -0,0 +1,162 @@
+package workflows
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockedClusterManagerForRefresh struct {
+	mock.Mock
+	common.ClusterManager
+}
+
+func (m *mockedClusterManagerForRefresh) ListInstances(clusterName string) ([]common.ContainerInstance, error) {
+	args := m.Called(clusterName)
+	return args.Get(0).([]common.ContainerInstance), args.Error(1)
+}
+func (m *mockedClusterManagerForRefresh) DrainInstances(clusterName string, containerInstanceArns ...string) error {
+	args := m.Called(clusterName, containerInstanceArns)
+	return args.Error(0)
+}
+func (m *mockedClusterManagerForRefresh) ListServices(clusterName string) ([]common.ClusterService, error) {
+	args := m.Called(clusterName)
+	return args.Get(0).([]common.ClusterService), args.Error(1)
+}
+
+type mockedInstanceManager struct {
+	mock.Mock
+	common.InstanceManager
+}
+
+func (m *mockedInstanceManager) ListInstances(instanceIds ...string) ([]common.Instance, error) {
+	args := m.Called(instanceIds)
+	return args.Get(0).([]common.Instance), args.Error(1)
+}
+func (m *mockedInstanceManager) TerminateInstances(instanceIds ...string) error {
+	args := m.Called(instanceIds)
+	return args.Error(0)
+}
+
+func newContainerInstance(instanceID string, status string, runningTasks int64) common.ContainerInstance {
+	return &ecs.ContainerInstance{
+		ContainerInstanceArn: aws.String("arn:aws:ecs:us-east-1:123456789012:container-instance/" + instanceID),
+		Ec2InstanceId:        aws.String(instanceID),
+		Status:               aws.String(status),
+		AgentConnected:       aws.Bool(true),
+		RunningTasksCount:    aws.Int64(runningTasks),
+	}
+}
+
+func TestNewEnvironmentAMIRefresher(t *testing.T) {
+	assert := assert.New(t)
+	ctx := common.NewContext()
+	refresher := NewEnvironmentAMIRefresher(ctx, "foo", 1, time.Minute)
+	assert.NotNil(refresher)
+}
+
+func TestEnvironmentImageRefresher(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEcs,
+	}
+
+	stackManager := new(mockedStackManagerForUpgrade)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{
+		Status:     common.StackStatusCreateComplete,
+		Parameters: map[string]string{"ImageId": "ami-old", "RollingUpdate": "true", "InstanceType": "m5.large"},
+	})
+	stackManager.On("UpsertStack", "mu-environment-foo", common.TemplateEnvECS).Return(nil)
+	stackManager.On("FindLatestImageID", ecsImageOwner, ecsImagePattern).Return("ami-new", nil)
+
+	err := workflow.environmentImageRefresher("mu", stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackManager.AssertExpectations(t)
+	assert.Equal("ami-new", workflow.imageID)
+	assert.Equal(map[string]string{"ImageId": "ami-new", "RollingUpdate": "false", "InstanceType": "m5.large"}, stackManager.upsertedParams[0])
+}
+
+func TestEnvironmentInstanceReplacer(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEcs,
+	}
+	workflow.imageID = "ami-new"
+
+	clusterManager := new(mockedClusterManagerForRefresh)
+	clusterManager.On("ListInstances", "mu-environment-foo").Return([]common.ContainerInstance{
+		newContainerInstance("i-1", "ACTIVE", 2),
+		newContainerInstance("i-2", "ACTIVE", 1),
+		newContainerInstance("i-3", "ACTIVE", 1),
+	}, nil).Once()
+	clusterManager.On("ListInstances", "mu-environment-foo").Return([]common.ContainerInstance{
+		newContainerInstance("i-1", "DRAINING", 0),
+		newContainerInstance("i-2", "DRAINING", 0),
+		newContainerInstance("i-3", "ACTIVE", 3),
+		newContainerInstance("i-4", "ACTIVE", 0),
+		newContainerInstance("i-5", "ACTIVE", 0),
+	}, nil)
+	clusterManager.On("DrainInstances", "mu-environment-foo", mock.Anything).Return(nil)
+	clusterManager.On("ListServices", "mu-environment-foo").Return([]common.ClusterService{
+		{Name: "mu-foo-dev", DesiredCount: 3, RunningCount: 3},
+	}, nil)
+
+	instanceManager := new(mockedInstanceManager)
+	instanceManager.On("ListInstances", []string{"i-1", "i-2", "i-3"}).Return([]common.Instance{
+		&ec2.Instance{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-old")},
+		&ec2.Instance{InstanceId: aws.String("i-2"), ImageId: aws.String("ami-old")},
+		&ec2.Instance{InstanceId: aws.String("i-3"), ImageId: aws.String("ami-new")},
+	}, nil)
+	instanceManager.On("TerminateInstances", mock.Anything).Return(nil)
+
+	err := workflow.environmentInstanceReplacer("mu", 1, 0, clusterManager, instanceManager)()
+	assert.Nil(err)
+
+	// one outdated instance at a time, the instance on the new image is kept
+	clusterManager.AssertNumberOfCalls(t, "DrainInstances", 2)
+	clusterManager.AssertCalled(t, "DrainInstances", "mu-environment-foo", []string{"arn:aws:ecs:us-east-1:123456789012:container-instance/i-1"})
+	instanceManager.AssertNumberOfCalls(t, "TerminateInstances", 2)
+	instanceManager.AssertCalled(t, "TerminateInstances", []string{"i-1"})
+	instanceManager.AssertCalled(t, "TerminateInstances", []string{"i-2"})
+	instanceManager.AssertNotCalled(t, "TerminateInstances", []string{"i-3"})
+}
+
+func TestEnvironmentInstanceReplacer_NotDrained(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEcs,
+	}
+	workflow.imageID = "ami-new"
+
+	clusterManager := new(mockedClusterManagerForRefresh)
+	clusterManager.On("ListInstances", "mu-environment-foo").Return([]common.ContainerInstance{
+		newContainerInstance("i-1", "ACTIVE", 2),
+	}, nil)
+	clusterManager.On("DrainInstances", "mu-environment-foo", mock.Anything).Return(nil)
+
+	instanceManager := new(mockedInstanceManager)
+	instanceManager.On("ListInstances", []string{"i-1"}).Return([]common.Instance{
+		&ec2.Instance{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-old")},
+	}, nil)
+
+	// the tasks never leave the instance, so it isn't terminated
+	err := workflow.environmentInstanceReplacer("mu", 1, 0, clusterManager, instanceManager)()
+	assert.NotNil(err)
+	instanceManager.AssertNotCalled(t, "TerminateInstances", mock.Anything)
+}
@@ -17,6 +17,10 @@ func NewEnvironmentsTerminator(ctx *common.Context, environmentNames []string) E
 }
 
 func newEnvironmentTerminator(ctx *common.Context, environmentName string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(environmentWorkflow)
 
@@ -0,0 +1,251 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const eksNodeGroupLabel = "eks.amazonaws.com/nodegroup"
+
+// NewEnvironmentUpgrader create a new workflow for upgrading the kubernetes version of an environment
+func NewEnvironmentUpgrader(ctx *common.Context, environmentName string, timeout time.Duration) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(environmentWorkflow)
+	envStackParams := make(map[string]string)
+	workflow.codeRevision = ctx.Config.Repo.Revision
+	workflow.repoName = ctx.Config.Repo.Slug
+
+	return newPipelineExecutor(
+		workflow.environmentFinder(&ctx.Config, environmentName),
+		workflow.environmentNormalizer(),
+		newConditionalExecutor(workflow.isKubernetesProvider(),
+			newPipelineExecutor(
+				workflow.environmentRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, envStackParams),
+				workflow.connectKubernetes(ctx.Config.Namespace, ctx.KubernetesResourceManagerProvider),
+				workflow.environmentKubernetesUpgrader(ctx.Config.Namespace, timeout, ctx.KubernetesResourceManagerProvider, ctx.StackManager, ctx.StackManager, ctx.StackManager),
+			),
+			newErrorExecutor(fmt.Errorf("Environment '%s' doesn't use a kubernetes provider and can't be upgraded", environmentName)),
+		),
+	)
+}
+
+// environmentKubernetesUpgrader upgrades the control plane one minor version at a time to the
+// `cluster.kubernetesVersion` of the environment, then rolls the nodes onto the new version
+func (workflow *environmentWorkflow) environmentKubernetesUpgrader(namespace string, timeout time.Duration, versionGetter common.KubernetesClusterVersionGetter,
+	imageFinder common.ImageFinder, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) Executor {
+	return func() error {
+		environment := workflow.environment
+		targetVersion := environment.Cluster.KubernetesVersion
+		if targetVersion == "" {
+			return fmt.Errorf("Environment '%s' has no 'cluster.kubernetesVersion' to upgrade to", environment.Name)
+		}
+
+		envStackName := common.CreateStackName(namespace, common.StackTypeEnv, environment.Name)
+		envStack := stackWaiter.AwaitFinalStatus(envStackName)
+		if envStack == nil {
+			return fmt.Errorf("Unable to find stack '%s', run `mu env up %s` first", envStackName, environment.Name)
+		}
+
+		controlPlaneVersion, err := versionGetter.GetClusterVersion(envStackName)
+		if err != nil {
+			return err
+		}
+		skew, err := common.KubernetesMinorVersionSkew(controlPlaneVersion, targetVersion)
+		if err != nil {
+			return err
+		}
+		if skew < 0 {
+			return fmt.Errorf("Environment '%s' is running kubernetes '%s', downgrading to '%s' isn't supported", environment.Name, controlPlaneVersion, targetVersion)
+		}
+
+		nodeVersions, err := workflow.kubernetesNodeVersions(envStackName)
+		if err != nil {
+			return err
+		}
+		for nodeGroupName, versions := range nodeVersions {
+			for _, version := range versions {
+				if nodeSkew, err := common.KubernetesMinorVersionSkew(controlPlaneVersion, version); err != nil {
+					return err
+				} else if nodeSkew > 0 {
+					return fmt.Errorf("Nodes of node group '%s' are running kubernetes '%s', which is newer than the control plane '%s'", nodeGroupName, version, controlPlaneVersion)
+				}
+			}
+		}
+
+		stackParams := make(map[string]string)
+		for key, value := range envStack.Parameters {
+			stackParams[key] = value
+		}
+
+		for ; skew > 0; skew-- {
+			// the nodes must be on the version of the control plane before it is upgraded any further
+			if err := workflow.kubernetesNodesRoller(envStackName, controlPlaneVersion, nodeVersions, stackParams, timeout, imageFinder, stackUpserter, stackWaiter); err != nil {
+				return err
+			}
+
+			nextVersion, err := common.NextKubernetesVersion(controlPlaneVersion)
+			if err != nil {
+				return err
+			}
+			log.Noticef("Upgrading control plane of environment '%s' from kubernetes '%s' to '%s' ...", environment.Name, controlPlaneVersion, nextVersion)
+			stackParams["KubernetesVersion"] = nextVersion
+			if err := workflow.environmentStackUpgrader(envStackName, common.TemplateEnvEKS, stackParams, stackUpserter, stackWaiter); err != nil {
+				return err
+			}
+			controlPlaneVersion = nextVersion
+		}
+
+		if err := workflow.kubernetesNodesRoller(envStackName, controlPlaneVersion, nodeVersions, stackParams, timeout, imageFinder, stackUpserter, stackWaiter); err != nil {
+			return err
+		}
+
+		log.Noticef("Environment '%s' is running kubernetes '%s'", environment.Name, controlPlaneVersion)
+		return nil
+	}
+}
+
+// kubernetesNodeVersions returns the kubelet versions of the nodes by node group name, self-managed nodes have no node group name
+func (workflow *environmentWorkflow) kubernetesNodeVersions(envStackName string) (map[string][]string, error) {
+	nodes, err := workflow.kubernetesResourceManager.ListResources("v1", "Node", "")
+	if err != nil {
+		return nil, err
+	}
+
+	nodeVersions := make(map[string][]string)
+	if nodes == nil {
+		return nodeVersions, nil
+	}
+	for _, node := range nodes.Items {
+		version, _, _ := unstructured.NestedString(node.Object, "status", "nodeInfo", "kubeletVersion")
+		nodeGroupName := strings.TrimPrefix(node.GetLabels()[eksNodeGroupLabel], fmt.Sprintf("%s-", envStackName))
+		nodeVersions[nodeGroupName] = append(nodeVersions[nodeGroupName], version)
+	}
+	return nodeVersions, nil
+}
+
+// kubernetesNodesRoller rolls the nodes behind 'version' onto it, one node group at a time in the order of the config
+func (workflow *environmentWorkflow) kubernetesNodesRoller(envStackName string, version string, nodeVersions map[string][]string, stackParams map[string]string,
+	timeout time.Duration, imageFinder common.ImageFinder, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) error {
+	environment := workflow.environment
+
+	isBehind := func(nodeGroupName string) (bool, error) {
+		for _, nodeVersion := range nodeVersions[nodeGroupName] {
+			skew, err := common.KubernetesMinorVersionSkew(version, nodeVersion)
+			if err != nil {
+				return false, err
+			}
+			if skew < 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if len(environment.Cluster.NodeGroups) == 0 {
+		behind, err := isBehind("")
+		if err != nil || !behind {
+			return err
+		}
+		if environment.Cluster.ImageID != "" {
+			log.Warningf("Environment '%s' has 'cluster.imageId' set, the nodes will stay on image '%s'", environment.Name, environment.Cluster.ImageID)
+			return nil
+		}
+
+		imageID, err := imageFinder.FindLatestImageID(eksImageOwner, fmt.Sprintf(eksVersionImagePattern, version))
+		if err != nil {
+			return err
+		}
+		log.Noticef("Rolling nodes of environment '%s' onto kubernetes '%s' ...", environment.Name, version)
+		stackParams["ImageId"] = imageID
+		if err := workflow.environmentStackUpgrader(envStackName, common.TemplateEnvEKS, stackParams, stackUpserter, stackWaiter); err != nil {
+			return err
+		}
+		nodeVersions[""] = []string{version}
+		return workflow.kubernetesPodsRescheduledWaiter(timeout)
+	}
+
+	for _, nodeGroup := range environment.Cluster.NodeGroups {
+		versionParam := fmt.Sprintf("%sVersion", nodeGroup.ResourceName())
+		behind, err := isBehind(nodeGroup.Name)
+		if err != nil {
+			return err
+		}
+		if !behind {
+			stackParams[versionParam] = version
+			continue
+		}
+
+		log.Noticef("Rolling node group '%s' of environment '%s' onto kubernetes '%s' ...", nodeGroup.Name, environment.Name, version)
+		stackParams[versionParam] = version
+		if err := workflow.environmentStackUpgrader(envStackName, common.TemplateEnvEKS, stackParams, stackUpserter, stackWaiter); err != nil {
+			return err
+		}
+		nodeVersions[nodeGroup.Name] = []string{version}
+		if err := workflow.kubernetesPodsRescheduledWaiter(timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// environmentStackUpgrader updates the environment stack with new versions or images, keeping the rest of the parameters
+func (workflow *environmentWorkflow) environmentStackUpgrader(envStackName string, templateName string, stackParams map[string]string, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) error {
+	tags := createTagMap(&EnvironmentTags{
+		Environment: workflow.environment.Name,
+		Type:        string(common.StackTypeEnv),
+		Provider:    string(workflow.environment.Provider),
+		Revision:    workflow.codeRevision,
+		Repo:        workflow.repoName,
+	})
+
+	err := stackUpserter.UpsertStack(envStackName, templateName, workflow.environment, stackParams, tags, "", workflow.cloudFormationRoleArn)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Waiting for stack '%s' to complete", envStackName)
+	stack := stackWaiter.AwaitFinalStatus(envStackName)
+
+	if stack == nil {
+		return fmt.Errorf("Unable to update stack %s", envStackName)
+	}
+	if strings.HasSuffix(stack.Status, "ROLLBACK_COMPLETE") || !strings.HasSuffix(stack.Status, "_COMPLETE") {
+		return fmt.Errorf("Ended in failed status %s %s", stack.Status, stack.StatusReason)
+	}
+	return nil
+}
+
+// kubernetesPodsRescheduledWaiter waits for the pods evicted from the old nodes to be scheduled again
+func (workflow *environmentWorkflow) kubernetesPodsRescheduledWaiter(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		pods, err := workflow.kubernetesResourceManager.ListResources("v1", "Pod", "")
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		if pods != nil {
+			for _, pod := range pods.Items {
+				if phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase"); phase == "Pending" {
+					pending++
+				}
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+		log.Debugf("Environment: %s, Pending Pods: %d", workflow.environment.Name, pending)
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d pods of environment '%s' were not rescheduled within %v", pending, workflow.environment.Name, timeout)
+		}
+		time.Sleep(time.Duration(PollDelay) * time.Second)
+	}
+}
@@ -0,0 +1,181 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type mockedClusterVersionGetter struct {
+	mock.Mock
+}
+
+func (m *mockedClusterVersionGetter) GetClusterVersion(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+type mockedStackManagerForUpgrade struct {
+	mock.Mock
+	common.StackManager
+	upsertedParams []map[string]string
+}
+
+func (m *mockedStackManagerForUpgrade) AwaitFinalStatus(stackName string) *common.Stack {
+	args := m.Called(stackName)
+	return args.Get(0).(*common.Stack)
+}
+func (m *mockedStackManagerForUpgrade) UpsertStack(stackName string, templateName string, templateData interface{}, stackParameters map[string]string, stackTags map[string]string, policy string, roleArn string) error {
+	args := m.Called(stackName, templateName)
+	params := make(map[string]string)
+	for key, value := range stackParameters {
+		params[key] = value
+	}
+	m.upsertedParams = append(m.upsertedParams, params)
+	return args.Error(0)
+}
+func (m *mockedStackManagerForUpgrade) FindLatestImageID(owner string, pattern string) (string, error) {
+	args := m.Called(owner, pattern)
+	return args.String(0), args.Error(1)
+}
+
+func newKubernetesNode(nodeGroup string, version string) unstructured.Unstructured {
+	node := unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"nodeInfo": map[string]interface{}{"kubeletVersion": version},
+		},
+	}}
+	if nodeGroup != "" {
+		node.SetLabels(map[string]string{eksNodeGroupLabel: nodeGroup})
+	}
+	return node
+}
+
+func TestEnvironmentKubernetesUpgrader_NodeGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEks,
+		Cluster: common.Cluster{
+			KubernetesVersion: "1.15",
+			NodeGroups: []common.NodeGroup{
+				{Name: "default"},
+				{Name: "spot", Spot: true},
+			},
+		},
+	}
+
+	versionGetter := new(mockedClusterVersionGetter)
+	versionGetter.On("GetClusterVersion", "mu-environment-foo").Return("1.13", nil)
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "v1", "Node", "").Return(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		newKubernetesNode("mu-environment-foo-default", "v1.13.12-eks-c500e1"),
+		newKubernetesNode("mu-environment-foo-spot", "v1.13.12-eks-c500e1"),
+	}}, nil)
+	kubernetesResourceManager.On("ListResources", "v1", "Pod", "").Return(&unstructured.UnstructuredList{}, nil)
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	stackManager := new(mockedStackManagerForUpgrade)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{
+		Status:     common.StackStatusCreateComplete,
+		Parameters: map[string]string{"KubernetesVersion": "1.13", "NodeGroupDefaultVersion": "", "NodeGroupSpotVersion": ""},
+	})
+	stackManager.On("UpsertStack", "mu-environment-foo", common.TemplateEnvEKS).Return(nil)
+
+	err := workflow.environmentKubernetesUpgrader("mu", 0, versionGetter, stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	// control plane to 1.14, both node groups to 1.14, control plane to 1.15, both node groups to 1.15
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 6)
+	stackManager.AssertNotCalled(t, "FindLatestImageID", mock.Anything, mock.Anything)
+
+	upserts := stackManager.upsertedParams
+	assert.Equal(map[string]string{"KubernetesVersion": "1.14", "NodeGroupDefaultVersion": "1.13", "NodeGroupSpotVersion": "1.13"}, upserts[0])
+	assert.Equal(map[string]string{"KubernetesVersion": "1.14", "NodeGroupDefaultVersion": "1.14", "NodeGroupSpotVersion": "1.13"}, upserts[1])
+	assert.Equal(map[string]string{"KubernetesVersion": "1.14", "NodeGroupDefaultVersion": "1.14", "NodeGroupSpotVersion": "1.14"}, upserts[2])
+	assert.Equal("1.15", upserts[3]["KubernetesVersion"])
+	assert.Equal(map[string]string{"KubernetesVersion": "1.15", "NodeGroupDefaultVersion": "1.15", "NodeGroupSpotVersion": "1.14"}, upserts[4])
+	assert.Equal(map[string]string{"KubernetesVersion": "1.15", "NodeGroupDefaultVersion": "1.15", "NodeGroupSpotVersion": "1.15"}, upserts[5])
+}
+
+func TestEnvironmentKubernetesUpgrader_SelfManaged(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEks,
+		Cluster: common.Cluster{
+			KubernetesVersion: "1.14",
+		},
+	}
+
+	versionGetter := new(mockedClusterVersionGetter)
+	versionGetter.On("GetClusterVersion", "mu-environment-foo").Return("1.14", nil)
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "v1", "Node", "").Return(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		newKubernetesNode("", "v1.13.12-eks-c500e1"),
+	}}, nil)
+	kubernetesResourceManager.On("ListResources", "v1", "Pod", "").Return(&unstructured.UnstructuredList{}, nil)
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	stackManager := new(mockedStackManagerForUpgrade)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{
+		Status:     common.StackStatusCreateComplete,
+		Parameters: map[string]string{"KubernetesVersion": "1.14", "ImageId": "ami-113"},
+	})
+	stackManager.On("UpsertStack", "mu-environment-foo", common.TemplateEnvEKS).Return(nil)
+	stackManager.On("FindLatestImageID", eksImageOwner, "amazon-eks-node-1.14-v*").Return("ami-114", nil)
+
+	err := workflow.environmentKubernetesUpgrader("mu", 0, versionGetter, stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "UpsertStack", 1)
+	assert.Equal("ami-114", stackManager.upsertedParams[0]["ImageId"])
+}
+
+func TestEnvironmentKubernetesUpgrader_Skew(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEks,
+	}
+
+	stackManager := new(mockedStackManagerForUpgrade)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{Status: common.StackStatusCreateComplete})
+
+	versionGetter := new(mockedClusterVersionGetter)
+	versionGetter.On("GetClusterVersion", "mu-environment-foo").Return("1.15", nil)
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "v1", "Node", "").Return(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		newKubernetesNode("mu-environment-foo-default", "v1.16.8-eks-e16311"),
+	}}, nil)
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	// no version to upgrade to
+	err := workflow.environmentKubernetesUpgrader("mu", 0, versionGetter, stackManager, stackManager, stackManager)()
+	assert.NotNil(err)
+
+	// downgrade
+	workflow.environment.Cluster.KubernetesVersion = "1.14"
+	err = workflow.environmentKubernetesUpgrader("mu", 0, versionGetter, stackManager, stackManager, stackManager)()
+	assert.NotNil(err)
+
+	// nodes newer than the control plane
+	workflow.environment.Cluster.KubernetesVersion = "1.16"
+	err = workflow.environmentKubernetesUpgrader("mu", 0, versionGetter, stackManager, stackManager, stackManager)()
+	assert.NotNil(err)
+
+	stackManager.AssertNotCalled(t, "UpsertStack", mock.Anything, mock.Anything)
+}
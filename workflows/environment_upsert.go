@@ -1,9 +1,17 @@
 package workflows
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/stelligent/mu/common"
@@ -13,6 +21,7 @@ var ecsImageOwner = "amazon"
 var ecsImagePattern = "amzn-ami-*-amazon-ecs-optimized"
 var eksImageOwner = "602401143452"
 var eksImagePattern = "amazon-eks-node-v*"
+var eksVersionImagePattern = "amazon-eks-node-%s-v*"
 var ec2ImageOwner = "amazon"
 var ec2ImagePattern = "amzn-ami-hvm-*-x86_64-gp2"
 
@@ -26,6 +35,10 @@ func NewEnvironmentsUpserter(ctx *common.Context, environmentNames []string) Exe
 }
 
 func newEnvironmentUpserter(ctx *common.Context, environmentName string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(environmentWorkflow)
 	envStackParams := make(map[string]string)
@@ -49,7 +62,7 @@ func newEnvironmentUpserter(ctx *common.Context, environmentName string) Executo
 				workflow.environmentUpserter(ctx.Config.Namespace, envStackParams, ctx.StackManager, ctx.StackManager, ctx.StackManager),
 				workflow.connectKubernetes(ctx.Config.Namespace, ctx.KubernetesResourceManagerProvider),
 				workflow.environmentKubernetesClusterUpserter(ctx.Config.Namespace, serviceName, ctx.Region, ctx.AccountID, ctx.Partition),
-				workflow.environmentKubernetesIngressUpserter(ctx.Config.Namespace, ctx.Region, ctx.AccountID, ctx.Partition),
+				workflow.environmentKubernetesIngressUpserter(ctx.Config.Namespace, ctx.Region, ctx.AccountID, ctx.Partition, ctx.StackManager),
 			),
 			newPipelineExecutor(
 				workflow.environmentElbUpserter(ctx.Config.Namespace, envStackParams, elbStackParams, ctx.StackManager, ctx.StackManager, ctx.StackManager),
@@ -66,41 +79,7 @@ func (workflow *environmentWorkflow) environmentFinder(config *common.Config, en
 		for _, e := range config.Environments {
 			if strings.EqualFold(e.Name, environmentName) {
 				workflow.environment = &e
-
-				workflow.rbacServices = make([]*subjectRoleBinding, 0)
-				workflow.rbacUsers = make([]*subjectRoleBinding, 0)
-				for _, binding := range config.RBAC {
-					if len(binding.Environments) > 0 {
-						found := false
-						for _, env := range binding.Environments {
-							if env == environmentName {
-								found = true
-								break
-							}
-						}
-
-						if !found {
-							log.Debugf("Skipping binding %v - unable to match env %v", binding, environmentName)
-							continue
-						}
-					}
-
-					for _, service := range binding.Services {
-						log.Debugf("Binding service %s to role %s", service, binding.Role)
-						workflow.rbacServices = append(workflow.rbacServices, &subjectRoleBinding{
-							Name: service,
-							Role: string(binding.Role),
-						})
-					}
-					for _, user := range binding.Users {
-						log.Debugf("Binding user %s to role %s", user, binding.Role)
-						workflow.rbacUsers = append(workflow.rbacUsers, &subjectRoleBinding{
-							Name: user,
-							Role: string(binding.Role),
-						})
-					}
-				}
-				return nil
+				return workflow.rbacBindingResolver(config.RBAC, environmentName)
 			}
 		}
 		return common.Warningf("Unable to find environment named '%s' in configuration", environmentName)
@@ -232,6 +211,7 @@ func (workflow *environmentWorkflow) environmentRolesetUpserter(rolesetUpserter
 		envStackParams["EC2InstanceProfileArn"] = environmentRoleset["EC2InstanceProfileArn"]
 		if workflow.environment.Provider == common.EnvProviderEks {
 			envStackParams["EksServiceRoleArn"] = environmentRoleset["EksServiceRoleArn"]
+			envStackParams["EC2RoleArn"] = environmentRoleset["EC2RoleArn"]
 		}
 		workflow.ec2RoleArn = environmentRoleset["EC2RoleArn"]
 
@@ -357,12 +337,21 @@ func (workflow *environmentWorkflow) environmentUpserter(namespace string, envSt
 		imageOwner = envMapping[environment.Provider]["imageOwner"]
 		common.NewMapElementIfNotEmpty(stackParams, "LaunchType", envMapping[environment.Provider]["launchType"])
 
+		if workflow.isKubernetesProvider()() {
+			if kubernetesVersion := workflow.eksVersionParams(envStackName, stackParams, stackWaiter); kubernetesVersion != "" {
+				imagePattern = fmt.Sprintf(eksVersionImagePattern, kubernetesVersion)
+			}
+		}
+
 		log.Noticef("Upserting environment '%s' ...", environment.Name)
 
 		// Default SshAllow if none defined
 		stackParams["SshAllow"] = "0.0.0.0/0"
 		common.NewMapElementIfNotEmpty(stackParams, "SshAllow", environment.Cluster.SSHAllow)
 		common.NewMapElementIfNotEmpty(stackParams, "InstanceType", environment.Cluster.InstanceType)
+		if environment.Provider != common.EnvProviderEks {
+			workflow.environmentCapacityParams(stackParams)
+		}
 		common.NewMapElementIfNotEmpty(stackParams, "ExtraUserData", environment.Cluster.ExtraUserData)
 		common.NewMapElementIfNotEmpty(stackParams, "ImageId", environment.Cluster.ImageID)
 
@@ -386,6 +375,15 @@ func (workflow *environmentWorkflow) environmentUpserter(namespace string, envSt
 
 		common.NewMapElementIfNotEmpty(stackParams, "HttpProxy", environment.Cluster.HTTPProxy)
 
+		if workflow.isKubernetesProvider()() {
+			// services read the ingress settings from the environment stack when they are deployed
+			common.NewMapElementIfNotEmpty(stackParams, "IngressController", string(environment.Loadbalancer.IngressController))
+			common.NewMapElementIfNotEmpty(stackParams, "IngressCertificate", environment.Loadbalancer.Certificate)
+		}
+		if environment.Provider == common.EnvProviderEks {
+			stackParams["SelfManagedNodes"] = strconv.FormatBool(len(environment.Cluster.NodeGroups) == 0)
+		}
+
 		tags := createTagMap(&EnvironmentTags{
 			Environment: environment.Name,
 			Type:        string(common.StackTypeEnv),
@@ -412,6 +410,72 @@ func (workflow *environmentWorkflow) environmentUpserter(namespace string, envSt
 	}
 }
 
+// environmentCapacityParams sets the instance types, spot capacity and managed scaling of the autoscaling groups
+func (workflow *environmentWorkflow) environmentCapacityParams(stackParams map[string]string) {
+	cluster := workflow.environment.Cluster
+
+	// the launch template starts with the first instance type, the autoscaling group overrides it with all of them
+	if cluster.InstanceType == "" && len(cluster.InstanceTypes) > 0 {
+		stackParams["InstanceType"] = cluster.InstanceTypes[0]
+	}
+	if workflow.environment.Provider == common.EnvProviderEc2 {
+		// services of ec2 environments read the instance types from the outputs of the environment stack
+		common.NewMapElementIfNotEmpty(stackParams, "InstanceTypes", strings.Join(cluster.InstanceTypes, ","))
+	}
+
+	if cluster.Spot != nil {
+		stackParams["OnDemandBaseCapacity"] = strconv.Itoa(cluster.Spot.OnDemandBase)
+		stackParams["OnDemandPercentageAboveBaseCapacity"] = strconv.Itoa(cluster.Spot.OnDemandPercent)
+		common.NewMapElementIfNotEmpty(stackParams, "SpotAllocationStrategy", string(cluster.Spot.AllocationStrategy))
+	}
+
+	if workflow.environment.Provider == common.EnvProviderEcs || workflow.environment.Provider == common.EnvProviderEcsFargate {
+		// always set, so that removing the capacity provider goes back to scaling on reservation
+		stackParams["ManagedScaling"] = strconv.FormatBool(cluster.CapacityProvider != nil)
+		// `mu env refresh-ami` turns off the rolling update to replace the instances itself
+		stackParams["RollingUpdate"] = "true"
+		if cluster.CapacityProvider != nil {
+			if cluster.TargetCPUReservation != 0 || cluster.TargetMemoryReservation != 0 {
+				log.Warningf("Environment '%s' has a capacity provider, 'targetCPUReservation' and 'targetMemoryReservation' are ignored", workflow.environment.Name)
+			}
+			common.NewMapElementIfNotZero(stackParams, "TargetCapacity", cluster.CapacityProvider.TargetCapacity)
+			common.NewMapElementIfNotZero(stackParams, "MinimumScalingStepSize", cluster.CapacityProvider.MinimumScalingStepSize)
+			common.NewMapElementIfNotZero(stackParams, "MaximumScalingStepSize", cluster.CapacityProvider.MaximumScalingStepSize)
+		}
+	}
+}
+
+// eksVersionParams sets the kubernetes versions of the cluster and node groups.  The versions of an existing cluster
+// are kept, since only `mu env upgrade` changes them.  Returns the version of the control plane, if known.
+func (workflow *environmentWorkflow) eksVersionParams(envStackName string, stackParams map[string]string, stackWaiter common.StackWaiter) string {
+	cluster := workflow.environment.Cluster
+
+	kubernetesVersion := cluster.KubernetesVersion
+	envStack := stackWaiter.AwaitFinalStatus(envStackName)
+	if envStack != nil && envStack.Status != cloudformation.StackStatusRollbackComplete {
+		kubernetesVersion = envStack.Parameters["KubernetesVersion"]
+		if cluster.KubernetesVersion != "" && cluster.KubernetesVersion != kubernetesVersion {
+			log.Warningf("Environment '%s' is running kubernetes '%s' rather than '%s', run `mu env upgrade %s` to upgrade it", workflow.environment.Name, kubernetesVersion, cluster.KubernetesVersion, workflow.environment.Name)
+		}
+	}
+	common.NewMapElementIfNotEmpty(stackParams, "KubernetesVersion", kubernetesVersion)
+
+	for _, nodeGroup := range cluster.NodeGroups {
+		versionParam := fmt.Sprintf("%sVersion", nodeGroup.ResourceName())
+		nodeGroupVersion, ok := "", false
+		if envStack != nil {
+			nodeGroupVersion, ok = envStack.Parameters[versionParam]
+		}
+		if !ok {
+			// new node groups start on the version of the control plane
+			nodeGroupVersion = kubernetesVersion
+		}
+		common.NewMapElementIfNotEmpty(stackParams, versionParam, nodeGroupVersion)
+	}
+
+	return kubernetesVersion
+}
+
 func (workflow *environmentWorkflow) environmentKubernetesBootstrapper(namespace string, envStackParams map[string]string, stackWaiter common.StackWaiter, stackUpserter common.StackUpserter) Executor {
 	return func() error {
 		envStackName := common.CreateStackName(namespace, common.StackTypeEnv, workflow.environment.Name)
@@ -426,6 +490,7 @@ func (workflow *environmentWorkflow) environmentKubernetesBootstrapper(namespace
 			stackParams["EksServiceRoleArn"] = envStackParams["EksServiceRoleArn"]
 			stackParams["Namespace"] = namespace
 			stackParams["EnvironmentName"] = workflow.environment.Name
+			common.NewMapElementIfNotEmpty(stackParams, "KubernetesVersion", workflow.environment.Cluster.KubernetesVersion)
 
 			tags := createTagMap(&EnvironmentTags{
 				Environment: workflow.environment.Name,
@@ -468,6 +533,8 @@ func (workflow *environmentWorkflow) environmentKubernetesClusterUpserter(namesp
 			"AWSPartition": partition,
 			"RBACServices": workflow.rbacServices,
 			"RBACUsers":    workflow.rbacUsers,
+			"RBACIAMRoles": workflow.rbacIAMRoles,
+			"RBACRoles":    workflow.rbacRoles,
 		}
 
 		clusterName := common.CreateStackName(namespace, common.StackTypeEnv, workflow.environment.Name)
@@ -477,7 +544,7 @@ func (workflow *environmentWorkflow) environmentKubernetesClusterUpserter(namesp
 	}
 }
 
-func (workflow *environmentWorkflow) environmentKubernetesIngressUpserter(namespace string, region string, accountID string, partition string) Executor {
+func (workflow *environmentWorkflow) environmentKubernetesIngressUpserter(namespace string, region string, accountID string, partition string, stackWaiter common.StackWaiter) Executor {
 	return func() error {
 
 		var elbCertArn string
@@ -493,8 +560,71 @@ func (workflow *environmentWorkflow) environmentKubernetesIngressUpserter(namesp
 		}
 
 		clusterName := common.CreateStackName(namespace, common.StackTypeEnv, workflow.environment.Name)
+
+		if workflow.environment.Loadbalancer.IngressController == common.IngressControllerAlb {
+			envStack := stackWaiter.AwaitFinalStatus(clusterName)
+			if envStack == nil {
+				return fmt.Errorf("Unable to find stack '%s' for environment '%s'", clusterName, workflow.environment.Name)
+			}
+
+			// the controller only needs the webhook server to start, no webhooks are registered with the cluster
+			webhookCert, webhookKey, err := selfSignedCertificate(fmt.Sprintf("aws-load-balancer-webhook-service.%s.svc", templateData["Namespace"]))
+			if err != nil {
+				return err
+			}
+
+			scheme := "internet-facing"
+			if workflow.environment.Loadbalancer.Internal {
+				scheme = "internal"
+			}
+
+			templateData["ClusterName"] = clusterName
+			templateData["IngressGroup"] = clusterName
+			templateData["AWSRegion"] = region
+			templateData["VpcId"] = envStack.Outputs["VpcId"]
+			templateData["Scheme"] = scheme
+			templateData["AccessLogs"] = workflow.environment.Loadbalancer.AccessLogs
+			templateData["WebhookCert"] = base64.StdEncoding.EncodeToString(webhookCert)
+			templateData["WebhookKey"] = base64.StdEncoding.EncodeToString(webhookKey)
+
+			log.Noticef("Upserting kubernetes ALB ingress controller in cluster '%s' ...", clusterName)
+			return workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sAlbIngress, templateData)
+		}
+
 		log.Noticef("Upserting kubernetes ingress in cluster '%s' ...", clusterName)
 
 		return workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sIngress, templateData)
 	}
 }
+
+// selfSignedCertificate creates a PEM encoded certificate and key for serving TLS inside the cluster
+func selfSignedCertificate(commonName string) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, nil
+}
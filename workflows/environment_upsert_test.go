@@ -50,6 +50,29 @@ func TestNewEnvironmentUpserter(t *testing.T) {
 	assert.NotNil(upserter)
 }
 
+func TestNewEnvironmentUpserter_Regions(t *testing.T) {
+	assert := assert.New(t)
+	ctx := common.NewContext()
+	ctx.Region = "us-east-1"
+	ctx.Config.Namespace = "mu"
+	ctx.Config.Environments = []common.Environment{
+		{Name: "dev"},
+		{Name: "prod", Region: "eu-west-1"},
+		{Name: "prod-dr", Region: "eu-west-1"},
+	}
+
+	regions := make([]string, 0)
+	ctx.SetContextInitializer(func(regionCtx *common.Context, region string, account common.EnvironmentAccount) error {
+		regions = append(regions, region)
+		regionCtx.Region = region
+		return nil
+	})
+
+	upserter := NewEnvironmentsUpserter(ctx, []string{"dev", "prod", "prod-dr"})
+	assert.NotNil(upserter)
+	assert.Equal([]string{"eu-west-1"}, regions)
+}
+
 type mockedStackManagerForUpsert struct {
 	mock.Mock
 	common.StackManager
@@ -103,6 +126,69 @@ func TestEnvironmentEcsUpserter(t *testing.T) {
 	assert.Equal("EC2", stackParams["LaunchType"])
 	assert.Equal("ami-00000", stackParams["ImageId"])
 	assert.NotContains(stackParams, "KeyName")
+	assert.Equal("false", stackParams["ManagedScaling"])
+}
+
+func TestEnvironmentEksFargateUpserter(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEksFargate,
+	}
+	workflow.environment.Loadbalancer.IngressController = common.IngressControllerAlb
+	workflow.environment.Loadbalancer.Certificate = "arn:aws:acm:us-west-2:123456789012:certificate/abc"
+	workflow.environment.Cluster.KubernetesVersion = "1.18"
+
+	stackManager := new(mockedStackManagerForUpsert)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(nil).Once()
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{Status: common.StackStatusCreateComplete})
+	stackManager.On("UpsertStack", "mu-environment-foo", mock.AnythingOfType("map[string]string")).Return(nil)
+	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
+
+	err := workflow.environmentUpserter("mu", make(map[string]string), stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	var stackParams map[string]string
+	for _, call := range stackManager.Calls {
+		if call.Method == "UpsertStack" {
+			stackParams = call.Arguments.Get(1).(map[string]string)
+		}
+	}
+	assert.Equal(string(common.IngressControllerAlb), stackParams["IngressController"])
+	assert.Equal("arn:aws:acm:us-west-2:123456789012:certificate/abc", stackParams["IngressCertificate"])
+	assert.Equal("1.18", stackParams["KubernetesVersion"])
+}
+
+func TestEnvironmentEcsUpserter_CapacityProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEcs,
+		Cluster: common.Cluster{
+			CapacityProvider: &common.ClusterCapacityProvider{
+				TargetCapacity: 90,
+			},
+		},
+	}
+
+	vpcInputParams := make(map[string]string)
+
+	stackManager := new(mockedStackManagerForUpsert)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{Status: common.StackStatusCreateComplete})
+	stackManager.On("UpsertStack", "mu-environment-foo", mock.AnythingOfType("map[string]string")).Return(nil)
+	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
+
+	err := workflow.environmentUpserter("mu", vpcInputParams, stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackParams := stackManager.Calls[1].Arguments.Get(1).(map[string]string)
+	assert.Equal("true", stackParams["ManagedScaling"])
+	assert.Equal("90", stackParams["TargetCapacity"])
+	assert.NotContains(stackParams, "MinimumScalingStepSize")
 }
 
 func TestEnvironmentEc2Upserter(t *testing.T) {
@@ -132,6 +218,51 @@ func TestEnvironmentEc2Upserter(t *testing.T) {
 	assert.NotContains(stackParams, "LaunchType")
 	assert.Equal("ami-00000", stackParams["ImageId"])
 	assert.NotContains(stackParams, "KeyName")
+	assert.NotContains(stackParams, "ManagedScaling")
+}
+
+func TestEnvironmentUpserter_Spot(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEc2,
+		Cluster: common.Cluster{
+			InstanceTypes: []string{"m5.large", "m5a.large"},
+			Spot: &common.ClusterSpot{
+				OnDemandBase:       1,
+				AllocationStrategy: common.SpotAllocationStrategyCapacityOptimized,
+			},
+		},
+	}
+
+	vpcInputParams := make(map[string]string)
+
+	stackManager := new(mockedStackManagerForUpsert)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{Status: common.StackStatusCreateComplete})
+	stackManager.On("UpsertStack", "mu-environment-foo", mock.AnythingOfType("map[string]string")).Return(nil)
+	stackManager.On("FindLatestImageID").Return("ami-00000", nil)
+
+	err := workflow.environmentUpserter("mu", vpcInputParams, stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackParams := stackManager.Calls[1].Arguments.Get(1).(map[string]string)
+	assert.Equal("m5.large", stackParams["InstanceType"])
+	assert.Equal("m5.large,m5a.large", stackParams["InstanceTypes"])
+	assert.Equal("1", stackParams["OnDemandBaseCapacity"])
+	assert.Equal("0", stackParams["OnDemandPercentageAboveBaseCapacity"])
+	assert.Equal("capacity-optimized", stackParams["SpotAllocationStrategy"])
+
+	// the overrides of ecs clusters are rendered from the environment, not passed as a parameter
+	workflow.environment.Provider = common.EnvProviderEcs
+	err = workflow.environmentUpserter("mu", make(map[string]string), stackManager, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackParams = stackManager.Calls[4].Arguments.Get(1).(map[string]string)
+	assert.Equal("m5.large", stackParams["InstanceType"])
+	assert.NotContains(stackParams, "InstanceTypes")
+	assert.Equal("1", stackParams["OnDemandBaseCapacity"])
 }
 
 func TestEnvironmentProviderConditionals(t *testing.T) {
@@ -273,3 +404,58 @@ func loadYamlConfig(yamlString string) (*common.Config, error) {
 
 	return config, nil
 }
+
+func TestEnvironmentKubernetesIngressUpserter_Alb(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEks,
+	}
+	workflow.environment.Loadbalancer.IngressController = common.IngressControllerAlb
+	workflow.environment.Loadbalancer.Certificate = "abc-123"
+	workflow.environment.Loadbalancer.Internal = true
+
+	stackManager := new(mockedStackManagerForUpsert)
+	stackManager.On("AwaitFinalStatus", "mu-environment-foo").Return(&common.Stack{
+		Status:  common.StackStatusCreateComplete,
+		Outputs: map[string]string{"VpcId": "vpc-123"},
+	})
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("UpsertResources", common.TemplateK8sAlbIngress, mock.Anything).Return(nil)
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	err := workflow.environmentKubernetesIngressUpserter("mu", "us-west-2", "1234", "aws", stackManager)()
+	assert.Nil(err)
+
+	kubernetesResourceManager.AssertExpectations(t)
+	templateData := kubernetesResourceManager.Calls[0].Arguments.Get(1).(map[string]interface{})
+	assert.Equal("mu-environment-foo", templateData["ClusterName"])
+	assert.Equal("vpc-123", templateData["VpcId"])
+	assert.Equal("internal", templateData["Scheme"])
+	assert.Equal("arn:aws:acm:us-west-2:1234:certificate/abc-123", templateData["ElbCertArn"])
+	assert.NotEmpty(templateData["WebhookCert"])
+}
+
+func TestEnvironmentKubernetesIngressUpserter_Nginx(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "foo",
+		Provider: common.EnvProviderEks,
+	}
+
+	stackManager := new(mockedStackManagerForUpsert)
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("UpsertResources", common.TemplateK8sIngress, mock.Anything).Return(nil)
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	err := workflow.environmentKubernetesIngressUpserter("mu", "us-west-2", "1234", "aws", stackManager)()
+	assert.Nil(err)
+
+	kubernetesResourceManager.AssertExpectations(t)
+	stackManager.AssertNotCalled(t, "AwaitFinalStatus", "mu-environment-foo")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/stelligent/mu/common"
 )
@@ -20,6 +21,7 @@ type instanceView struct {
 	taskCount    int64
 	cpuAvail     int64
 	memAvail     int64
+	managed      bool // nodes of EKS managed node groups, whose AMI isn't updated by `mu env up`
 }
 
 // ServiceView representation of service
@@ -41,18 +43,33 @@ type environmentView struct {
 	vpcStatus     string
 	bastionHost   string
 	baseURL       string
+	latestAMI     string
+	pinnedAMI     bool
+	k8sVersion    string
 	instances     []*instanceView
 	services      []*serviceView
 }
 
 // NewEnvironmentViewer create a new workflow for showing an environment
 func NewEnvironmentViewer(ctx *common.Context, format string, environmentName string, writer io.Writer) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(environmentWorkflow)
 	view := new(environmentView)
 	view.instances = make([]*instanceView, 0)
 	view.services = make([]*serviceView, 0)
 
+	// environments with `cluster.imageId` stay on that AMI, rather than the latest one
+	var pinnedImageID string
+	for _, environment := range ctx.Config.Environments {
+		if strings.EqualFold(environment.Name, environmentName) {
+			pinnedImageID = environment.Cluster.ImageID
+		}
+	}
+
 	var environmentViewer func() error
 	if format == JSON {
 		environmentViewer = workflow.environmentViewerJSON(view, writer)
@@ -81,6 +98,7 @@ func NewEnvironmentViewer(ctx *common.Context, format string, environmentName st
 			),
 			nil,
 		),
+		workflow.environmentImageLoader(ctx.StackManager, ctx.InstanceManager, pinnedImageID, view),
 		environmentViewer,
 	)
 }
@@ -107,6 +125,7 @@ func (workflow *environmentWorkflow) environmentLoader(namespace string, environ
 		view.provider = common.EnvProvider(clusterStack.Tags["provider"])
 		view.clusterName = clusterStackName
 		view.clusterStatus = clusterStack.Status
+		view.k8sVersion = clusterStack.Parameters["KubernetesVersion"]
 		view.vpcName = vpcStackName
 		if vpcStack != nil {
 			view.vpcStatus = vpcStack.Status
@@ -155,8 +174,13 @@ func (workflow *environmentWorkflow) environmentEksNodeLoader(instances *[]*inst
 					ip = common.MapGetString(address, "address")
 				}
 			}
+			// the provider id of a node is 'aws:///<az>/<instance id>'
+			instanceID := common.MapGetString(node.Object, "spec", "externalID")
+			if providerID := common.MapGetString(node.Object, "spec", "providerID"); providerID != "" {
+				instanceID = providerID[strings.LastIndex(providerID, "/")+1:]
+			}
 			*instances = append(*instances, &instanceView{
-				common.MapGetString(node.Object, "spec", "externalID"),
+				instanceID,
 				"",
 				"",
 				ip,
@@ -166,6 +190,7 @@ func (workflow *environmentWorkflow) environmentEksNodeLoader(instances *[]*inst
 				-1,
 				-1,
 				-1,
+				common.MapGetString(node.Object, "metadata", "labels", "eks.amazonaws.com/nodegroup") != "",
 			})
 		}
 
@@ -199,7 +224,7 @@ func (workflow *environmentWorkflow) environmentEcsInstanceLoader(namespace stri
 	return func() error {
 		clusterName := common.CreateStackName(namespace, common.StackTypeEnv, environmentName)
 		containerInstances, err := clusterInstanceLister.ListInstances(clusterName)
-		if err == nil {
+		if err != nil || len(containerInstances) == 0 {
 			return err
 		}
 
@@ -213,8 +238,10 @@ func (workflow *environmentWorkflow) environmentEcsInstanceLoader(namespace stri
 		}
 
 		instanceIps := make(map[string]string)
+		instanceImages := make(map[string]string)
 		for _, instance := range instances {
 			instanceIps[common.StringValue(instance.InstanceId)] = common.StringValue(instance.PrivateIpAddress)
+			instanceImages[common.StringValue(instance.InstanceId)] = common.StringValue(instance.ImageId)
 		}
 
 		for _, instance := range containerInstances {
@@ -231,6 +258,9 @@ func (workflow *environmentWorkflow) environmentEcsInstanceLoader(namespace stri
 					amiID = common.StringValue(attr.Value)
 				}
 			}
+			if imageID := instanceImages[common.StringValue(instance.Ec2InstanceId)]; imageID != "" {
+				amiID = imageID
+			}
 			var cpuAvail int64
 			var memAvail int64
 			for _, resource := range instance.RemainingResources {
@@ -252,11 +282,71 @@ func (workflow *environmentWorkflow) environmentEcsInstanceLoader(namespace stri
 				common.Int64Value(instance.RunningTasksCount),
 				cpuAvail,
 				memAvail,
+				false,
 			})
 		}
 		return nil
 	}
 }
+
+// environmentImageLoader finds the AMIs of the instances, and the AMI they should be on: the pinned AMI of the
+// environment, or else the latest AMI available for it
+func (workflow *environmentWorkflow) environmentImageLoader(imageFinder common.ImageFinder, instanceLister common.InstanceLister, pinnedImageID string, view *environmentView) Executor {
+	return func() error {
+		selfManaged := 0
+		for _, instance := range view.instances {
+			if !instance.managed {
+				selfManaged++
+			}
+		}
+		if selfManaged == 0 {
+			return nil
+		}
+
+		instanceIds := make([]string, 0)
+		for _, instance := range view.instances {
+			if instance.amiID == "" && instance.instanceID != "" {
+				instanceIds = append(instanceIds, instance.instanceID)
+			}
+		}
+		if len(instanceIds) > 0 {
+			instances, err := instanceLister.ListInstances(instanceIds...)
+			if err != nil {
+				log.Warningf("Unable to describe instances: %v", err)
+			}
+			for _, instance := range instances {
+				for _, node := range view.instances {
+					if node.instanceID == common.StringValue(instance.InstanceId) {
+						node.amiID = common.StringValue(instance.ImageId)
+						node.instanceType = common.StringValue(instance.InstanceType)
+					}
+				}
+			}
+		}
+
+		if pinnedImageID != "" {
+			view.latestAMI = pinnedImageID
+			view.pinnedAMI = true
+			return nil
+		}
+
+		imageOwner, imagePattern := ecsImageOwner, ecsImagePattern
+		if workflow.isKubernetesProvider()() {
+			imageOwner, imagePattern = eksImageOwner, eksImagePattern
+			if view.k8sVersion != "" {
+				imagePattern = fmt.Sprintf(eksVersionImagePattern, view.k8sVersion)
+			}
+		}
+		latestAMI, err := imageFinder.FindLatestImageID(imageOwner, imagePattern)
+		if err != nil {
+			log.Warningf("Unable to find the latest AMI: %v", err)
+			return nil
+		}
+		view.latestAMI = latestAMI
+		return nil
+	}
+}
+
 func (workflow *environmentWorkflow) environmentCFNServiceLoader(namespace string, environmentName string, serviceName string, stackLister common.StackLister, serviceViews *[]*serviceView) Executor {
 	return func() error {
 		stacks, err := stackLister.ListStacks(common.StackTypeService, namespace)
@@ -329,6 +419,7 @@ func (workflow *environmentWorkflow) environmentViewerCLI(view *environmentView,
 		if len(view.instances) > 0 {
 			fmt.Fprintf(writer, HeadNewlineHeader, Bold(ContainerInstances))
 			printInstanceTable(view.instances, writer)
+			printLatestAMI(view, writer)
 		}
 
 		fmt.Fprint(writer, NewLine)
@@ -376,3 +467,34 @@ func printInstanceTable(instances []*instanceView, writer io.Writer) {
 
 	table.Render()
 }
+
+func printLatestAMI(view *environmentView, writer io.Writer) {
+	if view.latestAMI == "" {
+		return
+	}
+	amiHeader := LatestAMIHeader
+	if view.pinnedAMI {
+		amiHeader = PinnedAMIHeader
+	}
+	fmt.Fprintf(writer, HeaderValueFormat, Bold(amiHeader), view.latestAMI)
+
+	outdated := 0
+	selfManaged := 0
+	for _, instance := range view.instances {
+		if instance.managed {
+			continue
+		}
+		selfManaged++
+		if instance.amiID != "" && instance.amiID != UnknownValue && instance.amiID != view.latestAMI {
+			outdated++
+		}
+	}
+	if outdated == 0 {
+		return
+	}
+	refreshCommand := fmt.Sprintf("mu env refresh-ami %s", view.name)
+	if view.provider != common.EnvProviderEcs {
+		refreshCommand = fmt.Sprintf("mu env up %s", view.name)
+	}
+	fmt.Fprintf(writer, OutdatedAMIFormat, outdated, selfManaged, view.latestAMI, refreshCommand)
+}
@@ -1,8 +1,11 @@
 package workflows
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
 )
@@ -13,3 +16,87 @@ func TestNewEnvironmentViewer(t *testing.T) {
 	viewer := NewEnvironmentViewer(ctx, "json", "foo", nil)
 	assert.NotNil(viewer)
 }
+
+func TestEnvironmentImageLoader(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "dev",
+		Provider: common.EnvProviderEks,
+	}
+
+	view := &environmentView{
+		name:       "dev",
+		provider:   common.EnvProviderEks,
+		k8sVersion: "1.15",
+		instances: []*instanceView{
+			{instanceID: "i-1"},
+			{instanceID: "i-2"},
+		},
+	}
+
+	instanceManager := new(mockedInstanceManager)
+	instanceManager.On("ListInstances", []string{"i-1", "i-2"}).Return([]common.Instance{
+		&ec2.Instance{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-old"), InstanceType: aws.String("m5.large")},
+		&ec2.Instance{InstanceId: aws.String("i-2"), ImageId: aws.String("ami-new"), InstanceType: aws.String("m5.large")},
+	}, nil)
+
+	stackManager := new(mockedStackManagerForUpgrade)
+	stackManager.On("FindLatestImageID", eksImageOwner, "amazon-eks-node-1.15-v*").Return("ami-new", nil)
+
+	err := workflow.environmentImageLoader(stackManager, instanceManager, "", view)()
+	assert.Nil(err)
+	assert.Equal("ami-new", view.latestAMI)
+	assert.Equal("ami-old", view.instances[0].amiID)
+	assert.Equal("m5.large", view.instances[0].instanceType)
+
+	buf := new(bytes.Buffer)
+	printLatestAMI(view, buf)
+	assert.Contains(buf.String(), "ami-new")
+	assert.Contains(buf.String(), "1 of 2 instances")
+	assert.Contains(buf.String(), "mu env up dev")
+}
+
+func TestEnvironmentImageLoader_Pinned(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(environmentWorkflow)
+	workflow.environment = &common.Environment{
+		Name:     "dev",
+		Provider: common.EnvProviderEks,
+	}
+
+	view := &environmentView{
+		name:     "dev",
+		provider: common.EnvProviderEks,
+		instances: []*instanceView{
+			{instanceID: "i-1", amiID: "ami-pinned"},
+			{instanceID: "i-2", amiID: "ami-other", managed: true},
+		},
+	}
+
+	stackManager := new(mockedStackManagerForUpgrade)
+
+	err := workflow.environmentImageLoader(stackManager, new(mockedInstanceManager), "ami-pinned", view)()
+	assert.Nil(err)
+	assert.Equal("ami-pinned", view.latestAMI)
+	stackManager.AssertNumberOfCalls(t, "FindLatestImageID", 0)
+
+	// managed node groups aren't on the AMI of the environment
+	buf := new(bytes.Buffer)
+	printLatestAMI(view, buf)
+	assert.Contains(buf.String(), "ami-pinned")
+	assert.NotContains(buf.String(), "mu env up dev")
+
+	// nor is the AMI of an environment with only managed node groups looked up
+	view = &environmentView{
+		name:      "dev",
+		provider:  common.EnvProviderEks,
+		instances: []*instanceView{{instanceID: "i-2", amiID: "ami-other", managed: true}},
+	}
+	err = workflow.environmentImageLoader(stackManager, new(mockedInstanceManager), "", view)()
+	assert.Nil(err)
+	assert.Equal("", view.latestAMI)
+	stackManager.AssertNumberOfCalls(t, "FindLatestImageID", 0)
+}
@@ -35,7 +35,7 @@ func NewPipelineUpserter(ctx *common.Context, tokenProvider func(bool) string) E
 					workflow.pipelineBucket(ctx.Config.Namespace, stackParams, ctx.StackManager, ctx.StackManager),
 					workflow.codedeployBucket(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
 				),
-				workflow.pipelineRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, stackParams),
+				workflow.pipelineRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, environmentRolesetUpserter(ctx), stackParams),
 				workflow.pipelineUpserter(ctx.Config.Namespace, ctx.StackManager, ctx.StackManager, stackParams),
 			),
 		),
@@ -139,7 +139,19 @@ func (workflow *pipelineWorkflow) pipelineToken(namespace string, tokenProvider
 	}
 }
 
-func (workflow *pipelineWorkflow) pipelineRolesetUpserter(rolesetUpserter common.RolesetUpserter, rolesetGetter common.RolesetGetter, params map[string]string) Executor {
+// environmentRolesetUpserter returns the roleset upserter for the region of an environment
+func environmentRolesetUpserter(ctx *common.Context) func(environmentName string) (common.RolesetUpserter, error) {
+	return func(environmentName string) (common.RolesetUpserter, error) {
+		envCtx, err := ctx.ForEnvironment(environmentName)
+		if err != nil {
+			return nil, err
+		}
+		return envCtx.RolesetManager, nil
+	}
+}
+
+func (workflow *pipelineWorkflow) pipelineRolesetUpserter(rolesetUpserter common.RolesetUpserter, rolesetGetter common.RolesetGetter,
+	envRolesetUpserter func(environmentName string) (common.RolesetUpserter, error), params map[string]string) Executor {
 	return func() error {
 		environments := make([]string, 0)
 
@@ -161,17 +173,28 @@ func (workflow *pipelineWorkflow) pipelineRolesetUpserter(rolesetUpserter common
 			}
 		}
 
+		commonExecutors := []Executor{rolesetUpserter.UpsertCommonRoleset}
+		regionUpserters := map[common.RolesetUpserter]bool{rolesetUpserter: true}
 		rolesetExecutors := make([]Executor, 0)
 
-		// add executors for environment and service rolesets
+		// add executors for environment and service rolesets, in the region of each environment
 		for i := range environments {
 			envName := environments[i]
+			envUpserter, err := envRolesetUpserter(envName)
+			if err != nil {
+				return err
+			}
+			if !regionUpserters[envUpserter] {
+				regionUpserters[envUpserter] = true
+				commonExecutors = append(commonExecutors, envUpserter.UpsertCommonRoleset)
+			}
+
 			rolesetExecutors = append(rolesetExecutors, func() error {
-				return rolesetUpserter.UpsertEnvironmentRoleset(envName)
+				return envUpserter.UpsertEnvironmentRoleset(envName)
 			})
 
 			rolesetExecutors = append(rolesetExecutors, func() error {
-				return rolesetUpserter.UpsertServiceRoleset(envName, workflow.serviceName, workflow.codeDeployBucket, workflow.databaseName)
+				return envUpserter.UpsertServiceRoleset(envName, workflow.serviceName, workflow.codeDeployBucket, workflow.databaseName)
 			})
 		}
 
@@ -196,7 +219,7 @@ func (workflow *pipelineWorkflow) pipelineRolesetUpserter(rolesetUpserter common
 		})
 
 		executor := newPipelineExecutor(
-			rolesetUpserter.UpsertCommonRoleset,
+			newParallelExecutor(commonExecutors...),
 			newParallelExecutor(rolesetExecutors...),
 		)
 
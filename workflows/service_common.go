@@ -2,8 +2,10 @@ package workflows
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/api/types"
@@ -20,6 +22,10 @@ type serviceWorkflow struct {
 	serviceImage                  string
 	registryAuth                  string
 	registryAuthConfig            map[string]types.AuthConfig
+	buildOptions                  common.DockerBuildOptions
+	promotedImage                 string
+	serviceTaggedImage            string
+	promotedRevisionKey           string
 	priority                      int
 	codeRevision                  string
 	repoName                      string
@@ -30,6 +36,9 @@ type serviceWorkflow struct {
 	cloudFormationRoleArn         string
 	microserviceTaskDefinitionArn string
 	ecsEventsRoleArn              string
+	ec2EventsRoleArn              string
+	eksPodRoleArn                 string
+	pullAccountIDs                []string
 	kubernetesResourceManager     common.KubernetesResourceManager
 }
 
@@ -138,6 +147,10 @@ func (workflow *serviceWorkflow) serviceRepoUpserter(namespace string, service *
 		if service.ImageRepository != "" {
 			log.Noticef("Using repo '%s' for service '%s'", service.ImageRepository, workflow.serviceName)
 			workflow.serviceImage = service.ImageRepository
+			if common.IsPrivateRegistry(service.Registry) && !hasImageTag(service.ImageRepository) {
+				// images pushed to a private registry are tagged like the ones in ECR
+				workflow.serviceImage = fmt.Sprintf("%s:%s", service.ImageRepository, workflow.serviceTag)
+			}
 			return nil
 		}
 
@@ -147,6 +160,7 @@ func (workflow *serviceWorkflow) serviceRepoUpserter(namespace string, service *
 
 		stackParams := make(map[string]string)
 		stackParams["RepoName"] = fmt.Sprintf("%s-%s", namespace, workflow.serviceName)
+		common.NewMapElementIfNotEmpty(stackParams, "PullAccountIds", strings.Join(workflow.pullAccountIDs, ","))
 
 		tags := createTagMap(&ServiceTags{
 			Service:  workflow.serviceName,
@@ -173,6 +187,119 @@ func (workflow *serviceWorkflow) serviceRepoUpserter(namespace string, service *
 		return nil
 	}
 }
+
+// servicePullAccountsResolver finds the accounts, other than the one of ctx, that have environments which
+// pull the image of the service from the repo
+func (workflow *serviceWorkflow) servicePullAccountsResolver(ctx *common.Context) Executor {
+	return func() error {
+		environmentNames := make([]string, 0)
+		for _, environment := range ctx.Config.Environments {
+			environmentNames = append(environmentNames, environment.Name)
+		}
+		for environmentName := range ctx.Config.Service.EnvironmentConfig {
+			environmentNames = append(environmentNames, environmentName)
+		}
+
+		accountIDs := map[string]bool{ctx.AccountID: true}
+		workflow.pullAccountIDs = make([]string, 0)
+		for _, environmentName := range environmentNames {
+			if ctx.Config.GetEnvironmentAccount(environmentName) == (common.EnvironmentAccount{}) {
+				continue
+			}
+			if ctx.AssumeRole != common.Empty {
+				// every environment is in the account of the assumed role, so the accounts that pull from the repo are unknown
+				return fmt.Errorf("Unable to find the account of environment '%s' with --assume-role '%s', run without it to update the accounts that pull from the repo", environmentName, ctx.AssumeRole)
+			}
+			envCtx, err := ctx.ForEnvironment(environmentName)
+			if err != nil {
+				return err
+			}
+			if !accountIDs[envCtx.AccountID] {
+				accountIDs[envCtx.AccountID] = true
+				workflow.pullAccountIDs = append(workflow.pullAccountIDs, envCtx.AccountID)
+			}
+		}
+		sort.Strings(workflow.pullAccountIDs)
+		return nil
+	}
+}
+
+// hasImageTag returns true if an image reference ends with a tag or a digest
+func hasImageTag(image string) bool {
+	name := image[strings.LastIndex(image, "/")+1:]
+	return strings.ContainsAny(name, ":@")
+}
+
+// sidecarImage returns the image for a sidecar.  Sidecars with a dockerfile are
+// built by `mu svc push` and tagged alongside the service image in its repo.
+func (workflow *serviceWorkflow) sidecarImage(sidecar common.Sidecar) string {
+	if sidecar.Dockerfile != "" {
+		if workflow.serviceTaggedImage != "" {
+			// sidecars are tagged after the service image, not its digest
+			return fmt.Sprintf("%s-%s", workflow.serviceTaggedImage, sidecar.Name)
+		}
+		return fmt.Sprintf("%s-%s", workflow.serviceImage, sidecar.Name)
+	}
+	return sidecar.Image
+}
+
+// resolveServiceSidecars fills in the image and default memory for each sidecar
+func (workflow *serviceWorkflow) resolveServiceSidecars(service *common.Service) {
+	for idx := range service.Sidecars {
+		sidecar := &service.Sidecars[idx]
+		sidecar.Image = workflow.sidecarImage(*sidecar)
+		if sidecar.Memory == 0 {
+			sidecar.Memory = defaultSidecarMemory
+		}
+	}
+}
+
+// resolveServiceAutoscaling fills in the defaults for step scaling policies and
+// scheduled scaling actions
+func resolveServiceAutoscaling(autoscaling *common.ServiceAutoscaling) error {
+	for idx := range autoscaling.StepScaling {
+		step := &autoscaling.StepScaling[idx]
+		if step.Namespace == "" || step.MetricName == "" {
+			return fmt.Errorf("Step scaling policy %d requires a namespace and metricName", idx)
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step-%d", idx)
+		}
+		if step.Statistic == "" {
+			step.Statistic = "Average"
+		}
+		if step.Period == 0 {
+			step.Period = 60
+		}
+		if step.EvaluationPeriods == 0 {
+			step.EvaluationPeriods = 1
+		}
+		if step.ComparisonOperator == "" {
+			step.ComparisonOperator = "GreaterThanOrEqualToThreshold"
+		}
+		if step.Adjustment == 0 {
+			// alarms on a lower bound scale in, like a queue that drains
+			step.Adjustment = 1
+			if strings.HasPrefix(step.ComparisonOperator, "LessThan") {
+				step.Adjustment = -1
+			}
+		}
+		if step.Cooldown == 0 {
+			step.Cooldown = 300
+		}
+	}
+	for idx := range autoscaling.Schedules {
+		schedule := &autoscaling.Schedules[idx]
+		if schedule.Expression == "" {
+			return fmt.Errorf("Scheduled scaling action %d requires an expression", idx)
+		}
+		if schedule.Name == "" {
+			schedule.Name = fmt.Sprintf("schedule-%d", idx)
+		}
+	}
+	return nil
+}
+
 func (workflow *serviceWorkflow) serviceAppUpserter(namespace string, service *common.Service, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) Executor {
 	return func() error {
 		log.Noticef("Upsert app for service '%s'", workflow.serviceName)
@@ -258,20 +385,33 @@ func (workflow *serviceWorkflow) serviceRegistryAuthenticator(authenticator comm
 			return err
 		}
 
-		authParts := strings.Split(string(data), ":")
-
-		workflow.registryAuth = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("{\"username\":\"%s\", \"password\":\"%s\"}", authParts[0], authParts[1])))
-
-		// ImageBuild pull auth
-		var authConfigs2 = make(map[string]types.AuthConfig)
-		var serviceImagePart = strings.Split(workflow.serviceImage, ":")[0]
+		// passwords may contain colons, the username can't
+		authParts := strings.SplitN(string(data), ":", 2)
+		if len(authParts) != 2 {
+			return fmt.Errorf("Unable to parse the credentials of registry for '%s'", workflow.serviceImage)
+		}
 
-		authConfigs2[serviceImagePart] = types.AuthConfig{
+		registryHost := common.RegistryHost(workflow.serviceImage)
+		serverAddress := registryHost
+		if !strings.Contains(serverAddress, "://") {
+			serverAddress = fmt.Sprintf("https://%s", registryHost)
+		}
+		authConfig := types.AuthConfig{
 			Username:      authParts[0],
 			Password:      authParts[1],
-			ServerAddress: fmt.Sprintf("https://%s", serviceImagePart),
+			ServerAddress: serverAddress,
 		}
 
+		authJSON, err := json.Marshal(authConfig)
+		if err != nil {
+			return err
+		}
+		workflow.registryAuth = base64.StdEncoding.EncodeToString(authJSON)
+
+		// ImageBuild pull auth
+		var authConfigs2 = make(map[string]types.AuthConfig)
+		authConfigs2[registryHost] = authConfig
+
 		workflow.registryAuthConfig = authConfigs2
 
 		return nil
@@ -2,8 +2,10 @@ package workflows
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"testing"
 
+	"github.com/docker/docker/api/types"
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -57,9 +59,10 @@ func TestServiceRegistryAuthenticator(t *testing.T) {
 	assert := assert.New(t)
 
 	authn := new(mockedRepositoryAuthenticator)
-	authn.On("AuthenticateRepository").Return(base64.StdEncoding.EncodeToString([]byte("user:pass")), nil)
+	authn.On("AuthenticateRepository").Return(base64.StdEncoding.EncodeToString([]byte("user:pa:ss\"word\\")), nil)
 
 	workflow := new(serviceWorkflow)
+	workflow.serviceImage = "harbor:8443/team/app:1.0.0"
 	err := workflow.serviceRegistryAuthenticator(authn)()
 
 	assert.Nil(err)
@@ -67,12 +70,29 @@ func TestServiceRegistryAuthenticator(t *testing.T) {
 
 	authJSON, err := base64.StdEncoding.DecodeString(workflow.registryAuth)
 	assert.Nil(err)
-	assert.Equal("{\"username\":\"user\", \"password\":\"pass\"}", string(authJSON))
+	authConfig := types.AuthConfig{}
+	assert.Nil(json.Unmarshal(authJSON, &authConfig))
+	assert.Equal("user", authConfig.Username)
+	assert.Equal("pa:ss\"word\\", authConfig.Password)
+	assert.Equal("https://harbor:8443", authConfig.ServerAddress)
+	assert.Equal(authConfig, workflow.registryAuthConfig["harbor:8443"])
 
 	authn.AssertExpectations(t)
 	authn.AssertNumberOfCalls(t, "AuthenticateRepository", 1)
 }
 
+func TestServiceRegistryAuthenticator_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	authn := new(mockedRepositoryAuthenticator)
+	authn.On("AuthenticateRepository").Return(base64.StdEncoding.EncodeToString([]byte("token")), nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceImage = "harbor:8443/team/app:1.0.0"
+	err := workflow.serviceRegistryAuthenticator(authn)()
+	assert.NotNil(err)
+}
+
 type mockedRolesetManagerForService struct {
 	mock.Mock
 	common.RolesetManager
@@ -148,6 +168,98 @@ func TestServiceRepoUpserter(t *testing.T) {
 	stackManager.AssertNumberOfCalls(t, "UpsertStack", 1)
 }
 
+func TestServiceRepoUpserter_PrivateRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := new(common.Service)
+	svc.ImageRepository = "ghcr.io/org/foo"
+	svc.Registry.Credentials = common.RegistryCredentialsEnv
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.serviceTag = "abc123"
+
+	stackManager := new(mockedStackManagerForUpsert)
+
+	err := workflow.serviceRepoUpserter("mu", svc, stackManager, stackManager)()
+	assert.Nil(err)
+	assert.Equal("ghcr.io/org/foo:abc123", workflow.serviceImage)
+
+	stackManager.AssertNotCalled(t, "UpsertStack", mock.Anything, mock.Anything)
+}
+
+func TestServiceRepoUpserter_PullAccounts(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := new(common.Service)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.pullAccountIDs = []string{"222222222222", "333333333333"}
+
+	stackManager := new(mockedStackManagerForUpsert)
+	stackManager.On("AwaitFinalStatus", "mu-repo-foo").Return(&common.Stack{Status: common.StackStatusCreateComplete})
+	stackManager.On("UpsertStack", "mu-repo-foo", mock.AnythingOfType("map[string]string")).Return(nil)
+
+	err := workflow.serviceRepoUpserter("mu", svc, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackParams := stackManager.Calls[0].Arguments.Get(1).(map[string]string)
+	assert.Equal("222222222222,333333333333", stackParams["PullAccountIds"])
+}
+
+func TestServicePullAccountsResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := common.NewContext()
+	ctx.Region = "us-east-1"
+	ctx.AccountID = "111111111111"
+	ctx.Config.Environments = []common.Environment{
+		{Name: "dev"},
+		{Name: "prod", Account: common.EnvironmentAccount{RoleArn: "arn:aws:iam::333333333333:role/mu"}},
+		{Name: "prod-eu", Region: "eu-west-1", Account: common.EnvironmentAccount{RoleArn: "arn:aws:iam::333333333333:role/mu"}},
+	}
+	ctx.Config.Service.EnvironmentConfig = map[string]common.ServiceEnvironmentConfig{
+		"shared": {Account: common.EnvironmentAccount{Profile: "shared"}},
+	}
+	accountIDs := map[string]string{
+		"arn:aws:iam::333333333333:role/mu": "333333333333",
+		"":                                  "222222222222",
+	}
+	ctx.SetContextInitializer(func(accountCtx *common.Context, region string, account common.EnvironmentAccount) error {
+		accountCtx.Region = region
+		accountCtx.AccountID = accountIDs[account.RoleArn]
+		return nil
+	})
+
+	workflow := new(serviceWorkflow)
+	err := workflow.servicePullAccountsResolver(ctx)()
+	assert.Nil(err)
+	assert.Equal([]string{"222222222222", "333333333333"}, workflow.pullAccountIDs)
+}
+
+func TestServicePullAccountsResolver_AssumeRole(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := common.NewContext()
+	ctx.AccountID = "111111111111"
+	ctx.AssumeRole = "arn:aws:iam::111111111111:role/mu"
+	ctx.Config.Environments = []common.Environment{
+		{Name: "dev"},
+		{Name: "prod", Account: common.EnvironmentAccount{RoleArn: "arn:aws:iam::333333333333:role/mu"}},
+	}
+
+	workflow := new(serviceWorkflow)
+	err := workflow.servicePullAccountsResolver(ctx)()
+	assert.NotNil(err)
+
+	// without environments in other accounts there's nothing to find
+	ctx.Config.Environments = ctx.Config.Environments[:1]
+	err = workflow.servicePullAccountsResolver(ctx)()
+	assert.Nil(err)
+	assert.Empty(workflow.pullAccountIDs)
+}
+
 func TestCodeDeploy_BucketUpserter(t *testing.T) {
 	assert := assert.New(t)
 
@@ -176,3 +288,37 @@ func TestCodeDeploy_BucketUpserter(t *testing.T) {
 
 	assert.Equal("foo-bucket", workflow.appRevisionBucket)
 }
+
+func TestResolveServiceAutoscaling(t *testing.T) {
+	assert := assert.New(t)
+
+	autoscaling := &common.ServiceAutoscaling{
+		StepScaling: []common.StepScalingPolicy{
+			{Namespace: "AWS/SQS", MetricName: "ApproximateNumberOfMessagesVisible", Threshold: 100},
+		},
+		Schedules: []common.ScheduledScalingAction{
+			{Expression: "cron(0 22 * * ? *)"},
+		},
+	}
+	err := resolveServiceAutoscaling(autoscaling)
+	assert.Nil(err)
+	assert.Equal("step-0", autoscaling.StepScaling[0].Name)
+	assert.Equal("Average", autoscaling.StepScaling[0].Statistic)
+	assert.Equal("GreaterThanOrEqualToThreshold", autoscaling.StepScaling[0].ComparisonOperator)
+	assert.Equal(1, autoscaling.StepScaling[0].Adjustment)
+	assert.Equal("schedule-0", autoscaling.Schedules[0].Name)
+
+	autoscaling = &common.ServiceAutoscaling{
+		StepScaling: []common.StepScalingPolicy{
+			{Namespace: "AWS/SQS", MetricName: "ApproximateNumberOfMessagesVisible", Threshold: 10, ComparisonOperator: "LessThanThreshold"},
+		},
+	}
+	err = resolveServiceAutoscaling(autoscaling)
+	assert.Nil(err)
+	assert.Equal(-1, autoscaling.StepScaling[0].Adjustment)
+
+	err = resolveServiceAutoscaling(&common.ServiceAutoscaling{
+		StepScaling: []common.StepScalingPolicy{{MetricName: "foo"}},
+	})
+	assert.NotNil(err)
+}
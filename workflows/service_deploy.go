@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
 
@@ -17,37 +19,66 @@ func NewServiceDeployer(ctx *common.Context, environmentName string, tag string)
 	workflow.codeRevision = ctx.Config.Repo.Revision
 	workflow.repoName = ctx.Config.Repo.Slug
 
+	return workflow.serviceDeployPipeline(ctx, environmentName, tag)
+}
+
+// serviceDeployPipeline deploys the service to an environment, from the artifacts of
+// the tag or the promoted artifacts if the workflow has any
+func (workflow *serviceWorkflow) serviceDeployPipeline(ctx *common.Context, environmentName string, tag string) Executor {
 	stackParams := make(map[string]string)
 
+	// the repo and artifacts of the service stay in the region of ctx, the service is deployed in the region of the environment
+	envCtx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	registryAuthenticator, err := common.NewRegistryAuthenticator(ctx.Config.Service.Registry, ctx.ClusterManager, ctx.ParamManager)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
 	return newPipelineExecutor(
 		workflow.serviceLoader(ctx, tag, ""),
-		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, ctx.StackManager),
-		workflow.serviceApplyCommonParams(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName, ctx.StackManager, ctx.ElbManager, ctx.ParamManager),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, envCtx.StackManager),
+		workflow.serviceApplyCommonParams(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName, envCtx.StackManager, envCtx.ElbManager, envCtx.ParamManager),
 		newConditionalExecutor(workflow.isEcsProvider(),
 			newPipelineExecutor(
-				workflow.serviceRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, environmentName),
+				workflow.serviceRolesetUpserter(envCtx.RolesetManager, envCtx.RolesetManager, environmentName),
+				workflow.servicePullAccountsResolver(ctx),
 				workflow.serviceRepoUpserter(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
-				workflow.serviceApplyEcsParams(&ctx.Config.Service, stackParams, ctx.RolesetManager),
-				workflow.serviceEcsDeployer(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName, ctx.StackManager, ctx.StackManager),
-				workflow.serviceCreateSchedules(ctx.Config.Namespace, &ctx.Config.Service, environmentName, ctx.StackManager, ctx.StackManager),
+				workflow.servicePromotedArtifactApplier(),
+				workflow.serviceImageDigestResolver(&ctx.Config.Service, ctx.ClusterManager),
+				workflow.serviceApplyEcsParams(&ctx.Config.Service, stackParams, envCtx.RolesetManager),
+				workflow.serviceEcsDeployer(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName, envCtx.StackManager, envCtx.StackManager),
+				workflow.serviceCreateSchedules(ctx.Config.Namespace, &ctx.Config.Service, environmentName, envCtx.StackManager, envCtx.StackManager),
+				workflow.serviceScheduleTerminator(ctx.Config.Namespace, ctx.Config.Service.Schedule, environmentName, envCtx.StackManager, envCtx.StackManager, envCtx.StackManager),
 			), nil),
 		newConditionalExecutor(workflow.isEc2Provider(),
 			newPipelineExecutor(
 				workflow.serviceBucketUpserter(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
-				workflow.serviceRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, environmentName),
-				workflow.serviceAppUpserter(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
-				workflow.serviceApplyEc2Params(stackParams, ctx.RolesetManager),
-				workflow.serviceEc2Deployer(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName, ctx.StackManager, ctx.StackManager),
-				// TODO - placeholder for doing serviceCreateSchedules for EC2, leaving out-of-scope per @cplee
+				workflow.serviceRevisionKeyResolver(ctx.ArtifactManager),
+				workflow.servicePromotedArtifactApplier(),
+				workflow.serviceRolesetUpserter(envCtx.RolesetManager, envCtx.RolesetManager, environmentName),
+				workflow.serviceAppUpserter(ctx.Config.Namespace, &ctx.Config.Service, envCtx.StackManager, envCtx.StackManager),
+				workflow.serviceApplyEc2Params(stackParams, envCtx.RolesetManager),
+				workflow.serviceEc2Deployer(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName, envCtx.StackManager, envCtx.StackManager),
+				workflow.serviceCreateSchedules(ctx.Config.Namespace, &ctx.Config.Service, environmentName, envCtx.StackManager, envCtx.StackManager),
+				workflow.serviceScheduleTerminator(ctx.Config.Namespace, ctx.Config.Service.Schedule, environmentName, envCtx.StackManager, envCtx.StackManager, envCtx.StackManager),
 			), nil),
 		newConditionalExecutor(workflow.isEksProvider(),
 			newPipelineExecutor(
-				workflow.serviceRolesetUpserter(ctx.RolesetManager, ctx.RolesetManager, environmentName),
+				workflow.serviceRolesetUpserter(envCtx.RolesetManager, envCtx.RolesetManager, environmentName),
+				workflow.servicePullAccountsResolver(ctx),
 				workflow.serviceRepoUpserter(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
-				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.servicePromotedArtifactApplier(),
+				workflow.serviceImageDigestResolver(&ctx.Config.Service, ctx.ClusterManager),
+				workflow.connectKubernetes(envCtx.KubernetesResourceManagerProvider),
 				workflow.serviceEksDBSecret(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName),
+				workflow.serviceEksSecrets(&ctx.Config.Service, stackParams, envCtx.ParamManager, environmentName),
+				workflow.serviceEksRegistrySecret(&ctx.Config.Service, registryAuthenticator, environmentName),
 				workflow.serviceEksDeployer(ctx.Config.Namespace, &ctx.Config.Service, stackParams, environmentName),
-				// TODO - placeholder for doing serviceCreateSchedules for EKS, leaving out-of-scope
+				workflow.serviceEksCreateSchedules(&ctx.Config.Service, environmentName),
 			), nil),
 	)
 }
@@ -134,6 +165,8 @@ func (workflow *serviceWorkflow) serviceRolesetUpserter(rolesetUpserter common.R
 			return err
 		}
 		workflow.ecsEventsRoleArn = serviceRoleset["EcsEventsRoleArn"]
+		workflow.ec2EventsRoleArn = serviceRoleset["Ec2EventsRoleArn"]
+		workflow.eksPodRoleArn = serviceRoleset["EksPodRoleArn"]
 
 		return nil
 	}
@@ -207,6 +240,64 @@ func getMaxUnavilableAndSurgePercentForKubernetesStrategy(
 	return maxUnavailable, maxSurge
 }
 
+// serviceRevisionKeyResolver finds the content addressed revision key that `svc push` uploaded for the tag
+func (workflow *serviceWorkflow) serviceRevisionKeyResolver(artifactGetter common.ArtifactGetter) Executor {
+	return func() error {
+		if workflow.promotedRevisionKey != "" {
+			return nil
+		}
+
+		pointerURL := fmt.Sprintf("s3://%s/%s", workflow.appRevisionBucket, revisionPointerKey(workflow.serviceName, workflow.serviceTag))
+		body, _, err := artifactGetter.GetArtifact(pointerURL, "")
+		if err != nil {
+			// archives pushed by older versions of mu are keyed by tag
+			log.Debugf("Unable to read revision of tag '%s' from '%s', using '%s': %v", workflow.serviceTag, pointerURL, workflow.appRevisionKey, err)
+			return nil
+		}
+		defer body.Close()
+
+		revisionKey, err := ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		workflow.appRevisionKey = strings.TrimSpace(string(revisionKey))
+		log.Debugf("Resolved tag '%s' to revision '%s'", workflow.serviceTag, workflow.appRevisionKey)
+		return nil
+	}
+}
+
+// serviceImageDigestResolver pins the service image to its digest, so tasks and pods keep
+// running the same image even if the tag is pushed again
+func (workflow *serviceWorkflow) serviceImageDigestResolver(service *common.Service, imageResolver common.RepositoryImageResolver) Executor {
+	return func() error {
+		if strings.Contains(workflow.serviceImage, "@") {
+			// already pinned, e.g. by `mu svc promote`
+			return nil
+		}
+		if common.IsPrivateRegistry(service.Registry) || !strings.Contains(workflow.serviceImage, ".dkr.ecr.") {
+			log.Debugf("Image '%s' isn't in ECR, deploying it by tag", workflow.serviceImage)
+			return nil
+		}
+
+		image, err := imageResolver.ResolveImageDigest(workflow.serviceImage)
+		if err != nil {
+			return fmt.Errorf("Unable to resolve the digest of image '%s': %v", workflow.serviceImage, err)
+		}
+		log.Debugf("Resolved image '%s' to '%s'", workflow.serviceImage, image)
+		workflow.serviceTaggedImage = workflow.serviceImage
+		workflow.serviceImage = image
+		return nil
+	}
+}
+
+// serviceImageTag returns the human-readable image of the service, before it was pinned to a digest
+func (workflow *serviceWorkflow) serviceImageTag() string {
+	if workflow.serviceTaggedImage != "" {
+		return workflow.serviceTaggedImage
+	}
+	return workflow.serviceImage
+}
+
 func (workflow *serviceWorkflow) serviceApplyEcsParams(service *common.Service, params map[string]string, rolesetGetter common.RolesetGetter) Executor {
 	return func() error {
 
@@ -218,19 +309,44 @@ func (workflow *serviceWorkflow) serviceApplyEcsParams(service *common.Service,
 		params["ServiceDiscoveryId"] = fmt.Sprintf("%s-ServiceDiscoveryId", workflow.lbStack.Name)
 		params["ServiceDiscoveryName"] = fmt.Sprintf("%s-ServiceDiscoveryName", workflow.lbStack.Name)
 		common.NewMapElementIfNotEmpty(params, "ServiceDiscoveryTTL", service.DiscoveryTTL)
+		if workflow.lbStack.Outputs["ElbFullName"] != "" {
+			params["ElbFullName"] = fmt.Sprintf("%s-ElbFullName", workflow.lbStack.Name)
+		}
 
 		params["ImageUrl"] = workflow.serviceImage
+		params["ImageTag"] = workflow.serviceImageTag()
+
+		err := resolveServiceAutoscaling(&service.Autoscaling)
+		if err != nil {
+			return err
+		}
+		if service.Autoscaling.TargetRequestCount != 0 && params["ElbFullName"] == "" {
+			log.Warningf("Scaling on request count requires an updated load balancer, run 'mu env up' to enable it")
+		}
+
+		// sidecars share the task, so size the task for every container in it
+		workflow.resolveServiceSidecars(service)
+		taskCPU := service.CPU
+		taskMemory := service.Memory
+		for _, sidecar := range service.Sidecars {
+			taskCPU += sidecar.CPU
+			taskMemory += sidecar.Memory
+		}
 
 		cpu := common.CPUMemorySupport[0]
 		if service.CPU != 0 {
 			params["ServiceCpu"] = strconv.Itoa(service.CPU)
-			cpu = matchRequestedCPU(service.CPU, cpu)
+		}
+		if taskCPU != 0 {
+			cpu = matchRequestedCPU(taskCPU, cpu)
 		}
 
 		memory := cpu.Memory[0]
 		if service.Memory != 0 {
 			params["ServiceMemory"] = strconv.Itoa(service.Memory)
-			memory = matchRequestedMemory(service.Memory, cpu, memory)
+		}
+		if taskMemory != 0 {
+			memory = matchRequestedMemory(taskMemory, cpu, memory)
 		}
 
 		if workflow.isFargateProvider()() {
@@ -242,8 +358,18 @@ func (workflow *serviceWorkflow) serviceApplyEcsParams(service *common.Service,
 			params["Links"] = strings.Join(service.Links, ",")
 		}
 
+		for key, value := range service.Environment {
+			if strings.Contains(fmt.Sprint(value), "${DatabaseMasterPassword}") {
+				return fmt.Errorf("Environment variable '%s' references ${DatabaseMasterPassword}, which is no longer set for ECS services. Move it to 'secrets' with a value of ${DatabaseMasterPasswordParam}", key)
+			}
+		}
+
 		params["AssignPublicIp"] = strconv.FormatBool(service.AssignPublicIP)
 
+		if err := workflow.resolveCapacityProviderStrategy(service, params); err != nil {
+			return err
+		}
+
 		// force 'awsvpc' network mode for ecs-fargate
 		if strings.EqualFold(string(workflow.envStack.Tags["provider"]), string(common.EnvProviderEcsFargate)) {
 			params["TaskNetworkMode"] = common.NetworkModeAwsVpc
@@ -267,6 +393,46 @@ func (workflow *serviceWorkflow) serviceApplyEcsParams(service *common.Service,
 	}
 }
 
+// resolveCapacityProviderStrategy checks that the capacity providers of a service are available in its environment, and
+// passes the capacity provider of the cluster when tasks are placed on its instances
+func (workflow *serviceWorkflow) resolveCapacityProviderStrategy(service *common.Service, params map[string]string) error {
+	if len(service.CapacityProviderStrategy) == 0 {
+		// the reservation scaling of a cluster is replaced by its capacity provider, which only scales for tasks placed through it
+		if capacityProvider := workflow.envStack.Outputs["CapacityProvider"]; capacityProvider != "" && !workflow.isFargateProvider()() {
+			service.CapacityProviderStrategy = []common.CapacityProviderStrategyItem{{CapacityProvider: common.CapacityProviderEc2, Weight: 1}}
+			params["CapacityProvider"] = capacityProvider
+		}
+		return nil
+	}
+
+	weight := 0
+	for _, item := range service.CapacityProviderStrategy {
+		switch item.CapacityProvider {
+		case common.CapacityProviderEc2:
+			if workflow.isFargateProvider()() {
+				return fmt.Errorf("Capacity provider '%s' isn't available in '%s' environments", item.CapacityProvider, common.EnvProviderEcsFargate)
+			}
+			capacityProvider := workflow.envStack.Outputs["CapacityProvider"]
+			if capacityProvider == "" {
+				return fmt.Errorf("Environment '%s' has no capacity provider, set 'cluster.capacityProvider' and run 'mu env up' first", workflow.envStack.Tags["environment"])
+			}
+			params["CapacityProvider"] = capacityProvider
+		case common.CapacityProviderFargate, common.CapacityProviderFargateSpot:
+			if !workflow.isFargateProvider()() {
+				return fmt.Errorf("Capacity provider '%s' is only available in '%s' environments", item.CapacityProvider, common.EnvProviderEcsFargate)
+			}
+		default:
+			return fmt.Errorf("Unknown capacity provider '%s', use one of '%s', '%s' or '%s'", item.CapacityProvider,
+				common.CapacityProviderEc2, common.CapacityProviderFargate, common.CapacityProviderFargateSpot)
+		}
+		weight += item.Weight
+	}
+	if weight == 0 {
+		return fmt.Errorf("The capacity provider strategy of service '%s' needs a weight on at least one capacity provider", workflow.serviceName)
+	}
+	return nil
+}
+
 func (workflow *serviceWorkflow) serviceApplyEc2Params(params map[string]string, rolesetGetter common.RolesetGetter) Executor {
 	return func() error {
 
@@ -294,6 +460,15 @@ func (workflow *serviceWorkflow) serviceApplyEc2Params(params map[string]string,
 			params[key] = workflow.envStack.Parameters[key]
 		}
 
+		// environments created before spot capacity was supported don't have these outputs
+		for _, key := range [...]string{
+			"OnDemandBaseCapacity",
+			"OnDemandPercentageAboveBaseCapacity",
+			"SpotAllocationStrategy",
+		} {
+			common.NewMapElementIfNotEmpty(params, key, workflow.envStack.Outputs[key])
+		}
+
 		serviceRoleset, err := rolesetGetter.GetServiceRoleset(workflow.envStack.Tags["environment"], workflow.serviceName)
 		if err != nil {
 			return err
@@ -338,12 +513,21 @@ func (workflow *serviceWorkflow) serviceApplyCommonParams(namespace string, serv
 			params["DatabaseEndpointAddress"] = dbStack.Outputs["DatabaseEndpointAddress"]
 			params["DatabaseEndpointPort"] = dbStack.Outputs["DatabaseEndpointPort"]
 			params["DatabaseMasterUsername"] = dbStack.Outputs["DatabaseMasterUsername"]
+			dbPassParam := fmt.Sprintf("%s-%s", dbStackName, "DatabaseMasterPassword")
 
-			dbPass, err := paramGetter.GetParam(fmt.Sprintf("%s-%s", dbStackName, "DatabaseMasterPassword"))
-			if err != nil {
-				log.Warningf("Unable to get db password: %s", err)
+			// secrets of ECS and kubernetes services can reference the parameter, the EC2 template doesn't declare it
+			if !workflow.isEc2Provider()() {
+				params["DatabaseMasterPasswordParam"] = dbPassParam
+			}
+
+			// ECS injects the password from SSM as a container secret
+			if !workflow.isEcsProvider()() {
+				dbPass, err := paramGetter.GetParam(dbPassParam)
+				if err != nil {
+					log.Warningf("Unable to get db password: %s", err)
+				}
+				params["DatabaseMasterPassword"] = dbPass
 			}
-			params["DatabaseMasterPassword"] = dbPass
 		}
 
 		svcStackName := common.CreateStackName(namespace, common.StackTypeService, workflow.serviceName, environmentName)
@@ -366,6 +550,58 @@ func (workflow *serviceWorkflow) serviceApplyCommonParams(namespace string, serv
 			// no value in config, and this is a create...use next available
 			params["PathListenerRulePriority"] = strconv.Itoa(nextAvailablePriority)
 			params["HostListenerRulePriority"] = strconv.Itoa(nextAvailablePriority + 1)
+			nextAvailablePriority += 2
+		}
+
+		// the load balancer only forwards HTTP/2 and gRPC to targets from an HTTPS listener
+		hasHTTPSListener := workflow.lbStack != nil && workflow.lbStack.Outputs["ElbHttpsListenerArn"] != ""
+		if (service.ProtocolVersion == common.ProtocolVersionHTTP2 || service.ProtocolVersion == common.ProtocolVersionGRPC) && !hasHTTPSListener {
+			return fmt.Errorf("Service '%s' uses %s, which requires an HTTPS listener on the load balancer", workflow.serviceName, service.ProtocolVersion)
+		}
+
+		resolveServicePorts(service)
+		if len(service.Ports) > 0 && nextAvailablePriority == 0 && workflow.lbStack != nil {
+			nextAvailablePriority = 1 + getMaxPriority(elbRuleLister, workflow.lbStack.Outputs["ElbHttpListenerArn"])
+		}
+		for idx, port := range service.Ports {
+			if port.Port == 0 || (len(port.PathPatterns) == 0 && len(port.HostPatterns) == 0) {
+				return fmt.Errorf("Port '%s' requires a port number and at least one path or host pattern", port.Name)
+			}
+
+			pathKey := fmt.Sprintf("Port%dPathListenerRulePriority", idx)
+			hostKey := fmt.Sprintf("Port%dHostListenerRulePriority", idx)
+			if svcStack != nil && svcStack.Status != "ROLLBACK_COMPLETE" && svcStack.Parameters[pathKey] != "" {
+				// port already exists...use prior value
+				params[pathKey] = ""
+				params[hostKey] = ""
+			} else if port.Priority > 0 {
+				err := checkPriorityNotInUse(elbRuleLister, workflow.lbStack.Outputs["ElbHttpListenerArn"], []int{port.Priority, port.Priority + 1})
+				if err != nil {
+					return err
+				}
+				params[pathKey] = strconv.Itoa(port.Priority)
+				params[hostKey] = strconv.Itoa(port.Priority + 1)
+			} else {
+				params[pathKey] = strconv.Itoa(nextAvailablePriority)
+				params[hostKey] = strconv.Itoa(nextAvailablePriority + 1)
+				nextAvailablePriority += 2
+			}
+
+			if port.ProtocolVersion != common.ProtocolVersionHTTP1 && !hasHTTPSListener {
+				return fmt.Errorf("Port '%s' uses %s, which requires an HTTPS listener on the load balancer", port.Name, port.ProtocolVersion)
+			}
+		}
+
+		// priorities set in the config can collide with the ones assigned to the other rules
+		usedPriorities := make(map[string]string)
+		for _, key := range listenerRulePriorityKeys(len(service.Ports)) {
+			if params[key] == "" {
+				continue
+			}
+			if other, ok := usedPriorities[params[key]]; ok {
+				return fmt.Errorf("Listener rule priority %s is used by both %s and %s, set a distinct 'priority' for each port", params[key], other, key)
+			}
+			usedPriorities[params[key]] = key
 		}
 
 		params["Namespace"] = namespace
@@ -373,7 +609,18 @@ func (workflow *serviceWorkflow) serviceApplyCommonParams(namespace string, serv
 		params["ServiceName"] = workflow.serviceName
 		common.NewMapElementIfNotZero(params, "ServicePort", service.Port)
 		common.NewMapElementIfNotEmpty(params, "ServiceProtocol", string(service.Protocol))
+		common.NewMapElementIfNotEmpty(params, "ServiceProtocolVersion", string(service.ProtocolVersion))
 		common.NewMapElementIfNotEmpty(params, "ServiceHealthEndpoint", service.HealthEndpoint)
+		common.NewMapElementIfNotZero(params, "ServiceHealthCheckInterval", service.HealthCheck.Interval)
+		common.NewMapElementIfNotZero(params, "ServiceHealthCheckTimeout", service.HealthCheck.Timeout)
+		common.NewMapElementIfNotZero(params, "ServiceHealthyThreshold", service.HealthCheck.HealthyThreshold)
+		common.NewMapElementIfNotZero(params, "ServiceUnhealthyThreshold", service.HealthCheck.UnhealthyThreshold)
+		common.NewMapElementIfNotEmpty(params, "ServiceHealthCheckMatcher", service.HealthCheck.Matcher)
+		common.NewMapElementIfNotZero(params, "ServiceHealthCheckGracePeriod", service.HealthCheck.GracePeriod)
+		common.NewMapElementIfNotZero(params, "ServiceDeregistrationDelay", service.HealthCheck.DeregistrationDelay)
+		common.NewMapElementIfNotZero(params, "ServiceSlowStart", service.HealthCheck.SlowStart)
+		params["ServiceStickiness"] = strconv.FormatBool(service.HealthCheck.Stickiness)
+		common.NewMapElementIfNotZero(params, "ServiceStickinessDuration", service.HealthCheck.StickinessDuration)
 		common.NewMapElementIfNotZero(params, "ServiceDesiredCount", service.DesiredCount)
 		common.NewMapElementIfNotZero(params, "ServiceMinSize", service.MinSize)
 		common.NewMapElementIfNotZero(params, "ServiceMaxSize", service.MaxSize)
@@ -388,6 +635,44 @@ func (workflow *serviceWorkflow) serviceApplyCommonParams(namespace string, serv
 	}
 }
 
+// listenerRulePriorityKeys returns the parameters with the listener rule priorities of the service and its ports
+func listenerRulePriorityKeys(portCount int) []string {
+	keys := []string{"PathListenerRulePriority", "HostListenerRulePriority"}
+	for idx := 0; idx < portCount; idx++ {
+		keys = append(keys, fmt.Sprintf("Port%dPathListenerRulePriority", idx), fmt.Sprintf("Port%dHostListenerRulePriority", idx))
+	}
+	return keys
+}
+
+// resolveServicePorts fills in the defaults for the additional ports of a service
+func resolveServicePorts(service *common.Service) {
+	for idx := range service.Ports {
+		port := &service.Ports[idx]
+		if port.Name == "" {
+			port.Name = fmt.Sprintf("port-%d", port.Port)
+		}
+		if port.Protocol == "" {
+			port.Protocol = common.ServiceProtocolHTTP
+		}
+		if port.ProtocolVersion == "" {
+			port.ProtocolVersion = common.ProtocolVersionHTTP1
+		}
+		if port.HealthEndpoint == "" {
+			if port.ProtocolVersion == common.ProtocolVersionGRPC {
+				port.HealthEndpoint = "/AWS.ALB/healthcheck"
+			} else {
+				port.HealthEndpoint = "/health"
+			}
+		}
+	}
+}
+
+// ec2ServiceTemplateData adds the instance types of the environment to the service, for the overrides of the autoscaling group
+type ec2ServiceTemplateData struct {
+	*common.Service
+	InstanceTypes []string
+}
+
 func (workflow *serviceWorkflow) serviceEc2Deployer(namespace string, service *common.Service, stackParams map[string]string, environmentName string, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) Executor {
 	return func() error {
 
@@ -405,7 +690,14 @@ func (workflow *serviceWorkflow) serviceEc2Deployer(namespace string, service *c
 			Revision:    workflow.codeRevision,
 			Repo:        workflow.repoName,
 		})
-		err := stackUpserter.UpsertStack(svcStackName, common.TemplateServiceEC2, service, stackParams, tags, "", workflow.cloudFormationRoleArn)
+		templateData := &ec2ServiceTemplateData{Service: service}
+		for _, instanceType := range strings.Split(workflow.envStack.Outputs["InstanceTypes"], ",") {
+			if instanceType != "" {
+				templateData.InstanceTypes = append(templateData.InstanceTypes, instanceType)
+			}
+		}
+
+		err := stackUpserter.UpsertStack(svcStackName, common.TemplateServiceEC2, templateData, stackParams, tags, "", workflow.cloudFormationRoleArn)
 		if err != nil {
 			return err
 		}
@@ -425,6 +717,9 @@ func (workflow *serviceWorkflow) serviceEc2Deployer(namespace string, service *c
 func (workflow *serviceWorkflow) serviceEcsDeployer(namespace string, service *common.Service, stackParams map[string]string, environmentName string, stackUpserter common.StackUpserter, stackWaiter common.StackWaiter) Executor {
 	return func() error {
 		log.Noticef("Deploying service '%s' to '%s' from '%s'", workflow.serviceName, environmentName, workflow.serviceImage)
+		if common.IsPrivateRegistry(service.Registry) && service.Registry.PullSecretArn == "" {
+			log.Warningf("No 'registry.pullSecretArn' set for service '%s', ECS won't be able to pull from a private registry", workflow.serviceName)
+		}
 
 		svcStackName := common.CreateStackName(namespace, common.StackTypeService, workflow.serviceName, environmentName)
 
@@ -479,6 +774,121 @@ func (workflow *serviceWorkflow) serviceEksDBSecret(namespace string, service *c
 	}
 }
 
+// serviceEksSecrets resolves the values of the service secrets from SSM and upserts
+// them as a kubernetes secret that is loaded into the environment of the pods
+func (workflow *serviceWorkflow) serviceEksSecrets(service *common.Service, stackParams map[string]string, paramGetter common.ParamGetter, environmentName string) Executor {
+	return func() error {
+		if len(service.Secrets) == 0 {
+			return nil
+		}
+		log.Noticef("Deploying secrets for '%s' in '%s'", workflow.serviceName, environmentName)
+
+		secretData := make(map[string]string)
+		for name, valueFrom := range service.Secrets {
+			// allow references such as ${DatabaseMasterPasswordParam}, like ECS does with !Sub
+			var missing []string
+			valueFrom = os.Expand(valueFrom, func(key string) string {
+				if stackParams[key] == "" {
+					missing = append(missing, key)
+				}
+				return stackParams[key]
+			})
+			if len(missing) > 0 {
+				return fmt.Errorf("Secret '%s' references unknown parameters: %s", name, strings.Join(missing, ", "))
+			}
+			value, err := getSecretValue(paramGetter, valueFrom)
+			if err != nil {
+				return fmt.Errorf("Unable to get secret '%s': %v", name, err)
+			}
+			secretData[name] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+
+		params := map[string]interface{}{
+			"ServiceName": workflow.serviceName,
+			"Namespace":   fmt.Sprintf("mu-service-%s", workflow.serviceName),
+			"Revision":    workflow.codeRevision,
+			"MuVersion":   common.GetVersion(),
+			"SecretName":  fmt.Sprintf("%s-secrets", workflow.serviceName),
+			"SecretData":  secretData,
+		}
+
+		return workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sSecrets, params)
+	}
+}
+
+// serviceEksRegistrySecret upserts the credentials of a private registry as the
+// kubernetes secret the pods pull the service images with
+func (workflow *serviceWorkflow) serviceEksRegistrySecret(service *common.Service, authenticator common.RepositoryAuthenticator, environmentName string) Executor {
+	return func() error {
+		if !common.IsPrivateRegistry(service.Registry) {
+			return nil
+		}
+		log.Noticef("Deploying registry credentials for '%s' in '%s'", workflow.serviceName, environmentName)
+
+		token, err := authenticator.AuthenticateRepository(workflow.serviceImage)
+		if err != nil {
+			return err
+		}
+		dockerConfig, err := json.Marshal(map[string]interface{}{
+			"auths": map[string]interface{}{
+				common.RegistryHost(workflow.serviceImage): map[string]string{"auth": token},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		params := map[string]interface{}{
+			"ServiceName":      workflow.serviceName,
+			"Namespace":        fmt.Sprintf("mu-service-%s", workflow.serviceName),
+			"Revision":         workflow.codeRevision,
+			"MuVersion":        common.GetVersion(),
+			"SecretName":       fmt.Sprintf("%s-registry", workflow.serviceName),
+			"DockerConfigJSON": base64.StdEncoding.EncodeToString(dockerConfig),
+		}
+
+		return workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sRegistry, params)
+	}
+}
+
+// secretParamName returns the SSM parameter name to read a secret from, and the json key
+// to select from its value.  Secrets Manager secrets are read through the SSM parameter
+// store reference path.
+func secretParamName(valueFrom string) (string, string) {
+	arnParts := strings.Split(valueFrom, ":")
+	if len(arnParts) >= 7 && arnParts[0] == "arn" && arnParts[2] == "secretsmanager" {
+		jsonKey := ""
+		if len(arnParts) > 7 {
+			jsonKey = arnParts[7]
+		}
+		return fmt.Sprintf("/aws/reference/secretsmanager/%s", strings.Join(arnParts[:7], ":")), jsonKey
+	}
+	return valueFrom, ""
+}
+
+// getSecretValue reads a secret, selecting the json key of a Secrets Manager secret like ECS does
+func getSecretValue(paramGetter common.ParamGetter, valueFrom string) (string, error) {
+	paramName, jsonKey := secretParamName(valueFrom)
+	value, err := paramGetter.GetParam(paramName)
+	if err != nil || jsonKey == "" {
+		return value, err
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("Unable to select key '%s' from a secret that isn't json: %v", jsonKey, err)
+	}
+	field, ok := fields[jsonKey]
+	if !ok {
+		return "", fmt.Errorf("Secret has no key '%s'", jsonKey)
+	}
+	if str, ok := field.(string); ok {
+		return str, nil
+	}
+	fieldJSON, err := json.Marshal(field)
+	return string(fieldJSON), err
+}
+
 // serviceEksDeployer accepts a service and its information and upserts a kubernetes Pod file to
 // a k8s cluster
 func (workflow *serviceWorkflow) serviceEksDeployer(namespace string, service *common.Service, stackParams map[string]string, environmentName string) Executor {
@@ -500,26 +910,41 @@ func (workflow *serviceWorkflow) serviceEksDeployer(namespace string, service *c
 			serviceHealthEndpoint = service.HealthEndpoint
 		}
 
-		pathPatterns := service.PathPatterns
-		for idx, pattern := range pathPatterns {
-			pathPatterns[idx] = strings.TrimRight(pattern, "*")
-		}
+		ingressController := workflow.kubernetesIngressController()
 
 		resolveServiceEnvironment(service, environmentName)
+		resolveServicePorts(service)
+		workflow.resolveServiceSidecars(service)
+		autoscaling := kubernetesAutoscaling(service)
+		disruptionBudget := kubernetesDisruptionBudget(service, autoscaling)
 		templateData := map[string]interface{}{
 			"Namespace":             fmt.Sprintf("mu-service-%s", workflow.serviceName),
 			"ServiceName":           workflow.serviceName,
 			"ServicePort":           servicePort,
 			"ServiceProto":          strings.ToLower(serviceProto),
-			"PathPatterns":          pathPatterns,
+			"PathPatterns":          kubernetesIngressPaths(service.PathPatterns, ingressController),
 			"HostPatterns":          service.HostPatterns,
 			"ImageUrl":              workflow.serviceImage,
+			"ImageTag":              workflow.serviceImageTag(),
 			"ServiceHealthEndpoint": serviceHealthEndpoint,
 			"ServiceHealthProto":    strings.ToUpper(serviceProto),
 			"Revision":              workflow.codeRevision,
 			"MuVersion":             common.GetVersion(),
 			"EnvVariables":          service.Environment,
 			"DeploymentStrategy":    string(service.DeploymentStrategy),
+			"Sidecars":              kubernetesSidecars(service.Sidecars),
+			"Ports":                 kubernetesPorts(service.Ports, ingressController),
+			"Probes":                kubernetesProbes(service.HealthCheck),
+			"Replicas":              kubernetesReplicas(service),
+			"Resources":             kubernetesResources(service),
+			"Autoscaling":           autoscaling,
+			"DisruptionBudget":      disruptionBudget,
+			"Stickiness":            service.HealthCheck.Stickiness,
+			"ServiceAccountRoleArn": workflow.eksPodRoleArn,
+			"IngressController":     string(ingressController),
+			"IngressGroup":          workflow.envStack.Name,
+			"IngressHTTPS":          workflow.envStack.Parameters["IngressCertificate"] != "",
+			"Priority":              service.Priority,
 		}
 		// see common/types.go DeploymentStrategy types for valid string values
 		templateData["MaxUnavailable"], templateData["MaxSurge"] = getMaxUnavilableAndSurgePercentForKubernetesStrategy(service.DeploymentStrategy)
@@ -527,53 +952,260 @@ func (workflow *serviceWorkflow) serviceEksDeployer(namespace string, service *c
 		if stackParams["DatabaseName"] != "" {
 			templateData["DatabaseSecretName"] = fmt.Sprintf("%s-database", workflow.serviceName)
 		}
+		if len(service.Secrets) > 0 {
+			templateData["SecretName"] = fmt.Sprintf("%s-secrets", workflow.serviceName)
+		}
+		if common.IsPrivateRegistry(service.Registry) {
+			templateData["ImagePullSecret"] = fmt.Sprintf("%s-registry", workflow.serviceName)
+		}
 
-		return workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sDeployment, templateData)
-	}
-}
+		err := workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sDeployment, templateData)
+		if err != nil {
+			return err
+		}
 
-func (workflow *serviceWorkflow) serviceCreateSchedules(namespace string, service *common.Service, environmentName string, stackWaiter common.StackWaiter, stackUpserter common.StackUpserter) Executor {
-	return func() error {
-		log.Noticef("Creating schedules for service '%s' to '%s'", workflow.serviceName, environmentName)
-		for _, schedule := range service.Schedule {
-			params := make(map[string]string)
-
-			params["ServiceName"] = workflow.serviceName
-			params["EcsCluster"] = fmt.Sprintf("%s-EcsCluster", workflow.envStack.Name)
-			params["MicroserviceTaskDefinitionArn"] = workflow.microserviceTaskDefinitionArn
-			params["EcsEventsRoleArn"] = workflow.ecsEventsRoleArn
-
-			// these parameters are specific to each of the defined schedules
-			params["ScheduleExpression"] = schedule.Expression
-			commandBytes, err := json.Marshal(schedule.Command)
+		// a HorizontalPodAutoscaler left over from a previous deploy would keep overriding the replicas
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+		if autoscaling == nil {
+			err = workflow.kubernetesStaleResourceDeleter("autoscaling/v2beta1", "HorizontalPodAutoscaler", namespace, fmt.Sprintf("%s-autoscaler", workflow.serviceName))
 			if err != nil {
 				return err
 			}
-			params["ScheduleCommand"] = string(commandBytes)
+		}
+		if disruptionBudget == nil {
+			return workflow.kubernetesStaleResourceDeleter("policy/v1beta1", "PodDisruptionBudget", namespace, fmt.Sprintf("%s-disruption-budget", workflow.serviceName))
+		}
+		return nil
+	}
+}
 
-			scheduleStackName := common.CreateStackName(namespace, common.StackTypeSchedule, workflow.serviceName+"-"+strings.ToLower(schedule.Name), environmentName)
-			resolveServiceEnvironment(service, environmentName)
+// kubernetesStaleResourceDeleter deletes a resource of the service that the deployment template no longer renders
+func (workflow *serviceWorkflow) kubernetesStaleResourceDeleter(apiVersion string, kind string, namespace string, name string) error {
+	resources, err := workflow.kubernetesResourceManager.ListResources(apiVersion, kind, namespace)
+	if err != nil {
+		return err
+	}
+	if resources == nil {
+		return nil
+	}
+	for _, resource := range resources.Items {
+		if resource.GetName() == name {
+			log.Noticef("Deleting %s '%s'", kind, name)
+			return workflow.kubernetesResourceManager.DeleteResource(apiVersion, kind, namespace, name)
+		}
+	}
+	return nil
+}
 
-			tags := createTagMap(&ScheduleTags{
-				Service:     workflow.serviceName,
-				Environment: environmentName,
-				Type:        common.StackTypeSchedule,
-			})
+// kubernetesAutoscaling converts the service scaling targets into the fields
+// used by the HorizontalPodAutoscaler in the kubernetes deployment template.
+// Policies that depend on CloudWatch metrics or schedules are only supported on ECS.
+func kubernetesAutoscaling(service *common.Service) map[string]int {
+	autoscaling := service.Autoscaling
+	if autoscaling.TargetRequestCount != 0 || len(autoscaling.StepScaling) > 0 || len(autoscaling.Schedules) > 0 {
+		log.Warningf("Request count, step scaling and scheduled scaling are not supported on EKS and will be ignored")
+	}
+	if service.TargetCPUUtilization == 0 && autoscaling.TargetMemoryUtilization == 0 {
+		return nil
+	}
 
-			err = stackUpserter.UpsertStack(scheduleStackName, common.TemplateSchedule, service, params, tags, "", workflow.cloudFormationRoleArn)
-			if err != nil {
-				return err
-			}
-			log.Debugf("Waiting for stack '%s' to complete", scheduleStackName)
-			stack := stackWaiter.AwaitFinalStatus(scheduleStackName)
-			if stack == nil {
-				return fmt.Errorf("Unable to create stack %s", scheduleStackName)
-			}
-			if strings.HasSuffix(stack.Status, "ROLLBACK_COMPLETE") || !strings.HasSuffix(stack.Status, "_COMPLETE") {
-				return fmt.Errorf("Ended in failed status %s %s", stack.Status, stack.StatusReason)
+	hpa := map[string]int{
+		"MinReplicas":             1,
+		"MaxReplicas":             2,
+		"TargetCPUUtilization":    service.TargetCPUUtilization,
+		"TargetMemoryUtilization": autoscaling.TargetMemoryUtilization,
+	}
+	if service.MinSize != 0 {
+		hpa["MinReplicas"] = service.MinSize
+	}
+	if service.MaxSize != 0 {
+		hpa["MaxReplicas"] = service.MaxSize
+	}
+	if service.TargetCPUUtilization != 0 && service.CPU == 0 {
+		log.Warningf("Scaling on CPU utilization needs `cpu` to be set for the service on EKS")
+	}
+	return hpa
+}
+
+// kubernetesReplicas returns the number of pods of the deployment, which defaults
+// to the desired count of an ECS service, or the 3 pods it ran before it was configurable
+func kubernetesReplicas(service *common.Service) int {
+	if service.DesiredCount != 0 {
+		return service.DesiredCount
+	}
+	return 3
+}
+
+// kubernetesResources converts the service cpu and memory into the resource
+// requests and limits of the service container.  ECS cpu units are shares of
+// the instance rather than a hard limit, so cpu is only requested.
+func kubernetesResources(service *common.Service) map[string]string {
+	if service.CPU == 0 && service.Memory == 0 {
+		return nil
+	}
+	resources := map[string]string{
+		"CPU":    "",
+		"Memory": "",
+	}
+	if service.CPU != 0 {
+		// ECS cpu units are 1/1024 of a vCPU
+		resources["CPU"] = fmt.Sprintf("%dm", service.CPU*1000/1024)
+	}
+	if service.Memory != 0 {
+		resources["Memory"] = fmt.Sprintf("%dMi", service.Memory)
+	}
+	return resources
+}
+
+// kubernetesDisruptionBudget keeps all but one pod of the service running during
+// voluntary disruptions, like draining a node, when it runs more than one pod
+func kubernetesDisruptionBudget(service *common.Service, autoscaling map[string]int) map[string]int {
+	minReplicas := kubernetesReplicas(service)
+	if autoscaling != nil {
+		minReplicas = autoscaling["MinReplicas"]
+	}
+	if minReplicas < 2 {
+		return nil
+	}
+	return map[string]int{
+		"MaxUnavailable": 1,
+	}
+}
+
+// kubernetesProbes converts the service health check into the readiness and
+// liveness probe settings used by the kubernetes deployment template
+func kubernetesProbes(healthCheck common.ServiceHealthCheck) map[string]int {
+	probes := map[string]int{
+		"ReadinessDelay":   5,
+		"ReadinessPeriod":  10,
+		"LivenessDelay":    15,
+		"LivenessPeriod":   30,
+		"Timeout":          3,
+		"SuccessThreshold": 2,
+		"FailureThreshold": 5,
+	}
+	if healthCheck.Interval != 0 {
+		probes["ReadinessPeriod"] = healthCheck.Interval
+		probes["LivenessPeriod"] = healthCheck.Interval
+	}
+	if healthCheck.Timeout != 0 {
+		probes["Timeout"] = healthCheck.Timeout
+	}
+	if healthCheck.HealthyThreshold != 0 {
+		probes["SuccessThreshold"] = healthCheck.HealthyThreshold
+	}
+	if healthCheck.UnhealthyThreshold != 0 {
+		probes["FailureThreshold"] = healthCheck.UnhealthyThreshold
+	}
+	if healthCheck.GracePeriod != 0 {
+		probes["LivenessDelay"] = healthCheck.GracePeriod
+	}
+	return probes
+}
+
+// kubernetesIngressController returns the ingress controller the environment of the service was created with
+func (workflow *serviceWorkflow) kubernetesIngressController() common.IngressController {
+	if workflow.envStack != nil && workflow.envStack.Parameters["IngressController"] == common.IngressControllerAlb {
+		return common.IngressControllerAlb
+	}
+	return common.IngressControllerNginx
+}
+
+// kubernetesIngressPaths converts path patterns for the ingress controller, the
+// ALB controller keeps the wildcards of the listener rules that nginx doesn't understand
+func kubernetesIngressPaths(pathPatterns []string, ingressController common.IngressController) []string {
+	paths := make([]string, len(pathPatterns))
+	for idx, pattern := range pathPatterns {
+		if ingressController == common.IngressControllerAlb {
+			paths[idx] = pattern
+		} else {
+			paths[idx] = strings.TrimRight(pattern, "*")
+		}
+	}
+	return paths
+}
+
+// kubernetesPorts converts the additional service ports into the fields used by
+// the kubernetes deployment template
+func kubernetesPorts(ports []common.ServicePort, ingressController common.IngressController) []map[string]interface{} {
+	servicePorts := []map[string]interface{}{}
+	for _, port := range ports {
+		backendProtocol := string(port.Protocol)
+		if port.ProtocolVersion == common.ProtocolVersionGRPC {
+			backendProtocol = "GRPC"
+			if port.Protocol == common.ServiceProtocolHTTPS {
+				backendProtocol = "GRPCS"
 			}
 		}
-		return nil
+
+		healthEndpoint := "/health"
+		if port.HealthEndpoint != "" {
+			healthEndpoint = port.HealthEndpoint
+		}
+		protocol := common.ServiceProtocolHTTP
+		if port.Protocol != "" {
+			protocol = string(port.Protocol)
+		}
+
+		servicePorts = append(servicePorts, map[string]interface{}{
+			"Name":            port.Name,
+			"Port":            port.Port,
+			"Protocol":        protocol,
+			"BackendProtocol": backendProtocol,
+			"HealthEndpoint":  healthEndpoint,
+			"Priority":        port.Priority,
+			"PathPatterns":    kubernetesIngressPaths(port.PathPatterns, ingressController),
+			"HostPatterns":    port.HostPatterns,
+		})
+	}
+	return servicePorts
+}
+
+// kubernetesSidecars converts sidecars into the container fields used by the
+// kubernetes deployment template.  Kubernetes has no equivalent of container
+// dependencies, so `dependsOn` is only honored on ECS.
+func kubernetesSidecars(sidecars []common.Sidecar) []map[string]interface{} {
+	containers := []map[string]interface{}{}
+	for _, sidecar := range sidecars {
+		container := map[string]interface{}{
+			"Name":         sidecar.Name,
+			"Image":        sidecar.Image,
+			"EnvVariables": sidecar.Environment,
+			"Ports":        sidecar.Ports,
+			"CPU":          "",
+			"Memory":       fmt.Sprintf("%dMi", sidecar.Memory),
+			"HealthCheck":  nil,
+		}
+		if sidecar.CPU != 0 {
+			// ECS cpu units are 1/1024 of a vCPU
+			container["CPU"] = fmt.Sprintf("%dm", sidecar.CPU*1000/1024)
+		}
+		if sidecar.HealthCheck != nil {
+			healthCheck := *sidecar.HealthCheck
+			healthCheck.Command = kubernetesExecCommand(healthCheck.Command)
+			container["HealthCheck"] = &healthCheck
+		}
+		if len(sidecar.DependsOn) > 0 {
+			log.Debugf("Ignoring dependsOn for sidecar '%s' on kubernetes", sidecar.Name)
+		}
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// kubernetesExecCommand translates an ECS style health check command
+// (e.g. ["CMD-SHELL", "curl -f localhost"]) into an exec probe command
+func kubernetesExecCommand(command []string) []string {
+	if len(command) == 0 {
+		return command
+	}
+	switch command[0] {
+	case "CMD-SHELL":
+		return []string{"/bin/sh", "-c", strings.Join(command[1:], " ")}
+	case "CMD":
+		return command[1:]
+	default:
+		return command
 	}
 }
 
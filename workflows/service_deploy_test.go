@@ -1,6 +1,9 @@
 package workflows
 
 import (
+	"errors"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/stelligent/mu/common"
@@ -32,6 +35,7 @@ func TestServiceApplyCommon_Create(t *testing.T) {
 	outputs := make(map[string]string)
 	outputs["ElbHttpListenerArn"] = "foo"
 	outputs["ElbHttpsListenerArn"] = "foo"
+	outputs["ElbFullName"] = "app/foo/123"
 
 	stackManager.On("AwaitFinalStatus", "mu-service-myservice-dev").Return(nil).Once()
 	stackManager.On("AwaitFinalStatus", "mu-database-myservice-dev").Return(nil).Once()
@@ -59,12 +63,209 @@ func TestServiceApplyCommon_Create(t *testing.T) {
 	assert.Equal("mu-loadbalancer-dev-ElbHttpsListenerArn", params["ElbHttpsListenerArn"])
 	assert.Equal("16", params["PathListenerRulePriority"])
 
+	// only declared by the ECS service template
+	assert.NotContains(params, "ElbFullName")
+
 	stackManager.AssertExpectations(t)
 	stackManager.AssertNumberOfCalls(t, "AwaitFinalStatus", 2)
 	elbRuleLister.AssertExpectations(t)
 	elbRuleLister.AssertNumberOfCalls(t, "ListRules", 1)
 }
 
+func TestServiceApplyCommon_Ports(t *testing.T) {
+	assert := assert.New(t)
+	stackManager := new(mockedStackManagerForUpsert)
+	outputs := make(map[string]string)
+	outputs["ElbHttpListenerArn"] = "foo"
+	outputs["ElbHttpsListenerArn"] = "foo"
+
+	stackManager.On("AwaitFinalStatus", "mu-service-myservice-dev").Return(nil).Once()
+	stackManager.On("AwaitFinalStatus", "mu-database-myservice-dev").Return(nil).Once()
+
+	paramManager := new(mockedParamManager)
+
+	elbRuleLister := new(mockedElbManager)
+	elbRuleLister.On("ListRules", "foo").Return([]common.ElbRule{
+		{Priority: stringRef("15")},
+		{Priority: stringRef("5")},
+		{Priority: stringRef("10")},
+	})
+
+	service := new(common.Service)
+	service.Ports = []common.ServicePort{
+		{Port: 9090, PathPatterns: []string{"/admin/*"}},
+		{Name: "api", Port: 50051, ProtocolVersion: common.ProtocolVersionGRPC, HostPatterns: []string{"grpc.example.com"}},
+	}
+	params := make(map[string]string)
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "myservice"
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+	workflow.lbStack = &common.Stack{Name: "mu-loadbalancer-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+	err := workflow.serviceApplyCommonParams("mu", service, params, "dev", stackManager, elbRuleLister, paramManager)()
+	assert.Nil(err)
+
+	assert.Equal("16", params["PathListenerRulePriority"])
+	assert.Equal("17", params["HostListenerRulePriority"])
+	assert.Equal("18", params["Port0PathListenerRulePriority"])
+	assert.Equal("19", params["Port0HostListenerRulePriority"])
+	assert.Equal("20", params["Port1PathListenerRulePriority"])
+	assert.Equal("21", params["Port1HostListenerRulePriority"])
+
+	assert.Equal("port-9090", service.Ports[0].Name)
+	assert.Equal(common.ServiceProtocol(common.ServiceProtocolHTTP), service.Ports[0].Protocol)
+	assert.Equal(common.ProtocolVersion(common.ProtocolVersionHTTP1), service.Ports[0].ProtocolVersion)
+	assert.Equal("/health", service.Ports[0].HealthEndpoint)
+	assert.Equal("/AWS.ALB/healthcheck", service.Ports[1].HealthEndpoint)
+
+	stackManager.AssertExpectations(t)
+	elbRuleLister.AssertNumberOfCalls(t, "ListRules", 1)
+}
+
+func TestServiceApplyCommon_ProtocolVersionWithoutHttps(t *testing.T) {
+	assert := assert.New(t)
+	outputs := make(map[string]string)
+	outputs["ElbHttpListenerArn"] = "foo"
+
+	services := map[string]*common.Service{
+		"grpc port": {Ports: []common.ServicePort{
+			{Name: "api", Port: 50051, ProtocolVersion: common.ProtocolVersionGRPC, HostPatterns: []string{"grpc.example.com"}},
+		}},
+		"http2 port": {Ports: []common.ServicePort{
+			{Name: "push", Port: 8443, ProtocolVersion: common.ProtocolVersionHTTP2, PathPatterns: []string{"/push/*"}},
+		}},
+		"http2 service": {ProtocolVersion: common.ProtocolVersionHTTP2, PathPatterns: []string{"/*"}},
+	}
+	for name, service := range services {
+		stackManager := new(mockedStackManagerForUpsert)
+		stackManager.On("AwaitFinalStatus", "mu-service-myservice-dev").Return(nil).Once()
+		stackManager.On("AwaitFinalStatus", "mu-database-myservice-dev").Return(nil).Once()
+
+		elbRuleLister := new(mockedElbManager)
+		elbRuleLister.On("ListRules", "foo").Return([]common.ElbRule{})
+
+		params := make(map[string]string)
+		workflow := new(serviceWorkflow)
+		workflow.serviceName = "myservice"
+		workflow.envStack = &common.Stack{Name: "mu-environment-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+		workflow.lbStack = &common.Stack{Name: "mu-loadbalancer-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+		err := workflow.serviceApplyCommonParams("mu", service, params, "dev", stackManager, elbRuleLister, new(mockedParamManager))()
+		assert.NotNil(err, name)
+		assert.Contains(err.Error(), "HTTPS listener", name)
+	}
+}
+
+func TestServiceApplyCommon_DuplicatePriority(t *testing.T) {
+	assert := assert.New(t)
+	stackManager := new(mockedStackManagerForUpsert)
+	outputs := make(map[string]string)
+	outputs["ElbHttpListenerArn"] = "foo"
+	outputs["ElbHttpsListenerArn"] = "foo"
+
+	stackManager.On("AwaitFinalStatus", "mu-service-myservice-dev").Return(nil).Once()
+	stackManager.On("AwaitFinalStatus", "mu-database-myservice-dev").Return(nil).Once()
+
+	elbRuleLister := new(mockedElbManager)
+	elbRuleLister.On("ListRules", "foo").Return([]common.ElbRule{
+		{Priority: stringRef("15")},
+	})
+
+	service := new(common.Service)
+	service.Ports = []common.ServicePort{
+		{Port: 9090, PathPatterns: []string{"/admin/*"}, Priority: 21},
+	}
+	params := make(map[string]string)
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "myservice"
+	workflow.priority = 20
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+	workflow.lbStack = &common.Stack{Name: "mu-loadbalancer-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+	err := workflow.serviceApplyCommonParams("mu", service, params, "dev", stackManager, elbRuleLister, new(mockedParamManager))()
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "21")
+}
+
+func TestKubernetesPorts(t *testing.T) {
+	assert := assert.New(t)
+
+	servicePorts := []common.ServicePort{
+		{Name: "admin", Port: 9090, Protocol: common.ServiceProtocolHTTP, ProtocolVersion: common.ProtocolVersionHTTP1, PathPatterns: []string{"/admin/*"}},
+		{Name: "api", Port: 50051, Protocol: common.ServiceProtocolHTTPS, ProtocolVersion: common.ProtocolVersionGRPC},
+	}
+	ports := kubernetesPorts(servicePorts, common.IngressControllerNginx)
+
+	assert.Len(ports, 2)
+	assert.Equal("HTTP", ports[0]["BackendProtocol"])
+	assert.Equal([]string{"/admin/"}, ports[0]["PathPatterns"])
+	assert.Equal("/health", ports[0]["HealthEndpoint"])
+	assert.Equal("GRPCS", ports[1]["BackendProtocol"])
+
+	ports = kubernetesPorts(servicePorts, common.IngressControllerAlb)
+	assert.Equal([]string{"/admin/*"}, ports[0]["PathPatterns"])
+}
+
+func TestKubernetesIngressController(t *testing.T) {
+	assert := assert.New(t)
+
+	workflow := new(serviceWorkflow)
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev"}
+	assert.Equal(common.IngressControllerNginx, workflow.kubernetesIngressController())
+
+	workflow.envStack.Parameters = map[string]string{"IngressController": "alb"}
+	assert.Equal(common.IngressController(common.IngressControllerAlb), workflow.kubernetesIngressController())
+}
+
+func TestServiceApplyCommon_HealthCheck(t *testing.T) {
+	assert := assert.New(t)
+	stackManager := new(mockedStackManagerForUpsert)
+	outputs := make(map[string]string)
+	outputs["ElbHttpListenerArn"] = "foo"
+
+	stackManager.On("AwaitFinalStatus", "mu-service-myservice-dev").Return(nil).Once()
+	stackManager.On("AwaitFinalStatus", "mu-database-myservice-dev").Return(nil).Once()
+
+	paramManager := new(mockedParamManager)
+
+	elbRuleLister := new(mockedElbManager)
+	elbRuleLister.On("ListRules", "foo").Return([]common.ElbRule{})
+
+	service := new(common.Service)
+	service.HealthCheck = common.ServiceHealthCheck{
+		Interval:    15,
+		Matcher:     "200,204",
+		GracePeriod: 300,
+		Stickiness:  true,
+	}
+	params := make(map[string]string)
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "myservice"
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+	workflow.lbStack = &common.Stack{Name: "mu-loadbalancer-dev", Status: common.StackStatusCreateComplete, Outputs: outputs}
+	err := workflow.serviceApplyCommonParams("mu", service, params, "dev", stackManager, elbRuleLister, paramManager)()
+	assert.Nil(err)
+
+	assert.Equal("15", params["ServiceHealthCheckInterval"])
+	assert.Equal("200,204", params["ServiceHealthCheckMatcher"])
+	assert.Equal("300", params["ServiceHealthCheckGracePeriod"])
+	assert.Equal("true", params["ServiceStickiness"])
+	_, ok := params["ServiceHealthCheckTimeout"]
+	assert.False(ok)
+}
+
+func TestKubernetesProbes(t *testing.T) {
+	assert := assert.New(t)
+
+	probes := kubernetesProbes(common.ServiceHealthCheck{})
+	assert.Equal(10, probes["ReadinessPeriod"])
+	assert.Equal(30, probes["LivenessPeriod"])
+	assert.Equal(15, probes["LivenessDelay"])
+
+	probes = kubernetesProbes(common.ServiceHealthCheck{Interval: 20, UnhealthyThreshold: 10, GracePeriod: 120})
+	assert.Equal(20, probes["ReadinessPeriod"])
+	assert.Equal(20, probes["LivenessPeriod"])
+	assert.Equal(10, probes["FailureThreshold"])
+	assert.Equal(120, probes["LivenessDelay"])
+}
+
 func TestServiceApplyCommon_Update(t *testing.T) {
 	assert := assert.New(t)
 	stackManager := new(mockedStackManagerForUpsert)
@@ -99,6 +300,47 @@ func TestServiceApplyCommon_Update(t *testing.T) {
 	elbRuleLister.AssertExpectations(t)
 	elbRuleLister.AssertNumberOfCalls(t, "ListRules", 1)
 }
+func TestServiceApplyCommon_Ec2Database(t *testing.T) {
+	assert := assert.New(t)
+	stackManager := new(mockedStackManagerForUpsert)
+	stackManager.On("AwaitFinalStatus", "mu-service-myservice-dev").Return(nil).Once()
+	stackManager.On("AwaitFinalStatus", "mu-database-myservice-dev").Return(&common.Stack{Status: common.StackStatusCreateComplete, Outputs: map[string]string{"DatabaseName": "mydb"}}).Once()
+
+	paramManager := new(mockedParamManager)
+	paramManager.On("GetParam", "mu-database-myservice-dev-DatabaseMasterPassword").Return("dbpass", nil)
+
+	service := new(common.Service)
+	params := make(map[string]string)
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "myservice"
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Status: common.StackStatusCreateComplete, Tags: map[string]string{"provider": "ec2"}}
+	err := workflow.serviceApplyCommonParams("mu", service, params, "dev", stackManager, new(mockedElbManager), paramManager)()
+	assert.Nil(err)
+
+	assert.Equal("mydb", params["DatabaseName"])
+	assert.Equal("dbpass", params["DatabaseMasterPassword"])
+	// only declared by the ECS service template
+	assert.NotContains(params, "DatabaseMasterPasswordParam")
+
+	paramManager.AssertExpectations(t)
+}
+
+func TestServiceApplyEcsParams_DatabasePasswordEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	service := new(common.Service)
+	service.Environment = map[string]interface{}{
+		"DB_PASSWORD": "${DatabaseMasterPassword}",
+	}
+	params := make(map[string]string)
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "myservice"
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Status: common.StackStatusCreateComplete, Tags: map[string]string{"provider": "ecs"}}
+	workflow.lbStack = &common.Stack{Name: "mu-loadbalancer-dev", Status: common.StackStatusCreateComplete}
+	err := workflow.serviceApplyEcsParams(service, params, nil)()
+	assert.NotNil(err)
+}
+
 func TestServiceApplyCommon_StaticPriority(t *testing.T) {
 	assert := assert.New(t)
 	stackManager := new(mockedStackManagerForUpsert)
@@ -207,16 +449,21 @@ func (m *mockKubernetesResourceManager) UpsertResources(templateName string, tem
 }
 
 func (m *mockKubernetesResourceManager) ListResources(apiVersion string, kind string, namespace string) (*unstructured.UnstructuredList, error) {
-	args := m.Called(apiVersion)
+	args := m.Called(apiVersion, kind, namespace)
 	stack := args.Get(0)
 	if stack == nil {
-		return nil, args.Error(0)
+		return nil, args.Error(1)
 	}
-	return stack.(*unstructured.UnstructuredList), args.Error(0)
+	return stack.(*unstructured.UnstructuredList), args.Error(1)
 }
 
 func (m *mockKubernetesResourceManager) DeleteResource(apiVersion string, kind string, namespace string, name string) error {
-	args := m.Called(apiVersion)
+	args := m.Called(apiVersion, kind, namespace, name)
+	return args.Error(0)
+}
+
+func (m *mockKubernetesResourceManager) ExecPod(namespace string, podName string, containerName string, command []string) error {
+	args := m.Called(namespace, podName, containerName, command)
 	return args.Error(0)
 }
 
@@ -229,6 +476,7 @@ func TestServiceEksDeployer(t *testing.T) {
 	// from workflows/service_common_test.go
 	kubernetesResourceManager := new(mockKubernetesResourceManager)
 	kubernetesResourceManager.On("UpsertResources", "kubernetes/deployment.yml").Return(nil)
+	kubernetesResourceManager.On("ListResources", "autoscaling/v2beta1", "HorizontalPodAutoscaler", "mu-service-foo").Return(&unstructured.UnstructuredList{}, nil)
 
 	config := new(common.Config)
 	config.Service.Name = "foo"
@@ -249,6 +497,38 @@ func TestServiceEksDeployer(t *testing.T) {
 	kubernetesResourceManager.AssertNumberOfCalls(t, "UpsertResources", 1)
 }
 
+func TestServiceEksDeployer_StaleAutoscaling(t *testing.T) {
+	assert := assert.New(t)
+
+	autoscaler := unstructured.Unstructured{}
+	autoscaler.SetName("foo-autoscaler")
+	disruptionBudget := unstructured.Unstructured{}
+	disruptionBudget.SetName("foo-disruption-budget")
+
+	kubernetesResourceManager := new(mockKubernetesResourceManager)
+	kubernetesResourceManager.On("UpsertResources", "kubernetes/deployment.yml").Return(nil)
+	kubernetesResourceManager.On("ListResources", "autoscaling/v2beta1", "HorizontalPodAutoscaler", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{autoscaler}}, nil)
+	kubernetesResourceManager.On("ListResources", "policy/v1beta1", "PodDisruptionBudget", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{disruptionBudget}}, nil)
+	kubernetesResourceManager.On("DeleteResource", "autoscaling/v2beta1", "HorizontalPodAutoscaler", "mu-service-foo", "foo-autoscaler").Return(nil)
+	kubernetesResourceManager.On("DeleteResource", "policy/v1beta1", "PodDisruptionBudget", "mu-service-foo", "foo-disruption-budget").Return(nil)
+
+	// autoscaling was removed and a single pod is left, so neither is rendered anymore
+	service := new(common.Service)
+	service.DesiredCount = 1
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Outputs: map[string]string{}}
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+	err := workflow.serviceEksDeployer("mu", service, map[string]string{}, "dev")()
+	assert.Nil(err)
+
+	kubernetesResourceManager.AssertExpectations(t)
+	kubernetesResourceManager.AssertNumberOfCalls(t, "DeleteResource", 2)
+}
+
 func stringRef(v string) *string {
 	return &v
 }
@@ -274,3 +554,261 @@ func TestServiceDeployer_serviceRolesetUpserter(t *testing.T) {
 	rolesetManager.AssertNumberOfCalls(t, "UpsertServiceRoleset", 1)
 
 }
+
+func TestServiceResolveSidecars(t *testing.T) {
+	assert := assert.New(t)
+
+	service := new(common.Service)
+	service.Sidecars = []common.Sidecar{
+		{Name: "envoy", Dockerfile: "Dockerfile.envoy"},
+		{Name: "datadog", Image: "datadog/agent:latest", Memory: 256},
+	}
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceImage = "1234.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc"
+	workflow.resolveServiceSidecars(service)
+
+	assert.Equal("1234.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc-envoy", service.Sidecars[0].Image)
+	assert.Equal(defaultSidecarMemory, service.Sidecars[0].Memory)
+	assert.Equal("datadog/agent:latest", service.Sidecars[1].Image)
+	assert.Equal(256, service.Sidecars[1].Memory)
+}
+
+func TestServiceResolveCapacityProviderStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	service := new(common.Service)
+	service.CapacityProviderStrategy = []common.CapacityProviderStrategyItem{
+		{CapacityProvider: common.CapacityProviderFargate, Base: 1},
+		{CapacityProvider: common.CapacityProviderFargateSpot, Weight: 3},
+	}
+
+	workflow := new(serviceWorkflow)
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Tags: map[string]string{"provider": "ecs-fargate", "environment": "dev"}}
+
+	params := make(map[string]string)
+	assert.Nil(workflow.resolveCapacityProviderStrategy(service, params))
+	assert.NotContains(params, "CapacityProvider")
+
+	// fargate capacity providers aren't available on ec2 instances
+	workflow.envStack.Tags["provider"] = "ecs"
+	assert.NotNil(workflow.resolveCapacityProviderStrategy(service, params))
+
+	// the cluster needs a capacity provider
+	service.CapacityProviderStrategy = []common.CapacityProviderStrategyItem{{CapacityProvider: common.CapacityProviderEc2, Weight: 1}}
+	assert.NotNil(workflow.resolveCapacityProviderStrategy(service, params))
+
+	workflow.envStack.Outputs = map[string]string{"CapacityProvider": "mu-environment-dev-EcsCapacityProvider-ABC"}
+	assert.Nil(workflow.resolveCapacityProviderStrategy(service, params))
+	assert.Equal("mu-environment-dev-EcsCapacityProvider-ABC", params["CapacityProvider"])
+
+	service.CapacityProviderStrategy[0].Weight = 0
+	assert.NotNil(workflow.resolveCapacityProviderStrategy(service, params))
+}
+
+func TestServiceResolveCapacityProviderStrategy_Default(t *testing.T) {
+	assert := assert.New(t)
+
+	service := new(common.Service)
+	workflow := new(serviceWorkflow)
+	workflow.envStack = &common.Stack{Name: "mu-environment-dev", Tags: map[string]string{"provider": "ecs", "environment": "dev"}}
+
+	// without a capacity provider, services keep the launch type of the environment
+	params := make(map[string]string)
+	assert.Nil(workflow.resolveCapacityProviderStrategy(service, params))
+	assert.Empty(service.CapacityProviderStrategy)
+	assert.NotContains(params, "CapacityProvider")
+
+	// with one, services are placed through it so the cluster scales for them
+	workflow.envStack.Outputs = map[string]string{"CapacityProvider": "mu-environment-dev-EcsCapacityProvider-ABC"}
+	assert.Nil(workflow.resolveCapacityProviderStrategy(service, params))
+	assert.Equal([]common.CapacityProviderStrategyItem{{CapacityProvider: common.CapacityProviderEc2, Weight: 1}}, service.CapacityProviderStrategy)
+	assert.Equal("mu-environment-dev-EcsCapacityProvider-ABC", params["CapacityProvider"])
+}
+
+func TestKubernetesSidecars(t *testing.T) {
+	assert := assert.New(t)
+
+	containers := kubernetesSidecars([]common.Sidecar{
+		{
+			Name:   "envoy",
+			Image:  "envoyproxy/envoy",
+			CPU:    512,
+			Memory: 128,
+			HealthCheck: &common.ContainerHealthCheck{
+				Command: []string{"CMD-SHELL", "curl -f http://localhost:9901/ready"},
+			},
+		},
+	})
+
+	assert.Equal(1, len(containers))
+	assert.Equal("500m", containers[0]["CPU"])
+	assert.Equal("128Mi", containers[0]["Memory"])
+	healthCheck := containers[0]["HealthCheck"].(*common.ContainerHealthCheck)
+	assert.Equal([]string{"/bin/sh", "-c", "curl -f http://localhost:9901/ready"}, healthCheck.Command)
+}
+
+func TestServiceEksSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	kubernetesResourceManager := new(mockKubernetesResourceManager)
+	kubernetesResourceManager.On("UpsertResources", "kubernetes/secrets.yml").Return(nil)
+
+	paramManager := new(mockedParamManager)
+	paramManager.On("GetParam", "mu-database-foo-dev-DatabaseMasterPassword").Return("dbpass", nil)
+	paramManager.On("GetParam", "/aws/reference/secretsmanager/arn:aws:secretsmanager:us-west-2:123456789012:secret:api-AbCdEf").Return(`{"key":"apikey"}`, nil)
+
+	service := new(common.Service)
+	service.Secrets = map[string]string{
+		"DB_PASSWORD": "${DatabaseMasterPasswordParam}",
+		"API_KEY":     "arn:aws:secretsmanager:us-west-2:123456789012:secret:api-AbCdEf:key::",
+	}
+	params := map[string]string{
+		"DatabaseMasterPasswordParam": "mu-database-foo-dev-DatabaseMasterPassword",
+	}
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+	err := workflow.serviceEksSecrets(service, params, paramManager, "dev")()
+	assert.Nil(err)
+
+	paramManager.AssertExpectations(t)
+	kubernetesResourceManager.AssertNumberOfCalls(t, "UpsertResources", 1)
+}
+
+func TestServiceEksSecrets_UnknownReference(t *testing.T) {
+	assert := assert.New(t)
+
+	kubernetesResourceManager := new(mockKubernetesResourceManager)
+	paramManager := new(mockedParamManager)
+
+	service := new(common.Service)
+	service.Secrets = map[string]string{
+		"DB_PASSWORD": "${DatabaseMasterPasswordParam}",
+	}
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+	err := workflow.serviceEksSecrets(service, map[string]string{}, paramManager, "dev")()
+	assert.NotNil(err)
+
+	paramManager.AssertNotCalled(t, "GetParam", mock.Anything)
+	kubernetesResourceManager.AssertNotCalled(t, "UpsertResources", mock.Anything)
+}
+
+func TestGetSecretValue(t *testing.T) {
+	assert := assert.New(t)
+
+	secretArn := "arn:aws:secretsmanager:us-west-2:123456789012:secret:api-AbCdEf"
+	paramManager := new(mockedParamManager)
+	paramManager.On("GetParam", "/myapp/api-key").Return("plain", nil)
+	paramManager.On("GetParam", "/aws/reference/secretsmanager/"+secretArn).Return(`{"key":"apikey","port":5432}`, nil)
+
+	value, err := getSecretValue(paramManager, "/myapp/api-key")
+	assert.Nil(err)
+	assert.Equal("plain", value)
+
+	value, err = getSecretValue(paramManager, secretArn)
+	assert.Nil(err)
+	assert.Equal(`{"key":"apikey","port":5432}`, value)
+
+	value, err = getSecretValue(paramManager, secretArn+":key::")
+	assert.Nil(err)
+	assert.Equal("apikey", value)
+
+	value, err = getSecretValue(paramManager, secretArn+":port::")
+	assert.Nil(err)
+	assert.Equal("5432", value)
+
+	_, err = getSecretValue(paramManager, secretArn+":missing::")
+	assert.NotNil(err)
+}
+
+func TestServiceImageDigestResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	tagged := "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc"
+	pinned := "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo@sha256:1234"
+
+	repo := new(mockedImageRepository)
+	repo.On("ResolveImageDigest", tagged).Return(pinned, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceImage = tagged
+	err := workflow.serviceImageDigestResolver(new(common.Service), repo)()
+	assert.Nil(err)
+	assert.Equal(pinned, workflow.serviceImage)
+	assert.Equal(tagged, workflow.serviceImageTag())
+	assert.Equal(tagged+"-envoy", workflow.sidecarImage(common.Sidecar{Name: "envoy", Dockerfile: "Dockerfile.envoy"}))
+
+	// already pinned images aren't resolved again
+	err = workflow.serviceImageDigestResolver(new(common.Service), repo)()
+	assert.Nil(err)
+	repo.AssertNumberOfCalls(t, "ResolveImageDigest", 1)
+}
+
+func TestServiceImageDigestResolver_NotEcr(t *testing.T) {
+	assert := assert.New(t)
+
+	repo := new(mockedImageRepository)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceImage = "nginx:latest"
+	err := workflow.serviceImageDigestResolver(new(common.Service), repo)()
+	assert.Nil(err)
+	assert.Equal("nginx:latest", workflow.serviceImage)
+	assert.Equal("nginx:latest", workflow.serviceImageTag())
+	repo.AssertNotCalled(t, "ResolveImageDigest", mock.Anything)
+}
+
+func TestServiceRevisionKeyResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	artifactManager := new(mockedArtifactManager)
+	artifactManager.On("GetArtifact", "s3://bucket/foo/abc.revision", "").Return(ioutil.NopCloser(strings.NewReader("foo/1234.zip")), "etag", nil)
+	artifactManager.On("GetArtifact", "s3://bucket/foo/def.revision", "").Return(nil, "", errors.New("NoSuchKey"))
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.serviceTag = "abc"
+	workflow.appRevisionBucket = "bucket"
+	workflow.appRevisionKey = "foo/abc.zip"
+	err := workflow.serviceRevisionKeyResolver(artifactManager)()
+	assert.Nil(err)
+	assert.Equal("foo/1234.zip", workflow.appRevisionKey)
+
+	// archives pushed before content addressing keep their key
+	workflow.serviceTag = "def"
+	workflow.appRevisionKey = "foo/def.zip"
+	err = workflow.serviceRevisionKeyResolver(artifactManager)()
+	assert.Nil(err)
+	assert.Equal("foo/def.zip", workflow.appRevisionKey)
+
+	artifactManager.AssertExpectations(t)
+}
+
+func TestKubernetesReplicasAndResources(t *testing.T) {
+	assert := assert.New(t)
+
+	service := new(common.Service)
+	assert.Equal(3, kubernetesReplicas(service))
+	assert.Nil(kubernetesResources(service))
+	assert.Equal(map[string]int{"MaxUnavailable": 1}, kubernetesDisruptionBudget(service, nil))
+
+	service.DesiredCount = 1
+	service.CPU = 512
+	service.Memory = 1024
+	assert.Equal(1, kubernetesReplicas(service))
+	assert.Equal(map[string]string{"CPU": "500m", "Memory": "1024Mi"}, kubernetesResources(service))
+	assert.Nil(kubernetesDisruptionBudget(service, nil))
+
+	service.TargetCPUUtilization = 60
+	service.MinSize = 3
+	service.MaxSize = 6
+	autoscaling := kubernetesAutoscaling(service)
+	assert.Equal(3, autoscaling["MinReplicas"])
+	assert.Equal(6, autoscaling["MaxReplicas"])
+	assert.Equal(map[string]int{"MaxUnavailable": 1}, kubernetesDisruptionBudget(service, autoscaling))
+}
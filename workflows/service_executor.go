@@ -1,31 +1,51 @@
 package workflows
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // NewServiceExecutor create a new workflow for executing a command in an environment
-func NewServiceExecutor(ctx *common.Context, task common.Task) Executor {
+func NewServiceExecutor(ctx *common.Context, task common.Task, wait bool, timeout time.Duration, writer io.Writer) Executor {
+	ctx, err := ctx.ForEnvironment(task.Environment)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
-	workflow := new(environmentWorkflow)
+	workflow := new(serviceWorkflow)
 	if len(task.Service) == Zero {
 		task.Service = ctx.Config.Service.Name
 	}
+	workflow.serviceName = task.Service
 
 	return newPipelineExecutor(
-		workflow.serviceTaskExecutor(ctx.Config.Namespace, ctx.TaskManager, task),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, task.Environment, ctx.StackManager),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.serviceEksTaskExecutor(task, wait, timeout),
+			),
+			workflow.serviceTaskExecutor(ctx.Config.Namespace, ctx.TaskManager, ctx.LogsManager, task, wait, timeout, writer)),
 	)
 }
 
-func newServiceExecutor(namespace string, taskManager common.TaskManager, task common.Task) Executor {
-	workflow := new(environmentWorkflow)
+func newServiceExecutor(namespace string, taskManager common.TaskManager, logsViewer common.LogsViewer, task common.Task, wait bool, timeout time.Duration, writer io.Writer) Executor {
+	workflow := new(serviceWorkflow)
 
 	return newPipelineExecutor(
-		workflow.serviceTaskExecutor(namespace, taskManager, task),
+		workflow.serviceTaskExecutor(namespace, taskManager, logsViewer, task, wait, timeout, writer),
 	)
 }
 
-func (workflow *environmentWorkflow) serviceTaskExecutor(namespace string, taskManager common.TaskManager, task common.Task) Executor {
+func (workflow *serviceWorkflow) serviceTaskExecutor(namespace string, taskManager common.TaskManager, logsViewer common.LogsViewer,
+	task common.Task, wait bool, timeout time.Duration, writer io.Writer) Executor {
 	return func() error {
 		log.Notice(SvcCmdTaskExecutingLog)
 		result, err := taskManager.ExecuteCommand(namespace, task)
@@ -33,7 +53,230 @@ func (workflow *environmentWorkflow) serviceTaskExecutor(namespace string, taskM
 			log.Noticef(SvcCmdTaskErrorLog, err)
 			return err
 		}
-		log.Noticef(SvcCmdTaskResultLog, result)
-		return nil
+		if !wait {
+			log.Noticef(SvcCmdTaskResultLog, result)
+			return nil
+		}
+
+		if result == nil || len(result.Tasks) == 0 {
+			reasons := []string{}
+			if result != nil {
+				for _, failure := range result.Failures {
+					reasons = append(reasons, aws.StringValue(failure.Reason))
+				}
+			}
+			return fmt.Errorf("Unable to start task: %s", strings.Join(reasons, ", "))
+		}
+
+		logGroup := common.CreateStackName(namespace, common.StackTypeService, task.Service, task.Environment)
+		return waitForTaskRun(taskManager, logsViewer, writer, logGroup,
+			aws.StringValue(result.Tasks[0].ClusterArn), aws.StringValue(result.Tasks[0].TaskArn), timeout)
+	}
+}
+
+// waitForTaskRun polls a task until it stops or the timeout passes, streaming its log stream along the way
+func waitForTaskRun(taskDescriber common.TaskRunDescriber, logsViewer common.LogsViewer, writer io.Writer,
+	logGroup string, cluster string, taskArn string, timeout time.Duration) error {
+	log.Noticef(SvcCmdTaskWaitingLog, taskArn)
+
+	startTime := time.Now()
+	deadline := startTime.Add(timeout)
+	var lastTimestamp int64
+	streamLogs := func(logStream string) {
+		newestTimestamp := lastTimestamp
+		err := logsViewer.ViewLogs(logGroup, time.Since(startTime)+time.Minute, false, "", func(stream string, message string, timestamp int64) {
+			if stream != logStream || timestamp <= lastTimestamp {
+				return
+			}
+			if timestamp > newestTimestamp {
+				newestTimestamp = timestamp
+			}
+			fmt.Fprintf(writer, "%s\n", strings.TrimSpace(message))
+		})
+		if err != nil {
+			log.Debugf("Unable to read logs from '%s': %v", logGroup, err)
+		}
+		lastTimestamp = newestTimestamp
+	}
+
+	for {
+		status, err := taskDescriber.DescribeTaskRun(cluster, taskArn)
+		if err != nil {
+			return err
+		}
+		streamLogs(status.LogStream)
+
+		if status.Status == "STOPPED" {
+			if status.ExitCode == nil {
+				return fmt.Errorf("Task %s stopped without an exit code: %s", taskArn, status.StopReason)
+			}
+			exitCode := int(aws.Int64Value(status.ExitCode))
+			if exitCode != 0 {
+				return &common.TaskExitError{TaskName: taskArn, ExitCode: exitCode}
+			}
+			log.Noticef(SvcCmdTaskCompleteLog, taskArn)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for task %s to stop, it is still running", timeout, taskArn)
+		}
+		time.Sleep(time.Duration(PollDelay) * time.Second)
+	}
+}
+
+func (workflow *serviceWorkflow) serviceEksTaskExecutor(task common.Task, wait bool, timeout time.Duration) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+		deploymentName := fmt.Sprintf("%s-deployment", workflow.serviceName)
+		jobName := fmt.Sprintf("%s-exec-%d", workflow.serviceName, time.Now().Unix())
+
+		deployment, err := getKubernetesDeployment(workflow.kubernetesResourceManager, namespace, deploymentName)
+		if err != nil {
+			return err
+		}
+
+		podTemplate, err := kubernetesJobPodTemplate(deployment.Object, workflow.serviceName, jobName, task)
+		if err != nil {
+			return err
+		}
+
+		log.Notice(SvcCmdTaskExecutingLog)
+		err = workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sJob, map[string]interface{}{
+			"Namespace":   namespace,
+			"ServiceName": workflow.serviceName,
+			"JobName":     jobName,
+			"PodTemplate": podTemplate,
+		})
+		if err != nil {
+			log.Noticef(SvcCmdTaskErrorLog, err)
+			return err
+		}
+		log.Noticef("Created job %s, view its output with 'kubectl logs -n %s job/%s'", jobName, namespace, jobName)
+		if !wait {
+			return nil
+		}
+
+		log.Noticef(SvcCmdTaskWaitingLog, jobName)
+		deadline := time.Now().Add(timeout)
+		for {
+			jobs, err := workflow.kubernetesResourceManager.ListResources("batch/v1", "Job", namespace)
+			if err != nil {
+				return err
+			}
+			if jobs != nil {
+				for _, job := range jobs.Items {
+					if job.GetName() != jobName {
+						continue
+					}
+					succeeded, _, _ := unstructured.NestedInt64(job.Object, "status", "succeeded")
+					failed, _, _ := unstructured.NestedInt64(job.Object, "status", "failed")
+					if succeeded > 0 {
+						log.Noticef(SvcCmdTaskCompleteLog, jobName)
+						return nil
+					}
+					if failed > 0 {
+						return &common.TaskExitError{
+							TaskName: jobName,
+							ExitCode: getKubernetesJobExitCode(workflow.kubernetesResourceManager, namespace, jobName, workflow.serviceName),
+						}
+					}
+				}
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("Timed out after %s waiting for job %s to finish, it is still running", timeout, jobName)
+			}
+			time.Sleep(time.Duration(PollDelay) * time.Second)
+		}
+	}
+}
+
+// kubernetesJobPodTemplate builds the pod template for a job from the pod template of the service deployment
+func kubernetesJobPodTemplate(deployment map[string]interface{}, serviceName string, jobName string, task common.Task) (string, error) {
+	template, found, err := unstructured.NestedMap(deployment, "spec", "template")
+	if err != nil || !found {
+		return "", fmt.Errorf("Unable to find pod template for service %s", serviceName)
+	}
+
+	// keep the job pods out of the deployment's selector and service endpoints
+	unstructured.SetNestedStringMap(template, map[string]string{
+		"app.kubernetes.io/part-of": serviceName,
+		"mu/job":                    jobName,
+	}, "metadata", "labels")
+	unstructured.SetNestedField(template, "Never", "spec", "restartPolicy")
+
+	// sidecars never exit, so only the service container runs in the job or its pod would never complete
+	containers, _, _ := unstructured.NestedSlice(template, "spec", "containers")
+	jobContainers := []interface{}{}
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != serviceName {
+			continue
+		}
+		jobContainers = append(jobContainers, container)
+
+		command := []interface{}{}
+		for _, commandPart := range task.Command {
+			command = append(command, strings.TrimSpace(commandPart))
+		}
+		container["command"] = command
+		delete(container, "readinessProbe")
+		delete(container, "livenessProbe")
+
+		env, _, _ := unstructured.NestedSlice(container, "env")
+		for name, value := range task.EnvVars {
+			env = append(env, map[string]interface{}{"name": name, "value": value})
+		}
+		container["env"] = env
+
+		// cpu is in ECS cpu units, where 1024 units is one vCPU.  The requests of the service
+		// are replaced as well, kubernetes rejects a limit that is lower than the request
+		if task.Cpu > 0 {
+			cpu := fmt.Sprintf("%dm", task.Cpu*1000/1024)
+			unstructured.SetNestedField(container, cpu, "resources", "requests", "cpu")
+			unstructured.SetNestedField(container, cpu, "resources", "limits", "cpu")
+		}
+		if task.Memory > 0 {
+			memory := fmt.Sprintf("%dMi", task.Memory)
+			unstructured.SetNestedField(container, memory, "resources", "requests", "memory")
+			unstructured.SetNestedField(container, memory, "resources", "limits", "memory")
+		}
+	}
+	if len(jobContainers) == 0 {
+		return "", fmt.Errorf("Unable to find container %s in pod template for service %s", serviceName, serviceName)
+	}
+	unstructured.SetNestedSlice(template, jobContainers, "spec", "containers")
+
+	if task.TaskRoleArn != "" {
+		log.Warningf("Task role overrides are not supported on EKS, ignoring role '%s'", task.TaskRoleArn)
+	}
+
+	// JSON is valid YAML, so the template can be embedded directly in the job manifest
+	body, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func getKubernetesJobExitCode(resourceLister common.KubernetesResourceLister, namespace string, jobName string, containerName string) int {
+	pods, err := resourceLister.ListResources("v1", "Pod", namespace)
+	if err != nil || pods == nil {
+		return 1
+	}
+	for _, pod := range pods.Items {
+		if pod.GetLabels()["job-name"] != jobName {
+			continue
+		}
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+		for _, s := range statuses {
+			status, ok := s.(map[string]interface{})
+			if !ok || status["name"] != containerName {
+				continue
+			}
+			if exitCode, found, _ := unstructured.NestedInt64(status, "state", "terminated", "exitCode"); found {
+				return int(exitCode)
+			}
+		}
 	}
+	return 1
 }
@@ -2,10 +2,15 @@ package workflows
 
 import (
 	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"testing"
 )
 
 type mockedStackManager struct {
@@ -31,7 +36,7 @@ func (m *mockedTaskManager) ExecuteCommand(namespace string, task common.Task) (
 func TestNewServiceExecutorCreate(t *testing.T) {
 	assertion := assert.New(t)
 	ctx := common.NewContext()
-	executor := NewServiceExecutor(ctx, common.Task{})
+	executor := NewServiceExecutor(ctx, common.Task{}, false, time.Minute, ioutil.Discard)
 	assertion.NotNil(executor)
 }
 
@@ -46,7 +51,7 @@ func TestNewServiceExecutorFail(t *testing.T) {
 		Service:     TestSvc,
 		Command:     []string{TestCmd},
 	}
-	executor := newServiceExecutor("mu", taskManagerMock, task)
+	executor := newServiceExecutor("mu", taskManagerMock, nil, task, false, time.Minute, ioutil.Discard)
 	assertion.NotNil(executor)
 	assertion.NotNil(executor())
 }
@@ -62,10 +67,136 @@ func TestNewServiceExecutor(t *testing.T) {
 		Service:     TestSvc,
 		Command:     []string{TestCmd},
 	}
-	executor := newServiceExecutor("mu", taskManagerMock, task)
+	executor := newServiceExecutor("mu", taskManagerMock, nil, task, false, time.Minute, ioutil.Discard)
 	assertion.NotNil(executor)
 	assertion.Nil(executor())
 
 	taskManagerMock.AssertExpectations(t)
 	taskManagerMock.AssertNumberOfCalls(t, "ExecuteCommand", 1)
 }
+
+type mockedWaitTaskManager struct {
+	mock.Mock
+	common.TaskManager
+}
+
+func (m *mockedWaitTaskManager) ExecuteCommand(namespace string, task common.Task) (common.ECSRunTaskResult, error) {
+	args := m.Called()
+	return args.Get(0).(*ecs.RunTaskOutput), args.Error(1)
+}
+
+func (m *mockedWaitTaskManager) DescribeTaskRun(cluster string, taskArn string) (*common.TaskRunStatus, error) {
+	args := m.Called(cluster, taskArn)
+	return args.Get(0).(*common.TaskRunStatus), args.Error(1)
+}
+
+func TestServiceExecutorWait(t *testing.T) {
+	assertion := assert.New(t)
+	taskManagerMock := new(mockedWaitTaskManager)
+	taskManagerMock.On("ExecuteCommand").Return(&ecs.RunTaskOutput{
+		Tasks: []*ecs.Task{{ClusterArn: aws.String("cluster"), TaskArn: aws.String("task/abc")}},
+	}, nil)
+	taskManagerMock.On("DescribeTaskRun", "cluster", "task/abc").Return(&common.TaskRunStatus{
+		Status:    "STOPPED",
+		ExitCode:  aws.Int64(2),
+		LogStream: "container/foo/abc",
+	}, nil)
+
+	logsManager := new(mockedLogsManager)
+	logsManager.On("ViewLogs", "mu-service-foo-dev").Return(nil)
+
+	task := common.Task{
+		Environment: "dev",
+		Service:     "foo",
+		Command:     []string{TestCmd},
+	}
+	err := newServiceExecutor("mu", taskManagerMock, logsManager, task, true, time.Minute, ioutil.Discard)()
+	assertion.NotNil(err)
+
+	exitErr, ok := err.(*common.TaskExitError)
+	assertion.True(ok)
+	assertion.Equal(2, exitErr.ExitCode)
+
+	taskManagerMock.AssertExpectations(t)
+	logsManager.AssertExpectations(t)
+}
+
+func TestServiceExecutorWait_Timeout(t *testing.T) {
+	assertion := assert.New(t)
+	taskManagerMock := new(mockedWaitTaskManager)
+	taskManagerMock.On("ExecuteCommand").Return(&ecs.RunTaskOutput{
+		Tasks: []*ecs.Task{{ClusterArn: aws.String("cluster"), TaskArn: aws.String("task/abc")}},
+	}, nil)
+	taskManagerMock.On("DescribeTaskRun", "cluster", "task/abc").Return(&common.TaskRunStatus{
+		Status:    "RUNNING",
+		LogStream: "container/foo/abc",
+	}, nil)
+
+	logsManager := new(mockedLogsManager)
+	logsManager.On("ViewLogs", "mu-service-foo-dev").Return(nil)
+
+	task := common.Task{
+		Environment: "dev",
+		Service:     "foo",
+		Command:     []string{TestCmd},
+	}
+	err := newServiceExecutor("mu", taskManagerMock, logsManager, task, true, 0, ioutil.Discard)()
+	assertion.NotNil(err)
+
+	_, ok := err.(*common.TaskExitError)
+	assertion.False(ok)
+	taskManagerMock.AssertNumberOfCalls(t, "DescribeTaskRun", 1)
+}
+
+func TestKubernetesJobPodTemplate(t *testing.T) {
+	assertion := assert.New(t)
+
+	deployment := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app.kubernetes.io/name": "foo-deployment"},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":           "foo",
+							"image":          "foo:latest",
+							"readinessProbe": map[string]interface{}{},
+							"env":            []interface{}{map[string]interface{}{"name": "A", "value": "1"}},
+							"resources": map[string]interface{}{
+								"requests": map[string]interface{}{"cpu": "1000m", "memory": "1024Mi"},
+								"limits":   map[string]interface{}{"memory": "1024Mi"},
+							},
+						},
+						map[string]interface{}{
+							"name":  "envoy",
+							"image": "envoyproxy/envoy",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	podTemplate, err := kubernetesJobPodTemplate(deployment, "foo", "foo-exec-1", common.Task{
+		Command: []string{"rake", "db:migrate"},
+		Cpu:     512,
+		Memory:  256,
+		EnvVars: map[string]string{"B": "2"},
+	})
+	assertion.Nil(err)
+	assertion.Contains(podTemplate, `"restartPolicy":"Never"`)
+	assertion.Contains(podTemplate, `"mu/job":"foo-exec-1"`)
+	assertion.NotContains(podTemplate, "foo-deployment")
+	assertion.NotContains(podTemplate, "readinessProbe")
+	assertion.Contains(podTemplate, `"command":["rake","db:migrate"]`)
+	assertion.Contains(podTemplate, `{"name":"B","value":"2"}`)
+	assertion.Contains(podTemplate, `"limits":{"cpu":"500m","memory":"256Mi"}`)
+	assertion.Contains(podTemplate, `"requests":{"cpu":"500m","memory":"256Mi"}`)
+	assertion.NotContains(podTemplate, "1024Mi")
+	assertion.NotContains(podTemplate, "envoy")
+
+	_, err = kubernetesJobPodTemplate(map[string]interface{}{}, "foo", "foo-exec-1", common.Task{})
+	assertion.NotNil(err)
+}
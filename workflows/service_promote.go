@@ -0,0 +1,141 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewServicePromoter create a new workflow for deploying the artifacts of a service in one environment to another
+func NewServicePromoter(ctx *common.Context, fromEnvironmentName string, toEnvironmentName string, retag bool) Executor {
+	fromCtx, err := ctx.ForEnvironment(fromEnvironmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(serviceWorkflow)
+	workflow.codeRevision = ctx.Config.Repo.Revision
+	workflow.repoName = ctx.Config.Repo.Slug
+
+	return newPipelineExecutor(
+		workflow.serviceInput(ctx, ""),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, fromEnvironmentName, fromCtx.StackManager),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(fromCtx.KubernetesResourceManagerProvider),
+				workflow.serviceEksDeployedImageReader(fromEnvironmentName),
+			),
+			workflow.serviceDeployedArtifactReader(ctx.Config.Namespace, fromEnvironmentName, fromCtx.StackManager)),
+		workflow.servicePromotedImageResolver(&ctx.Config.Service, ctx.ClusterManager, ctx.ClusterManager, toEnvironmentName, retag),
+		workflow.serviceDeployPipeline(ctx, toEnvironmentName, ""),
+	)
+}
+
+// serviceDeployedArtifactReader reads the image (or revision key for the EC2 provider) from the service stack of an environment
+func (workflow *serviceWorkflow) serviceDeployedArtifactReader(namespace string, environmentName string, stackGetter common.StackGetter) Executor {
+	return func() error {
+		svcStackName := common.CreateStackName(namespace, common.StackTypeService, workflow.serviceName, environmentName)
+		stack, err := stackGetter.GetStack(svcStackName)
+		if err != nil {
+			return fmt.Errorf("Unable to find service '%s' in environment '%s': %v", workflow.serviceName, environmentName, err)
+		}
+
+		if workflow.isEc2Provider()() {
+			workflow.promotedRevisionKey = stack.Parameters["RevisionKey"]
+			if workflow.promotedRevisionKey == "" {
+				return fmt.Errorf("Unable to find the revision of service '%s' in stack '%s'", workflow.serviceName, svcStackName)
+			}
+			log.Noticef("Promoting revision '%s' of service '%s' from '%s'", workflow.promotedRevisionKey, workflow.serviceName, environmentName)
+			return nil
+		}
+
+		workflow.promotedImage = stack.Parameters["ImageUrl"]
+		if workflow.promotedImage == "" {
+			return fmt.Errorf("Unable to find the image of service '%s' in stack '%s'", workflow.serviceName, svcStackName)
+		}
+		// the image may already be pinned to a digest, keep the tag it was deployed from for the sidecars
+		if imageTag := stack.Parameters["ImageTag"]; !strings.Contains(imageTag, "@") {
+			workflow.serviceTaggedImage = imageTag
+		}
+		log.Noticef("Promoting image '%s' of service '%s' from '%s'", workflow.promotedImage, workflow.serviceName, environmentName)
+		return nil
+	}
+}
+
+// serviceEksDeployedImageReader reads the image from the service deployment of an EKS environment
+func (workflow *serviceWorkflow) serviceEksDeployedImageReader(environmentName string) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+		deployment, err := getKubernetesDeployment(workflow.kubernetesResourceManager, namespace, fmt.Sprintf("%s-deployment", workflow.serviceName))
+		if err != nil {
+			return err
+		}
+
+		containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if ok && container["name"] == workflow.serviceName {
+				workflow.promotedImage, _ = container["image"].(string)
+			}
+		}
+		if workflow.promotedImage == "" {
+			return fmt.Errorf("Unable to find the image of service '%s' in environment '%s'", workflow.serviceName, environmentName)
+		}
+		if imageTag := deployment.GetAnnotations()["mu/image-tag"]; !strings.Contains(imageTag, "@") {
+			workflow.serviceTaggedImage = imageTag
+		}
+		log.Noticef("Promoting image '%s' of service '%s' from '%s'", workflow.promotedImage, workflow.serviceName, environmentName)
+		return nil
+	}
+}
+
+// servicePromotedImageResolver pins the promoted image to its digest, so the target environment
+// runs exactly the bits of the source environment even if the tag is pushed again
+func (workflow *serviceWorkflow) servicePromotedImageResolver(service *common.Service, imageResolver common.RepositoryImageResolver,
+	imageTagger common.RepositoryImageTagger, environmentName string, retag bool) Executor {
+	return func() error {
+		if workflow.promotedImage == "" {
+			return nil
+		}
+
+		if common.IsPrivateRegistry(service.Registry) || !strings.Contains(workflow.promotedImage, ".dkr.ecr.") {
+			if retag {
+				log.Warningf("Only images in ECR can be tagged, skipping tag '%s'", environmentName)
+			}
+			if !strings.Contains(workflow.promotedImage, "@") {
+				log.Warningf("Image '%s' isn't in ECR and is promoted by tag rather than digest", workflow.promotedImage)
+			}
+			return nil
+		}
+
+		image, err := imageResolver.ResolveImageDigest(workflow.promotedImage)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Resolved image '%s' to '%s'", workflow.promotedImage, image)
+		if workflow.serviceTaggedImage == "" && !strings.Contains(workflow.promotedImage, "@") {
+			workflow.serviceTaggedImage = workflow.promotedImage
+		}
+		workflow.promotedImage = image
+
+		if retag {
+			return imageTagger.TagImage(workflow.promotedImage, environmentName)
+		}
+		return nil
+	}
+}
+
+// servicePromotedArtifactApplier replaces the artifacts resolved from the tag with the promoted ones
+func (workflow *serviceWorkflow) servicePromotedArtifactApplier() Executor {
+	return func() error {
+		if workflow.promotedImage != "" {
+			workflow.serviceImage = workflow.promotedImage
+		}
+		if workflow.promotedRevisionKey != "" {
+			workflow.appRevisionKey = workflow.promotedRevisionKey
+		}
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockedImageRepository struct {
+	mock.Mock
+	common.ClusterManager
+}
+
+func (m *mockedImageRepository) ResolveImageDigest(imageURL string) (string, error) {
+	args := m.Called(imageURL)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockedImageRepository) TagImage(imageURL string, tag string) error {
+	args := m.Called(imageURL, tag)
+	return args.Error(0)
+}
+
+func TestNewServicePromoter(t *testing.T) {
+	assert := assert.New(t)
+	ctx := common.NewContext()
+	promoter := NewServicePromoter(ctx, "acceptance", "production", false)
+	assert.NotNil(promoter)
+}
+
+func TestServiceDeployedArtifactReader(t *testing.T) {
+	assert := assert.New(t)
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("GetStack").Return(&common.Stack{Parameters: map[string]string{
+		"ImageUrl":    "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc",
+		"RevisionKey": "foo/abc.zip",
+	}}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.envStack = &common.Stack{Tags: map[string]string{"provider": "ecs"}}
+	err := workflow.serviceDeployedArtifactReader("mu", "acceptance", stackManager)()
+	assert.Nil(err)
+	assert.Equal("123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc", workflow.promotedImage)
+	assert.Equal("", workflow.promotedRevisionKey)
+
+	workflow = new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.envStack = &common.Stack{Tags: map[string]string{"provider": "ec2"}}
+	err = workflow.serviceDeployedArtifactReader("mu", "acceptance", stackManager)()
+	assert.Nil(err)
+	assert.Equal("", workflow.promotedImage)
+	assert.Equal("foo/abc.zip", workflow.promotedRevisionKey)
+}
+
+func TestServicePromotedImageResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	tagged := "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc"
+	pinned := "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo@sha256:1234"
+
+	repo := new(mockedImageRepository)
+	repo.On("ResolveImageDigest", tagged).Return(pinned, nil)
+	repo.On("TagImage", pinned, "production").Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.promotedImage = tagged
+	err := workflow.servicePromotedImageResolver(new(common.Service), repo, repo, "production", true)()
+	assert.Nil(err)
+	assert.Equal(pinned, workflow.promotedImage)
+
+	workflow.servicePromotedArtifactApplier()()
+	assert.Equal(pinned, workflow.serviceImage)
+	assert.Equal(tagged+"-envoy", workflow.sidecarImage(common.Sidecar{Name: "envoy", Dockerfile: "Dockerfile.envoy"}))
+
+	repo.AssertExpectations(t)
+}
+
+func TestServicePromoter_PinnedSource(t *testing.T) {
+	assert := assert.New(t)
+
+	tagged := "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo:abc"
+	pinned := "123456789012.dkr.ecr.us-east-1.amazonaws.com/mu-foo@sha256:1234"
+
+	// the source environment was itself promoted, so its image is already pinned
+	stackManager := new(mockedStackManager)
+	stackManager.On("GetStack").Return(&common.Stack{Parameters: map[string]string{
+		"ImageUrl": pinned,
+		"ImageTag": tagged,
+	}}, nil)
+
+	repo := new(mockedImageRepository)
+	repo.On("ResolveImageDigest", pinned).Return(pinned, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.envStack = &common.Stack{Tags: map[string]string{"provider": "ecs"}}
+	err := workflow.serviceDeployedArtifactReader("mu", "acceptance", stackManager)()
+	assert.Nil(err)
+	err = workflow.servicePromotedImageResolver(new(common.Service), repo, repo, "production", false)()
+	assert.Nil(err)
+
+	workflow.servicePromotedArtifactApplier()()
+	assert.Equal(pinned, workflow.serviceImage)
+	assert.Equal(tagged, workflow.serviceImageTag())
+	assert.Equal(tagged+"-envoy", workflow.sidecarImage(common.Sidecar{Name: "envoy", Dockerfile: "Dockerfile.envoy"}))
+
+	repo.AssertExpectations(t)
+}
@@ -2,29 +2,47 @@ package workflows
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/stelligent/mu/common"
 )
 
 // NewServicePusher create a new workflow for pushing a service to a repo
-func NewServicePusher(ctx *common.Context, tag string, provider string, kmsKey string, dockerWriter io.Writer) Executor {
+func NewServicePusher(ctx *common.Context, tag string, provider string, kmsKey string, buildOverrides common.ServiceBuild, dockerWriter io.Writer) Executor {
 
 	workflow := new(serviceWorkflow)
 
+	registryAuthenticator, err := common.NewRegistryAuthenticator(ctx.Config.Service.Registry, ctx.ClusterManager, ctx.ParamManager)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	// only ECR repos have their tags looked up for the build cache
+	var tagLister common.RepositoryTagLister = ctx.ClusterManager
+	if common.IsPrivateRegistry(ctx.Config.Service.Registry) {
+		tagLister = nil
+	}
+
 	return newPipelineExecutor(
 		workflow.serviceLoader(ctx, tag, provider),
 		newConditionalExecutor(workflow.isEcrProvider(),
 			newPipelineExecutor(
+				workflow.servicePullAccountsResolver(ctx),
 				workflow.serviceRepoUpserter(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
-				workflow.serviceRegistryAuthenticator(ctx.ClusterManager),
+				workflow.serviceRegistryAuthenticator(registryAuthenticator),
+				workflow.serviceBuildOptionsResolver(&ctx.Config, buildOverrides, tagLister),
 				workflow.serviceImageBuilder(ctx.DockerManager, &ctx.Config, dockerWriter),
 				workflow.serviceImagePusher(ctx.DockerManager, dockerWriter),
+				workflow.serviceSidecarImageBuilder(ctx.DockerManager, &ctx.Config, dockerWriter),
+				workflow.serviceSidecarImagePusher(ctx.DockerManager, &ctx.Config.Service, dockerWriter),
 			),
 			newPipelineExecutor(
 				workflow.serviceBucketUpserter(ctx.Config.Namespace, &ctx.Config.Service, ctx.StackManager, ctx.StackManager),
@@ -33,10 +51,76 @@ func NewServicePusher(ctx *common.Context, tag string, provider string, kmsKey s
 
 }
 
+// serviceBuildOptionsResolver merges the `service.build` config with the overrides from the command line
+func (workflow *serviceWorkflow) serviceBuildOptionsResolver(config *common.Config, buildOverrides common.ServiceBuild, tagLister common.RepositoryTagLister) Executor {
+	return func() error {
+		build := config.Service.Build
+
+		buildArgs := map[string]string{}
+		for key, value := range build.Args {
+			buildArgs[key] = common.ResolveEnvironmentVariables(value)
+		}
+		for key, value := range buildOverrides.Args {
+			buildArgs[key] = common.ResolveEnvironmentVariables(value)
+		}
+
+		// git metadata first so that explicit labels can replace it
+		labels := map[string]string{}
+		common.NewMapElementIfNotEmpty(labels, "org.opencontainers.image.revision", config.Repo.Revision)
+		common.NewMapElementIfNotEmpty(labels, "org.opencontainers.image.source", config.Repo.Slug)
+		common.NewMapElementIfNotEmpty(labels, "org.opencontainers.image.version", workflow.serviceTag)
+		common.NewMapElementIfNotEmpty(labels, "mu.branch", config.Repo.Branch)
+		for key, value := range build.Labels {
+			labels[key] = common.ResolveEnvironmentVariables(value)
+		}
+		for key, value := range buildOverrides.Labels {
+			labels[key] = common.ResolveEnvironmentVariables(value)
+		}
+
+		cacheFrom := build.CacheFrom
+		if len(buildOverrides.CacheFrom) > 0 {
+			cacheFrom = buildOverrides.CacheFrom
+		}
+		if len(cacheFrom) == 0 {
+			cacheFrom = workflow.previousServiceImages(tagLister)
+		}
+
+		workflow.buildOptions = common.DockerBuildOptions{
+			BuildArgs: buildArgs,
+			Target:    common.NewStringIfNotEmpty(build.Target, buildOverrides.Target),
+			CacheFrom: cacheFrom,
+			Labels:    labels,
+			Platform:  common.NewStringIfNotEmpty(build.Platform, buildOverrides.Platform),
+		}
+		log.Debugf("Resolved build options %+v", workflow.buildOptions)
+		return nil
+	}
+}
+
+// previousServiceImages returns the most recently pushed image of the service repo, other than the one being built
+func (workflow *serviceWorkflow) previousServiceImages(tagLister common.RepositoryTagLister) []string {
+	if tagLister == nil {
+		return nil
+	}
+	tags, err := tagLister.ListRepositoryTags(workflow.serviceImage)
+	if err != nil {
+		log.Debugf("Unable to list tags of '%s' for the build cache: %v", workflow.serviceImage, err)
+		return nil
+	}
+
+	repo := common.ImageRepository(workflow.serviceImage)
+	for _, tag := range tags {
+		if tag != workflow.serviceTag {
+			return []string{fmt.Sprintf("%s:%s", repo, tag)}
+		}
+	}
+	return nil
+}
+
 func (workflow *serviceWorkflow) serviceImageBuilder(imageBuilder common.DockerImageBuilder, config *common.Config, dockerWriter io.Writer) Executor {
 	return func() error {
 		log.Noticef("Building service:'%s' as image:%s'", workflow.serviceName, workflow.serviceImage)
-		return imageBuilder.ImageBuild(config.Basedir, workflow.serviceName, config.Service.Dockerfile, []string{workflow.serviceImage}, workflow.registryAuthConfig, dockerWriter)
+		return imageBuilder.ImageBuild(config.Basedir, workflow.serviceName, config.Service.Dockerfile, []string{workflow.serviceImage}, workflow.buildOptions, workflow.registryAuthConfig, dockerWriter)
 	}
 }
 
@@ -47,53 +131,161 @@ func (workflow *serviceWorkflow) serviceImagePusher(imagePusher common.DockerIma
 	}
 }
 
-func (workflow *serviceWorkflow) serviceArchiveUploader(basedir string, artifactCreator common.ArtifactCreator, kmsKey string) Executor {
+func (workflow *serviceWorkflow) serviceSidecarImageBuilder(imageBuilder common.DockerImageBuilder, config *common.Config, dockerWriter io.Writer) Executor {
 	return func() error {
-		destURL := fmt.Sprintf("s3://%s/%s", workflow.appRevisionBucket, workflow.appRevisionKey)
-		log.Noticef("Pushing archive '%s' to '%s'", basedir, destURL)
+		for _, sidecar := range config.Service.Sidecars {
+			if sidecar.Dockerfile == "" {
+				continue
+			}
+			sidecarImage := workflow.sidecarImage(sidecar)
+			log.Noticef("Building sidecar:'%s' as image:%s'", sidecar.Name, sidecarImage)
+			err := imageBuilder.ImageBuild(config.Basedir, workflow.serviceName, sidecar.Dockerfile, []string{sidecarImage}, common.DockerBuildOptions{
+				Labels:   workflow.buildOptions.Labels,
+				Platform: workflow.buildOptions.Platform,
+			}, workflow.registryAuthConfig, dockerWriter)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (workflow *serviceWorkflow) serviceSidecarImagePusher(imagePusher common.DockerImagePusher, service *common.Service, dockerWriter io.Writer) Executor {
+	return func() error {
+		for _, sidecar := range service.Sidecars {
+			if sidecar.Dockerfile == "" {
+				continue
+			}
+			sidecarImage := workflow.sidecarImage(sidecar)
+			log.Noticef("Pushing sidecar '%s' to '%s'", sidecar.Name, sidecarImage)
+			err := imagePusher.ImagePush(sidecarImage, workflow.registryAuth, dockerWriter)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (workflow *serviceWorkflow) serviceArchiveUploader(basedir string, artifactManager common.ArtifactManager, kmsKey string) Executor {
+	return func() error {
+		excludes, err := common.ReadIgnorePatterns(filepath.Join(basedir, MuIgnoreFile))
+		if err != nil {
+			return err
+		}
 
-		zipfile, err := zipDir(fmt.Sprintf("%s/", basedir))
+		zipfile, err := zipDir(fmt.Sprintf("%s/", basedir), excludes)
 		if err != nil {
 			return err
 		}
 		defer os.Remove(zipfile.Name()) // clean up
+		defer zipfile.Close()
 
-		err = artifactCreator.CreateArtifact(zipfile, destURL, kmsKey)
+		hash, err := hashFile(zipfile)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		// the revision key is addressed by content, so identical code is only uploaded and deployed once
+		workflow.appRevisionKey = fmt.Sprintf("%s/%s.zip", workflow.serviceName, hash)
+		destURL := fmt.Sprintf("s3://%s/%s", workflow.appRevisionBucket, workflow.appRevisionKey)
+
+		exists, err := artifactManager.ArtifactExists(destURL)
+		if err != nil {
+			return err
+		}
+		if exists {
+			log.Noticef("Archive of '%s' already exists at '%s', skipping upload", basedir, destURL)
+		} else {
+			log.Noticef("Pushing archive '%s' to '%s'", basedir, destURL)
+			// hashing left the offset at the end of the archive, and the upload starts from the current offset
+			if _, err := zipfile.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			err = artifactManager.CreateArtifact(zipfile, destURL, kmsKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		// `svc deploy` finds the revision key of the tag through this pointer
+		pointerURL := fmt.Sprintf("s3://%s/%s", workflow.appRevisionBucket, revisionPointerKey(workflow.serviceName, workflow.serviceTag))
+		log.Debugf("Pointing tag '%s' at '%s'", workflow.serviceTag, workflow.appRevisionKey)
+		return artifactManager.CreateArtifact(strings.NewReader(workflow.appRevisionKey), pointerURL, kmsKey)
+	}
+}
+
+// revisionPointerKey returns the key of the object that holds the revision key of a tag
+func revisionPointerKey(serviceName string, tag string) string {
+	return fmt.Sprintf("%s/%s.revision", serviceName, tag)
+}
+
+func hashFile(file *os.File) (string, error) {
+	if _, err := file.Seek(0, 0); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func zipDir(basedir string) (*os.File, error) {
+// zipModTime is the timestamp of every entry in a service archive, the earliest a zip can hold
+var zipModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// zipDir archives basedir, skipping the paths that match the excludes.  The archive is deterministic:
+// filepath.Walk visits entries in lexical order and every entry gets the same timestamp, so the same
+// content always produces the same bytes.
+func zipDir(basedir string, excludes []string) (*os.File, error) {
 	zipfile, err := ioutil.TempFile("", "artifact")
 	if err != nil {
 		return nil, err
 	}
 
 	archive := zip.NewWriter(zipfile)
-	defer archive.Close()
 
 	log.Debugf("Creating zipfile '%s' from basedir '%s'", zipfile.Name(), basedir)
 
-	filepath.Walk(basedir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	skipDirs := true
+	for _, pattern := range excludes {
+		if strings.HasPrefix(pattern, "!") {
+			// exceptions may include files from an excluded dir
+			skipDirs = false
 		}
+	}
 
-		header, err := zip.FileInfoHeader(info)
+	err = filepath.Walk(basedir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		header.Name = strings.TrimPrefix(path, basedir)
+		name := strings.TrimPrefix(path, basedir)
+		if name == "" {
+			return nil
+		}
 
-		if header.Name == "" {
+		ignored, err := common.IsIgnored(name, excludes)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			log.Debugf(" ..Ignoring '%s'", name)
+			if info.IsDir() && skipDirs {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(name)
+		header.SetModTime(zipModTime)
+
 		if info.IsDir() {
 			header.Name += "/"
 		} else {
@@ -118,6 +310,14 @@ func zipDir(basedir string) (*os.File, error) {
 		_, err = io.Copy(writer, file)
 		return err
 	})
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		zipfile.Close()
+		os.Remove(zipfile.Name())
+		return nil, err
+	}
 
-	return zipfile, err
+	return zipfile, nil
 }
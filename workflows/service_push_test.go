@@ -1,19 +1,27 @@
 package workflows
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"github.com/docker/docker/api/types"
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewServicePusher(t *testing.T) {
 	assert := assert.New(t)
 	ctx := common.NewContext()
-	upserter := NewServicePusher(ctx, "foo", "", "", os.Stdout)
+	upserter := NewServicePusher(ctx, "foo", "", "", common.ServiceBuild{}, os.Stdout)
 	assert.NotNil(upserter)
 }
 
@@ -22,7 +30,7 @@ type mockServiceBuilder struct {
 	common.DockerImageBuilder
 }
 
-func (m *mockServiceBuilder) ImageBuild(basedir string, serviceName string, dockerfile string, tags []string, registryAuthConfig map[string]types.AuthConfig, dockerWriter io.Writer) error {
+func (m *mockServiceBuilder) ImageBuild(basedir string, serviceName string, dockerfile string, tags []string, buildOptions common.DockerBuildOptions, registryAuthConfig map[string]types.AuthConfig, dockerWriter io.Writer) error {
 	args := m.Called()
 	return args.Error(0)
 }
@@ -67,3 +75,221 @@ func TestServicePusher(t *testing.T) {
 	pusher.AssertExpectations(t)
 	pusher.AssertNumberOfCalls(t, "ImagePush", 1)
 }
+
+func TestServiceSidecarBuilder(t *testing.T) {
+	assert := assert.New(t)
+
+	builder := new(mockServiceBuilder)
+	builder.On("ImageBuild").Return(nil)
+
+	config := new(common.Config)
+	config.Service.Sidecars = []common.Sidecar{
+		{Name: "envoy", Dockerfile: "Dockerfile.envoy"},
+		{Name: "datadog", Image: "datadog/agent:latest"},
+	}
+
+	workflow := new(serviceWorkflow)
+	err := workflow.serviceSidecarImageBuilder(builder, config, os.Stdout)()
+	assert.Nil(err)
+
+	builder.AssertExpectations(t)
+	builder.AssertNumberOfCalls(t, "ImageBuild", 1)
+}
+
+type mockRepositoryTagLister struct {
+	mock.Mock
+	common.RepositoryTagLister
+}
+
+func (m *mockRepositoryTagLister) ListRepositoryTags(repoURL string) ([]string, error) {
+	args := m.Called(repoURL)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestServiceBuildOptionsResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv("MU_TEST_BUILD_ARG", "bar")
+	defer os.Unsetenv("MU_TEST_BUILD_ARG")
+
+	tagLister := new(mockRepositoryTagLister)
+	tagLister.On("ListRepositoryTags", "registry:5000/foo:v2").Return([]string{"v2", "v1"}, nil)
+
+	config := new(common.Config)
+	config.Repo.Revision = "abc123"
+	config.Service.Build = common.ServiceBuild{
+		Args:     map[string]string{"FOO": "${env:MU_TEST_BUILD_ARG}", "BAZ": "config"},
+		Target:   "runtime",
+		Labels:   map[string]string{"team": "core"},
+		Platform: "linux/amd64",
+	}
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceImage = "registry:5000/foo:v2"
+	workflow.serviceTag = "v2"
+	err := workflow.serviceBuildOptionsResolver(config, common.ServiceBuild{
+		Args:     map[string]string{"BAZ": "flag"},
+		Platform: "linux/arm64",
+	}, tagLister)()
+	assert.Nil(err)
+
+	assert.Equal(map[string]string{"FOO": "bar", "BAZ": "flag"}, workflow.buildOptions.BuildArgs)
+	assert.Equal("runtime", workflow.buildOptions.Target)
+	assert.Equal("linux/arm64", workflow.buildOptions.Platform)
+	assert.Equal([]string{"registry:5000/foo:v1"}, workflow.buildOptions.CacheFrom)
+	assert.Equal("core", workflow.buildOptions.Labels["team"])
+	assert.Equal("abc123", workflow.buildOptions.Labels["org.opencontainers.image.revision"])
+
+	tagLister.AssertExpectations(t)
+}
+
+func writeTestFiles(t *testing.T, basedir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(basedir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestZipDir(t *testing.T) {
+	assert := assert.New(t)
+
+	basedir, err := ioutil.TempDir("", "mu-zip")
+	assert.Nil(err)
+	defer os.RemoveAll(basedir)
+
+	writeTestFiles(t, basedir, map[string]string{
+		"appspec.yml":   "version: 0.0",
+		"src/app.js":    "console.log('hi')",
+		".git/HEAD":     "ref: refs/heads/master",
+		"secrets.env":   "PASSWORD=secret",
+		"src/local.env": "DEBUG=true",
+	})
+	excludes := []string{".git", "*.env", "**/*.env"}
+
+	zipfile, err := zipDir(basedir+"/", excludes)
+	assert.Nil(err)
+	defer os.Remove(zipfile.Name())
+	hash1, err := hashFile(zipfile)
+	assert.Nil(err)
+	zipfile.Close()
+
+	reader, err := zip.OpenReader(zipfile.Name())
+	assert.Nil(err)
+	names := []string{}
+	for _, f := range reader.File {
+		names = append(names, f.Name)
+	}
+	reader.Close()
+	assert.Equal([]string{"appspec.yml", "src/", "src/app.js"}, names)
+
+	// touching files doesn't change the archive
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(basedir, "src/app.js"), later, later)
+
+	zipfile2, err := zipDir(basedir+"/", excludes)
+	assert.Nil(err)
+	defer os.Remove(zipfile2.Name())
+	hash2, err := hashFile(zipfile2)
+	assert.Nil(err)
+	zipfile2.Close()
+
+	assert.Equal(hash1, hash2)
+}
+
+func TestZipDir_MissingDir(t *testing.T) {
+	assert := assert.New(t)
+
+	zipfile, err := zipDir("/does/not/exist/", nil)
+	assert.NotNil(err)
+	assert.Nil(zipfile)
+}
+
+type mockedArtifactManager struct {
+	mock.Mock
+	common.ArtifactManager
+	uploads map[string][]byte
+}
+
+func (m *mockedArtifactManager) CreateArtifact(body io.ReadSeeker, destURI string, kmsKey string) error {
+	args := m.Called(destURI, kmsKey)
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if m.uploads == nil {
+		m.uploads = make(map[string][]byte)
+	}
+	m.uploads[destURI] = data
+	return args.Error(0)
+}
+
+func (m *mockedArtifactManager) ArtifactExists(uri string) (bool, error) {
+	args := m.Called(uri)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockedArtifactManager) GetArtifact(uri string, etag string) (io.ReadCloser, string, error) {
+	args := m.Called(uri, etag)
+	body, _ := args.Get(0).(io.ReadCloser)
+	return body, args.String(1), args.Error(2)
+}
+
+func TestServiceArchiveUploader(t *testing.T) {
+	assert := assert.New(t)
+
+	basedir, err := ioutil.TempDir("", "mu-archive")
+	assert.Nil(err)
+	defer os.RemoveAll(basedir)
+
+	writeTestFiles(t, basedir, map[string]string{
+		"appspec.yml": "version: 0.0",
+		".muignore":   ".git",
+		".git/HEAD":   "ref: refs/heads/master",
+	})
+
+	artifactManager := new(mockedArtifactManager)
+	artifactManager.On("ArtifactExists", mock.Anything).Return(false, nil).Once()
+	artifactManager.On("ArtifactExists", mock.Anything).Return(true, nil)
+	artifactManager.On("CreateArtifact", mock.Anything, "kms").Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.serviceTag = "abc"
+	workflow.appRevisionBucket = "bucket"
+
+	err = workflow.serviceArchiveUploader(basedir, artifactManager, "kms")()
+	assert.Nil(err)
+	revisionKey := workflow.appRevisionKey
+	assert.Regexp("^foo/[0-9a-f]{64}\\.zip$", revisionKey)
+	artifactManager.AssertCalled(t, "CreateArtifact", "s3://bucket/"+revisionKey, "kms")
+	artifactManager.AssertCalled(t, "CreateArtifact", "s3://bucket/foo/abc.revision", "kms")
+	assert.Equal(revisionKey, string(artifactManager.uploads["s3://bucket/foo/abc.revision"]))
+
+	// the archive is uploaded whole, and matches the hash in its key
+	archive := artifactManager.uploads["s3://bucket/"+revisionKey]
+	assert.NotEmpty(archive)
+	hash := sha256.Sum256(archive)
+	assert.Equal(fmt.Sprintf("foo/%s.zip", hex.EncodeToString(hash[:])), revisionKey)
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	assert.Nil(err)
+	if reader != nil {
+		names := []string{}
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		assert.Equal([]string{".muignore", "appspec.yml"}, names)
+	}
+
+	// the same content under another tag only updates the pointer
+	workflow.serviceTag = "def"
+	err = workflow.serviceArchiveUploader(basedir, artifactManager, "kms")()
+	assert.Nil(err)
+	assert.Equal(revisionKey, workflow.appRevisionKey)
+	artifactManager.AssertCalled(t, "CreateArtifact", "s3://bucket/foo/def.revision", "kms")
+	artifactManager.AssertNumberOfCalls(t, "CreateArtifact", 3)
+}
@@ -5,58 +5,206 @@ import (
 	"time"
 
 	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // NewServiceRestarter create a new workflow for a rolling restart
-func NewServiceRestarter(ctx *common.Context, environmentName string, serviceName string, batchSize int) Executor {
+func NewServiceRestarter(ctx *common.Context, environmentName string, serviceName string, batchSize int, maxUnhealthy int, timeout time.Duration, dryRun bool) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(serviceWorkflow)
 
 	return newPipelineExecutor(
 		workflow.serviceInput(ctx, serviceName),
-		workflow.serviceRestarter(ctx.Config.Namespace, ctx.TaskManager, environmentName, batchSize),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, ctx.StackManager),
+		newConditionalExecutor(workflow.isEcsProvider(),
+			workflow.serviceRestarter(ctx.Config.Namespace, ctx.TaskManager, ctx.StackManager, ctx.ElbManager, environmentName, batchSize, maxUnhealthy, timeout, dryRun),
+			nil),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.serviceEksRestarter(environmentName, timeout, dryRun),
+			), nil),
 	)
 }
 
-func (workflow *serviceWorkflow) serviceRestarter(namespace string, taskManager common.TaskManager, environmentName string, batchSize int) Executor {
+func (workflow *serviceWorkflow) serviceRestarter(namespace string, taskManager common.TaskManager, stackGetter common.StackGetter,
+	healthCounter common.ElbTargetHealthCounter, environmentName string, batchSize int, maxUnhealthy int, timeout time.Duration, dryRun bool) Executor {
 	return func() error {
 		tasks, err := taskManager.ListTasks(namespace, environmentName, workflow.serviceName)
+		if err != nil {
+			return err
+		}
 
 		log.Noticef("Found %v tasks for service %s in environment %s", len(tasks), workflow.serviceName, environmentName)
 
-		if err != nil {
-			return err
+		if batchSize < 1 {
+			batchSize = 1
 		}
 
-		for taskIdx, task := range tasks {
-			log.Noticef("Restarting task %s in environment %s", task.Name, environmentName)
-			stopErr := taskManager.StopTask(namespace, environmentName, task.Name)
-			if stopErr != nil {
-				fmt.Println(stopErr)
+		if dryRun {
+			for taskIdx, task := range tasks {
+				log.Noticef("DRYRUN: Would restart task %s in batch %d", task.Name, taskIdx/batchSize+1)
 			}
+			return nil
+		}
+
+		// wait for target group health when the service is behind the load balancer
+		targetGroupArn := ""
+		svcStackName := common.CreateStackName(namespace, common.StackTypeService, workflow.serviceName, environmentName)
+		if svcStack, err := stackGetter.GetStack(svcStackName); err == nil && svcStack != nil {
+			targetGroupArn = svcStack.Outputs["ElbTargetGroupArn"]
+		}
 
-			// Polling for same length task lists
-			if (taskIdx+1)%batchSize == 0 {
+		stopped := map[string]bool{}
+		restarted := 0
+		failed := 0
+		for start := 0; start < len(tasks); start += batchSize {
+			end := start + batchSize
+			if end > len(tasks) {
+				end = len(tasks)
+			}
 
-				for countRunningTasks(namespace, taskManager, environmentName, workflow.serviceName) != len(tasks) {
-					duration := time.Duration(PollDelay) * time.Second
-					time.Sleep(duration)
+			batchStopped := 0
+			for _, task := range tasks[start:end] {
+				log.Noticef("Restarting task %s in environment %s", task.Name, environmentName)
+				err := taskManager.StopTask(namespace, environmentName, task.Name)
+				if err != nil {
+					log.Warningf("Unable to stop task %s: %v", task.Name, err)
+					failed++
+				} else {
+					stopped[task.Name] = true
+					batchStopped++
 				}
 			}
+
+			// the tasks that failed to stop are already counted, only the stopped ones wait on their replacements
+			if waitForHealthyTasks(namespace, taskManager, healthCounter, targetGroupArn, environmentName, workflow.serviceName, len(tasks), stopped, timeout) {
+				restarted += batchStopped
+			} else {
+				log.Warningf("Replacement tasks for batch %d did not become healthy within %v", start/batchSize+1, timeout)
+				failed += batchStopped
+			}
+
+			if failed > maxUnhealthy {
+				return fmt.Errorf("Aborting restart of service %s after %d failed tasks (max unhealthy %d): restarted %d of %d tasks",
+					workflow.serviceName, failed, maxUnhealthy, restarted, len(tasks))
+			}
 		}
 
+		log.Noticef("Restarted %d of %d tasks for service %s in environment %s with %d failures", restarted, len(tasks), workflow.serviceName, environmentName, failed)
 		return nil
 	}
 }
 
-func countRunningTasks(namespace string, taskManager common.TaskManager, environmentName string, serviceName string) int {
+func waitForHealthyTasks(namespace string, taskManager common.TaskManager, healthCounter common.ElbTargetHealthCounter, targetGroupArn string,
+	environmentName string, serviceName string, desiredCount int, stopped map[string]bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		healthy := countRunningTasks(namespace, taskManager, environmentName, serviceName, stopped) >= desiredCount
+		if healthy && targetGroupArn != "" {
+			healthyTargets, err := healthCounter.CountHealthyTargets(targetGroupArn)
+			if err != nil {
+				log.Debugf("Unable to get target health for '%s': %v", targetGroupArn, err)
+			}
+			log.Debugf("Environment: %s, Service: %s, Healthy Targets: %v", environmentName, serviceName, healthyTargets)
+			healthy = healthyTargets >= desiredCount
+		}
+		if healthy {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Duration(PollDelay) * time.Second)
+	}
+}
+
+// countRunningTasks counts the running tasks of a service, ignoring tasks that
+// were stopped but have not yet drained
+func countRunningTasks(namespace string, taskManager common.TaskManager, environmentName string, serviceName string, stopped map[string]bool) int {
 	newTaskList, _ := taskManager.ListTasks(namespace, environmentName, serviceName)
 	runningCount := 0
 	for _, newTask := range newTaskList {
-		if newTask.Status == "RUNNING" {
+		if newTask.Status == "RUNNING" && !stopped[newTask.Name] {
 			runningCount++
 		}
 	}
 	log.Debugf("Environment: %s, Service: %s, Running Tasks: %v", environmentName, serviceName, runningCount)
 	return runningCount
 }
+
+func (workflow *serviceWorkflow) serviceEksRestarter(environmentName string, timeout time.Duration, dryRun bool) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+		deploymentName := fmt.Sprintf("%s-deployment", workflow.serviceName)
+
+		if dryRun {
+			log.Noticef("DRYRUN: Would perform a rollout restart of deployment %s in environment %s", deploymentName, environmentName)
+			return nil
+		}
+
+		if _, err := getKubernetesDeployment(workflow.kubernetesResourceManager, namespace, deploymentName); err != nil {
+			return err
+		}
+
+		log.Noticef("Restarting deployment %s in environment %s", deploymentName, environmentName)
+		err := workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sRestart, map[string]interface{}{
+			"Namespace":   namespace,
+			"ServiceName": workflow.serviceName,
+			"RestartedAt": time.Now().UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(timeout)
+		for {
+			deployment, err := getKubernetesDeployment(workflow.kubernetesResourceManager, namespace, deploymentName)
+			if err != nil {
+				return err
+			}
+			if isKubernetesRolloutComplete(deployment.Object) {
+				log.Noticef("Restarted deployment %s in environment %s", deploymentName, environmentName)
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("Deployment %s did not finish restarting within %v", deploymentName, timeout)
+			}
+			time.Sleep(time.Duration(PollDelay) * time.Second)
+		}
+	}
+}
+
+func getKubernetesDeployment(resourceLister common.KubernetesResourceLister, namespace string, name string) (*unstructured.Unstructured, error) {
+	deployments, err := resourceLister.ListResources("apps/v1beta2", "Deployment", namespace)
+	if err != nil {
+		return nil, err
+	}
+	if deployments != nil {
+		for idx := range deployments.Items {
+			if deployments.Items[idx].GetName() == name {
+				return &deployments.Items[idx], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("Unable to find deployment %s in namespace %s", name, namespace)
+}
+
+// isKubernetesRolloutComplete mirrors the checks of `kubectl rollout status`
+func isKubernetesRolloutComplete(deployment map[string]interface{}) bool {
+	generation, _, _ := unstructured.NestedInt64(deployment, "metadata", "generation")
+	observedGeneration, _, _ := unstructured.NestedInt64(deployment, "status", "observedGeneration")
+	replicas, _, _ := unstructured.NestedInt64(deployment, "spec", "replicas")
+	updatedReplicas, _, _ := unstructured.NestedInt64(deployment, "status", "updatedReplicas")
+	availableReplicas, _, _ := unstructured.NestedInt64(deployment, "status", "availableReplicas")
+	totalReplicas, _, _ := unstructured.NestedInt64(deployment, "status", "replicas")
+
+	return observedGeneration >= generation &&
+		updatedReplicas >= replicas &&
+		totalReplicas <= updatedReplicas &&
+		availableReplicas >= updatedReplicas
+}
@@ -1,15 +1,110 @@
 package workflows
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+type mockedRestartTaskManager struct {
+	mock.Mock
+	common.TaskManager
+}
+
+func (m *mockedRestartTaskManager) ListTasks(namespace string, environment string, serviceName string) ([]common.Task, error) {
+	args := m.Called(namespace, environment, serviceName)
+	return args.Get(0).([]common.Task), args.Error(1)
+}
+
+func (m *mockedRestartTaskManager) StopTask(namespace string, environment string, task string) error {
+	args := m.Called(namespace, environment, task)
+	return args.Error(0)
+}
+
 func TestNewServiceRestarter(t *testing.T) {
 	assert := assert.New(t)
 	ctx := common.NewContext()
-	restarter := NewServiceRestarter(ctx, "foo", "foo", 0)
+	restarter := NewServiceRestarter(ctx, "foo", "foo", 0, 1, time.Minute, false)
 	assert.NotNil(restarter)
 }
+
+func TestServiceRestarter_DryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	taskManager := new(mockedRestartTaskManager)
+	taskManager.On("ListTasks", "mu", "dev", "foo").Return([]common.Task{{Name: "t1", Status: "RUNNING"}, {Name: "t2", Status: "RUNNING"}}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceRestarter("mu", taskManager, nil, nil, "dev", 1, 1, time.Minute, true)()
+	assert.Nil(err)
+
+	taskManager.AssertExpectations(t)
+	taskManager.AssertNotCalled(t, "StopTask", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceRestarter_Abort(t *testing.T) {
+	assert := assert.New(t)
+
+	taskManager := new(mockedRestartTaskManager)
+	taskManager.On("ListTasks", "mu", "dev", "foo").Return([]common.Task{{Name: "t1", Status: "RUNNING"}, {Name: "t2", Status: "RUNNING"}, {Name: "t3", Status: "RUNNING"}}, nil)
+	taskManager.On("StopTask", "mu", "dev", mock.Anything).Return(errors.New("boom"))
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("GetStack").Return(&common.Stack{Outputs: map[string]string{}}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceRestarter("mu", taskManager, stackManager, nil, "dev", 1, 1, time.Millisecond, false)()
+	assert.NotNil(err)
+
+	// aborted after the second failed batch
+	taskManager.AssertNumberOfCalls(t, "StopTask", 2)
+}
+
+func TestServiceRestarter_FailedOnce(t *testing.T) {
+	assert := assert.New(t)
+
+	taskManager := new(mockedRestartTaskManager)
+	taskManager.On("ListTasks", "mu", "dev", "foo").Return([]common.Task{{Name: "t1", Status: "RUNNING"}, {Name: "t2", Status: "RUNNING"}, {Name: "t3", Status: "RUNNING"}}, nil)
+	taskManager.On("StopTask", "mu", "dev", "t1").Return(errors.New("boom"))
+	taskManager.On("StopTask", "mu", "dev", mock.Anything).Return(nil)
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("GetStack").Return(&common.Stack{Outputs: map[string]string{}}, nil)
+
+	// t1 fails to stop and the replacements of t2 and t3 never run, which is 3 failed tasks rather than 4
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceRestarter("mu", taskManager, stackManager, nil, "dev", 3, 3, 0, false)()
+	assert.Nil(err)
+
+	err = workflow.serviceRestarter("mu", taskManager, stackManager, nil, "dev", 3, 2, 0, false)()
+	assert.NotNil(err)
+}
+
+func TestIsKubernetesRolloutComplete(t *testing.T) {
+	assert := assert.New(t)
+
+	deployment := map[string]interface{}{
+		"metadata": map[string]interface{}{"generation": int64(2)},
+		"spec":     map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{
+			"observedGeneration": int64(2),
+			"replicas":           int64(3),
+			"updatedReplicas":    int64(2),
+			"availableReplicas":  int64(2),
+		},
+	}
+	assert.False(isKubernetesRolloutComplete(deployment))
+
+	deployment["status"].(map[string]interface{})["replicas"] = int64(2)
+	assert.True(isKubernetesRolloutComplete(deployment))
+
+	deployment["metadata"].(map[string]interface{})["generation"] = int64(3)
+	assert.False(isKubernetesRolloutComplete(deployment))
+}
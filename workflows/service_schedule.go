@@ -0,0 +1,298 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stelligent/mu/common"
+)
+
+func (workflow *serviceWorkflow) serviceCreateSchedules(namespace string, service *common.Service, environmentName string, stackWaiter common.StackWaiter, stackUpserter common.StackUpserter) Executor {
+	return func() error {
+		log.Noticef("Creating schedules for service '%s' to '%s'", workflow.serviceName, environmentName)
+		for _, schedule := range service.Schedule {
+			params := make(map[string]string)
+			templateName := common.TemplateSchedule
+
+			if workflow.isEc2Provider()() {
+				// EC2 schedules run the command on the instances of the service with SSM Run Command
+				templateName = common.TemplateScheduleEC2
+				params["ServiceStackName"] = common.CreateStackName(namespace, common.StackTypeService, workflow.serviceName, environmentName)
+				params["Ec2EventsRoleArn"] = workflow.ec2EventsRoleArn
+
+				commandBytes, err := json.Marshal([]string{shellJoin(schedule.Command)})
+				if err != nil {
+					return err
+				}
+				params["ScheduleCommand"] = string(commandBytes)
+			} else {
+				params["ServiceName"] = workflow.serviceName
+				params["EcsCluster"] = fmt.Sprintf("%s-EcsCluster", workflow.envStack.Name)
+				params["MicroserviceTaskDefinitionArn"] = workflow.microserviceTaskDefinitionArn
+				params["EcsEventsRoleArn"] = workflow.ecsEventsRoleArn
+
+				commandBytes, err := json.Marshal(schedule.Command)
+				if err != nil {
+					return err
+				}
+				params["ScheduleCommand"] = string(commandBytes)
+			}
+
+			// these parameters are specific to each of the defined schedules
+			expression, err := scheduleExpressionUTC(schedule)
+			if err != nil {
+				return err
+			}
+			params["ScheduleExpression"] = expression
+			params["ScheduleState"] = scheduleState(schedule.IsEnabled())
+
+			stackName := scheduleStackName(namespace, workflow.serviceName, schedule, environmentName)
+			resolveServiceEnvironment(service, environmentName)
+
+			tags := createTagMap(&ScheduleTags{
+				Service:     workflow.serviceName,
+				Schedule:    schedule.Name,
+				Environment: environmentName,
+				Type:        common.StackTypeSchedule,
+			})
+
+			err = stackUpserter.UpsertStack(stackName, templateName, service, params, tags, "", workflow.cloudFormationRoleArn)
+			if err != nil {
+				return err
+			}
+			log.Debugf("Waiting for stack '%s' to complete", stackName)
+			stack := stackWaiter.AwaitFinalStatus(stackName)
+			if stack == nil {
+				return fmt.Errorf("Unable to create stack %s", stackName)
+			}
+			if strings.HasSuffix(stack.Status, "ROLLBACK_COMPLETE") || !strings.HasSuffix(stack.Status, "_COMPLETE") {
+				return fmt.Errorf("Ended in failed status %s %s", stack.Status, stack.StatusReason)
+			}
+		}
+		return nil
+	}
+}
+
+// serviceScheduleTerminator deletes the schedule stacks of the service in an environment that aren't in
+// the schedules to keep.  Undeploying a service keeps no schedules.
+func (workflow *serviceWorkflow) serviceScheduleTerminator(namespace string, keep []common.Schedule, environmentName string,
+	stackLister common.StackLister, stackDeleter common.StackDeleter, stackWaiter common.StackWaiter) Executor {
+	return func() error {
+		keepStackNames := make(map[string]bool)
+		for _, schedule := range keep {
+			keepStackNames[scheduleStackName(namespace, workflow.serviceName, schedule, environmentName)] = true
+		}
+
+		stacks, err := stackLister.ListStacks(common.StackTypeSchedule, namespace)
+		if err != nil {
+			return err
+		}
+		for _, stack := range stacks {
+			if stack.Tags[SvcTagKey] != workflow.serviceName || stack.Tags[EnvTagKey] != environmentName || keepStackNames[stack.Name] {
+				continue
+			}
+
+			log.Noticef("Deleting schedule stack '%s'", stack.Name)
+			err := stackDeleter.DeleteStack(stack.Name)
+			if err != nil {
+				return err
+			}
+			scheduleStack := stackWaiter.AwaitFinalStatus(stack.Name)
+			if scheduleStack != nil && !strings.HasSuffix(scheduleStack.Status, "_COMPLETE") {
+				return fmt.Errorf("Ended in failed status %s %s", scheduleStack.Status, scheduleStack.StatusReason)
+			}
+		}
+		return nil
+	}
+}
+
+// serviceEksCreateSchedules upserts a CronJob for each schedule, built from the pod template of the
+// service deployment, and deletes the CronJobs of schedules that were removed
+func (workflow *serviceWorkflow) serviceEksCreateSchedules(service *common.Service, environmentName string) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+
+		keepCronJobs := make(map[string]bool)
+		if len(service.Schedule) > 0 {
+			log.Noticef("Creating schedules for service '%s' to '%s'", workflow.serviceName, environmentName)
+
+			deployment, err := getKubernetesDeployment(workflow.kubernetesResourceManager, namespace, fmt.Sprintf("%s-deployment", workflow.serviceName))
+			if err != nil {
+				return err
+			}
+
+			for _, schedule := range service.Schedule {
+				cronJobName := scheduleCronJobName(workflow.serviceName, schedule.Name)
+				expression, err := scheduleExpressionUTC(schedule)
+				if err != nil {
+					return err
+				}
+				cronSchedule, err := kubernetesCronSchedule(expression)
+				if err != nil {
+					return fmt.Errorf("Unable to convert schedule '%s': %v", schedule.Name, err)
+				}
+
+				podTemplate, err := kubernetesJobPodTemplate(deployment.Object, workflow.serviceName, cronJobName, common.Task{Command: schedule.Command})
+				if err != nil {
+					return err
+				}
+
+				err = workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sCronJob, map[string]interface{}{
+					"Namespace":    namespace,
+					"ServiceName":  workflow.serviceName,
+					"ScheduleName": schedule.Name,
+					"CronJobName":  cronJobName,
+					"Expression":   expression,
+					"Schedule":     cronSchedule,
+					"Suspend":      !schedule.IsEnabled(),
+					"PodTemplate":  podTemplate,
+					"Revision":     workflow.codeRevision,
+					"MuVersion":    common.GetVersion(),
+				})
+				if err != nil {
+					return err
+				}
+				keepCronJobs[cronJobName] = true
+			}
+		}
+
+		cronJobs, err := workflow.kubernetesResourceManager.ListResources("batch/v1beta1", "CronJob", namespace)
+		if err != nil {
+			return err
+		}
+		if cronJobs == nil {
+			return nil
+		}
+		for _, cronJob := range cronJobs.Items {
+			if cronJob.GetAnnotations()["mu/type"] != "schedule" || keepCronJobs[cronJob.GetName()] {
+				continue
+			}
+			log.Noticef("Deleting schedule '%s'", cronJob.GetName())
+			err := workflow.kubernetesResourceManager.DeleteResource("batch/v1beta1", "CronJob", namespace, cronJob.GetName())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func scheduleStackName(namespace string, serviceName string, schedule common.Schedule, environmentName string) string {
+	return common.CreateStackName(namespace, common.StackTypeSchedule, serviceName+"-"+strings.ToLower(schedule.Name), environmentName)
+}
+
+func scheduleCronJobName(serviceName string, scheduleName string) string {
+	return fmt.Sprintf("%s-%s", serviceName, strings.ToLower(scheduleName))
+}
+
+func scheduleState(enabled bool) string {
+	if enabled {
+		return "ENABLED"
+	}
+	return "DISABLED"
+}
+
+// scheduleExpressionUTC converts the expression of a schedule with a timezone to UTC, which is what
+// both CloudWatch Events and kubernetes evaluate cron expressions in
+func scheduleExpressionUTC(schedule common.Schedule) (string, error) {
+	expression, err := common.ScheduleExpressionUTC(schedule.Expression, schedule.Timezone, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("Unable to convert schedule '%s': %v", schedule.Name, err)
+	}
+	if expression != strings.TrimSpace(schedule.Expression) {
+		log.Warningf("Schedule '%s' runs at '%s' UTC, redeploy the service when '%s' changes to or from daylight saving time", schedule.Name, expression, schedule.Timezone)
+	}
+	return expression, nil
+}
+
+var (
+	cronDayOfWeekNumber = regexp.MustCompile(`[0-9]+`)
+	cronLastDayOfWeek   = regexp.MustCompile(`[0-9]L`)
+)
+
+// kubernetesCronSchedule converts a CloudWatch schedule expression, like `cron(0 12 * * ? *)` or
+// `rate(5 minutes)`, into the cron syntax of a kubernetes CronJob
+func kubernetesCronSchedule(expression string) (string, error) {
+	expression = strings.TrimSpace(expression)
+
+	if strings.HasPrefix(expression, "rate(") && strings.HasSuffix(expression, ")") {
+		fields := strings.Fields(expression[len("rate(") : len(expression)-1])
+		if len(fields) != 2 {
+			return "", fmt.Errorf("invalid rate expression '%s'", expression)
+		}
+		value, err := strconv.Atoi(fields[0])
+		if err != nil || value < 1 {
+			return "", fmt.Errorf("invalid rate expression '%s'", expression)
+		}
+		// a step restarts at the top of each hour, day or month, so only rates that
+		// divide the next unit evenly keep firing at the same interval
+		unit := strings.TrimSuffix(fields[1], "s")
+		if unit == "minute" && value%60 == 0 {
+			unit, value = "hour", value/60
+		}
+		if unit == "hour" && value%24 == 0 {
+			unit, value = "day", value/24
+		}
+		switch {
+		case unit == "minute" && 60%value == 0:
+			return fmt.Sprintf("*/%d * * * *", value), nil
+		case unit == "hour" && 24%value == 0:
+			return fmt.Sprintf("0 */%d * * *", value), nil
+		case unit == "day" && value == 1:
+			return "0 0 * * *", nil
+		case unit == "minute", unit == "hour", unit == "day":
+			return "", fmt.Errorf("rate expression '%s' can't be converted to kubernetes, use a rate that divides an hour or a day, or a cron expression", expression)
+		}
+		return "", fmt.Errorf("invalid rate expression '%s'", expression)
+	}
+
+	if strings.HasPrefix(expression, "cron(") && strings.HasSuffix(expression, ")") {
+		fields := strings.Fields(expression[len("cron(") : len(expression)-1])
+		if len(fields) != 6 {
+			return "", fmt.Errorf("invalid cron expression '%s'", expression)
+		}
+		if fields[5] != "*" {
+			return "", fmt.Errorf("years aren't supported by kubernetes in cron expression '%s'", expression)
+		}
+		dayOfMonth, dayOfWeek := fields[2], fields[4]
+		if strings.ContainsAny(dayOfMonth, "LW") || strings.Contains(dayOfWeek, "#") || dayOfWeek == "L" || cronLastDayOfWeek.MatchString(dayOfWeek) {
+			return "", fmt.Errorf("L, W and # aren't supported by kubernetes in cron expression '%s'", expression)
+		}
+		if dayOfMonth == "?" {
+			fields[2] = "*"
+		}
+		if dayOfWeek == "?" {
+			fields[4] = "*"
+		} else {
+			// CloudWatch numbers the days of the week 1-7 from Sunday, kubernetes 0-6
+			parts := strings.SplitN(dayOfWeek, "/", 2)
+			parts[0] = cronDayOfWeekNumber.ReplaceAllStringFunc(parts[0], func(day string) string {
+				n, _ := strconv.Atoi(day)
+				return strconv.Itoa(n - 1)
+			})
+			fields[4] = strings.Join(parts, "/")
+		}
+		return strings.Join(fields[:5], " "), nil
+	}
+
+	return "", fmt.Errorf("invalid schedule expression '%s'", expression)
+}
+
+var shellSafeArgument = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellJoin joins the parts of a command into a shell command line, quoting the parts that need it
+func shellJoin(command []string) string {
+	parts := make([]string, len(command))
+	for idx, part := range command {
+		if shellSafeArgument.MatchString(part) {
+			parts[idx] = part
+		} else {
+			parts[idx] = fmt.Sprintf("'%s'", strings.Replace(part, "'", `'"'"'`, -1))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,199 @@
+package workflows
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetesCronSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := map[string]string{
+		"rate(1 minute)":          "*/1 * * * *",
+		"rate(5 minutes)":         "*/5 * * * *",
+		"rate(2 hours)":           "0 */2 * * *",
+		"rate(1 day)":             "0 0 * * *",
+		"rate(20 minutes)":        "*/20 * * * *",
+		"rate(120 minutes)":       "0 */2 * * *",
+		"rate(24 hours)":          "0 0 * * *",
+		"rate(1440 minutes)":      "0 0 * * *",
+		"cron(0 12 * * ? *)":      "0 12 * * *",
+		"cron(15 10 ? * 2-6 *)":   "15 10 * * 1-5",
+		"cron(0 8 ? * MON,FRI *)": "0 8 * * MON,FRI",
+		"cron(0 0 1 * ? *)":       "0 0 1 * *",
+		"cron(0/10 * ? * 1/2 *)":  "0/10 * * * 0/2",
+	}
+	for expression, expected := range valid {
+		schedule, err := kubernetesCronSchedule(expression)
+		assert.Nil(err, expression)
+		assert.Equal(expected, schedule, expression)
+	}
+
+	invalid := []string{
+		"rate(5 weeks)",
+		"rate(five minutes)",
+		"rate(90 minutes)",
+		"rate(7 minutes)",
+		"rate(5 hours)",
+		"rate(48 hours)",
+		"rate(2 days)",
+		"cron(0 12 * * ? 2020)",
+		"cron(0 12 L * ? *)",
+		"cron(0 12 ? * 6#3 *)",
+		"cron(0 12 * *)",
+		"every 5 minutes",
+	}
+	for _, expression := range invalid {
+		_, err := kubernetesCronSchedule(expression)
+		assert.NotNil(err, expression)
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("bin/cleanup --days=7", shellJoin([]string{"bin/cleanup", "--days=7"}))
+	assert.Equal("echo 'hello world' 'it'\"'\"'s'", shellJoin([]string{"echo", "hello world", "it's"}))
+}
+
+func TestServiceScheduleTerminator(t *testing.T) {
+	assert := assert.New(t)
+
+	stackLister := new(mockedStackListerForScaling)
+	stackLister.On("ListStacks", common.StackTypeSchedule, "mu").Return([]*common.Stack{
+		{Name: "mu-schedule-foo-nightly-dev", Tags: map[string]string{"service": "foo", "environment": "dev"}},
+		{Name: "mu-schedule-foo-hourly-dev", Tags: map[string]string{"service": "foo", "environment": "dev"}},
+		{Name: "mu-schedule-foo-hourly-prod", Tags: map[string]string{"service": "foo", "environment": "prod"}},
+		{Name: "mu-schedule-bar-hourly-dev", Tags: map[string]string{"service": "bar", "environment": "dev"}},
+	}, nil)
+
+	stackManager := new(mockedStackManagerForService)
+	stackManager.On("DeleteStack", "mu-schedule-foo-hourly-dev").Return(nil)
+	stackManager.On("AwaitFinalStatus", "mu-schedule-foo-hourly-dev").Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceScheduleTerminator("mu", []common.Schedule{{Name: "Nightly"}}, "dev", stackLister, stackManager, stackManager)()
+	assert.Nil(err)
+
+	stackManager.AssertExpectations(t)
+	stackManager.AssertNumberOfCalls(t, "DeleteStack", 1)
+}
+
+type mockedScheduleKubernetesResourceManager struct {
+	mock.Mock
+	common.KubernetesResourceManager
+}
+
+func (m *mockedScheduleKubernetesResourceManager) UpsertResources(templateName string, templateData interface{}) error {
+	args := m.Called(templateName, templateData)
+	return args.Error(0)
+}
+
+func (m *mockedScheduleKubernetesResourceManager) ListResources(apiVersion string, kind string, namespace string) (*unstructured.UnstructuredList, error) {
+	args := m.Called(apiVersion, kind, namespace)
+	return args.Get(0).(*unstructured.UnstructuredList), args.Error(1)
+}
+
+func (m *mockedScheduleKubernetesResourceManager) DeleteResource(apiVersion string, kind string, namespace string, name string) error {
+	args := m.Called(apiVersion, kind, namespace, name)
+	return args.Error(0)
+}
+
+func TestServiceEksCreateSchedules(t *testing.T) {
+	assert := assert.New(t)
+
+	deployment := unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "foo-deployment"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "foo", "image": "foo:abc"},
+					},
+				},
+			},
+		},
+	}}
+	newCronJob := func(name string, muType string) unstructured.Unstructured {
+		cronJob := unstructured.Unstructured{Object: map[string]interface{}{}}
+		cronJob.SetName(name)
+		cronJob.SetAnnotations(map[string]string{"mu/type": muType})
+		return cronJob
+	}
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "apps/v1beta2", "Deployment", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{deployment}}, nil)
+	kubernetesResourceManager.On("UpsertResources", common.TemplateK8sCronJob, mock.MatchedBy(func(data map[string]interface{}) bool {
+		return data["CronJobName"] == "foo-nightly" && data["Schedule"] == "0 2 * * *"
+	})).Return(nil)
+	kubernetesResourceManager.On("ListResources", "batch/v1beta1", "CronJob", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+			newCronJob("foo-nightly", "schedule"),
+			newCronJob("foo-hourly", "schedule"),
+			newCronJob("other", ""),
+		}}, nil)
+	kubernetesResourceManager.On("DeleteResource", "batch/v1beta1", "CronJob", "mu-service-foo", "foo-hourly").Return(nil)
+
+	service := new(common.Service)
+	service.Schedule = []common.Schedule{{Name: "Nightly", Expression: "cron(0 2 * * ? *)", Command: []string{"bin/cleanup"}}}
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+	err := workflow.serviceEksCreateSchedules(service, "dev")()
+	assert.Nil(err)
+
+	kubernetesResourceManager.AssertExpectations(t)
+	kubernetesResourceManager.AssertNumberOfCalls(t, "DeleteResource", 1)
+}
+
+func TestServiceEksCreateSchedules_Sidecars(t *testing.T) {
+	assert := assert.New(t)
+
+	deployment := unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "foo-deployment"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "foo", "image": "foo:abc"},
+						map[string]interface{}{"name": "envoy", "image": "envoyproxy/envoy"},
+					},
+				},
+			},
+		},
+	}}
+
+	var podTemplate string
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "apps/v1beta2", "Deployment", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{deployment}}, nil)
+	kubernetesResourceManager.On("UpsertResources", common.TemplateK8sCronJob, mock.MatchedBy(func(data map[string]interface{}) bool {
+		podTemplate, _ = data["PodTemplate"].(string)
+		return true
+	})).Return(nil)
+	kubernetesResourceManager.On("ListResources", "batch/v1beta1", "CronJob", "mu-service-foo").Return(&unstructured.UnstructuredList{}, nil)
+
+	service := new(common.Service)
+	service.Schedule = []common.Schedule{{Name: "Nightly", Expression: "cron(0 2 * * ? *)", Command: []string{"bin/cleanup"}}}
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+	err := workflow.serviceEksCreateSchedules(service, "dev")()
+	assert.Nil(err)
+
+	// a sidecar would keep the job pod running, and with concurrencyPolicy: Forbid block every later run
+	template := make(map[string]interface{})
+	assert.Nil(json.Unmarshal([]byte(podTemplate), &template))
+	containers, _, _ := unstructured.NestedSlice(template, "spec", "containers")
+	assert.Equal(1, len(containers))
+	assert.Equal("foo", containers[0].(map[string]interface{})["name"])
+}
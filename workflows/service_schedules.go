@@ -0,0 +1,265 @@
+package workflows
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewServiceSchedulesViewer create a new workflow for listing the schedules of a service in an environment
+func NewServiceSchedulesViewer(ctx *common.Context, environmentName string, writer io.Writer) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(serviceWorkflow)
+
+	return newPipelineExecutor(
+		workflow.serviceInput(ctx, ""),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, ctx.StackManager),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.serviceEksSchedulesViewer(writer),
+			),
+			workflow.serviceSchedulesViewer(ctx.Config.Namespace, environmentName, ctx.StackManager, ctx.ScheduleManager, writer)),
+	)
+}
+
+// NewServiceScheduleRunner create a new workflow for running the command of a schedule now
+func NewServiceScheduleRunner(ctx *common.Context, environmentName string, scheduleName string, wait bool, timeout time.Duration, writer io.Writer) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(serviceWorkflow)
+
+	schedule := findSchedule(ctx.Config.Service.Schedule, scheduleName)
+	if schedule == nil {
+		return newErrorExecutor(fmt.Errorf("Unable to find schedule '%s' in service config", scheduleName))
+	}
+	task := common.Task{
+		Environment: environmentName,
+		Service:     ctx.Config.Service.Name,
+		Command:     schedule.Command,
+	}
+
+	return newPipelineExecutor(
+		workflow.serviceInput(ctx, ""),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, ctx.StackManager),
+		newConditionalExecutor(workflow.isEc2Provider(),
+			newErrorExecutor(fmt.Errorf("Running schedule '%s' now isn't supported for EC2 services", schedule.Name)),
+			nil),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.serviceEksTaskExecutor(task, wait, timeout),
+			),
+			workflow.serviceTaskExecutor(ctx.Config.Namespace, ctx.TaskManager, ctx.LogsManager, task, wait, timeout, writer)),
+	)
+}
+
+// NewServiceScheduleStateUpdater create a new workflow for enabling or disabling a schedule until the next deploy
+func NewServiceScheduleStateUpdater(ctx *common.Context, environmentName string, scheduleName string, enabled bool) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(serviceWorkflow)
+
+	return newPipelineExecutor(
+		workflow.serviceInput(ctx, ""),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, ctx.StackManager),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.serviceEksScheduleStateUpdater(scheduleName, enabled),
+			),
+			workflow.serviceScheduleStateUpdater(ctx.Config.Namespace, environmentName, scheduleName, enabled, ctx.StackManager, ctx.ScheduleManager)),
+	)
+}
+
+func (workflow *serviceWorkflow) serviceSchedulesViewer(namespace string, environmentName string, stackLister common.StackLister,
+	scheduleStateGetter common.ScheduleStateGetter, writer io.Writer) Executor {
+	return func() error {
+		stacks, err := stackLister.ListStacks(common.StackTypeSchedule, namespace)
+		if err != nil {
+			return err
+		}
+
+		table := CreateTableSection(writer, SvcScheduleTableHeader)
+		for _, stack := range stacks {
+			if stack.Tags[SvcTagKey] != workflow.serviceName || stack.Tags[EnvTagKey] != environmentName {
+				continue
+			}
+
+			expression := stack.Parameters["ScheduleExpression"]
+			state := LineChar
+			lastRun := LineChar
+			var lastInvocation *time.Time
+
+			// stacks deployed before the rule name was an output can't be looked up
+			if ruleName := stack.Outputs["ScheduledRuleName"]; ruleName != "" {
+				ruleState, err := scheduleStateGetter.GetScheduleState(ruleName)
+				if err != nil {
+					log.Debugf("Unable to get state of schedule rule '%s': %v", ruleName, err)
+				} else {
+					expression = ruleState.Expression
+					state = scheduleState(ruleState.Enabled)
+					lastInvocation = ruleState.LastInvocation
+				}
+			}
+			if lastInvocation != nil {
+				lastRun = lastInvocation.Local().Format(LastUpdateTime)
+			}
+
+			table.Append([]string{
+				Bold(scheduleNameOfStack(stack, namespace, workflow.serviceName, environmentName)),
+				expression,
+				state,
+				lastRun,
+				nextScheduleRuns(expression, lastInvocation),
+			})
+		}
+		table.Render()
+
+		return nil
+	}
+}
+
+func (workflow *serviceWorkflow) serviceEksSchedulesViewer(writer io.Writer) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+		cronJobs, err := workflow.kubernetesResourceManager.ListResources("batch/v1beta1", "CronJob", namespace)
+		if err != nil {
+			return err
+		}
+
+		table := CreateTableSection(writer, SvcScheduleTableHeader)
+		if cronJobs != nil {
+			for _, cronJob := range cronJobs.Items {
+				annotations := cronJob.GetAnnotations()
+				if annotations["mu/type"] != "schedule" {
+					continue
+				}
+
+				state := scheduleState(true)
+				if suspend, _, _ := unstructured.NestedBool(cronJob.Object, "spec", "suspend"); suspend {
+					state = scheduleState(false)
+				}
+
+				lastRun := LineChar
+				var lastInvocation *time.Time
+				if lastScheduleTime, found, _ := unstructured.NestedString(cronJob.Object, "status", "lastScheduleTime"); found {
+					if lastTime, err := time.Parse(time.RFC3339, lastScheduleTime); err == nil {
+						lastInvocation = &lastTime
+						lastRun = lastTime.Local().Format(LastUpdateTime)
+					}
+				}
+
+				expression := annotations["mu/expression"]
+				table.Append([]string{
+					Bold(annotations["mu/schedule"]),
+					expression,
+					state,
+					lastRun,
+					nextScheduleRuns(expression, lastInvocation),
+				})
+			}
+		}
+		table.Render()
+
+		return nil
+	}
+}
+
+func (workflow *serviceWorkflow) serviceScheduleStateUpdater(namespace string, environmentName string, scheduleName string, enabled bool,
+	stackGetter common.StackGetter, scheduleStateSetter common.ScheduleStateSetter) Executor {
+	return func() error {
+		stackName := scheduleStackName(namespace, workflow.serviceName, common.Schedule{Name: scheduleName}, environmentName)
+		stack, err := stackGetter.GetStack(stackName)
+		if err != nil || stack == nil {
+			return fmt.Errorf("Unable to find schedule '%s' of service '%s' in environment '%s'", scheduleName, workflow.serviceName, environmentName)
+		}
+
+		ruleName := stack.Outputs["ScheduledRuleName"]
+		if ruleName == "" {
+			return fmt.Errorf("Unable to find the rule of schedule '%s', deploy the service again to manage it", scheduleName)
+		}
+
+		log.Noticef("Setting schedule '%s' of service '%s' in environment '%s' to %s until the next deploy", scheduleName, workflow.serviceName, environmentName, scheduleState(enabled))
+		return scheduleStateSetter.SetScheduleState(ruleName, enabled)
+	}
+}
+
+func (workflow *serviceWorkflow) serviceEksScheduleStateUpdater(scheduleName string, enabled bool) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+		cronJobName := scheduleCronJobName(workflow.serviceName, scheduleName)
+
+		// upserting the suspend flag alone would create a CronJob without a schedule or job template
+		cronJobs, err := workflow.kubernetesResourceManager.ListResources("batch/v1beta1", "CronJob", namespace)
+		if err != nil {
+			return err
+		}
+		deployed := false
+		if cronJobs != nil {
+			for _, cronJob := range cronJobs.Items {
+				deployed = deployed || cronJob.GetName() == cronJobName
+			}
+		}
+		if !deployed {
+			return fmt.Errorf("Schedule '%s' of service '%s' is not deployed, deploy the service to create it", scheduleName, workflow.serviceName)
+		}
+
+		log.Noticef("Setting schedule '%s' of service '%s' to %s until the next deploy", scheduleName, workflow.serviceName, scheduleState(enabled))
+		return workflow.kubernetesResourceManager.UpsertResources(common.TemplateK8sSuspend, map[string]interface{}{
+			"Namespace":   namespace,
+			"CronJobName": cronJobName,
+			"Suspend":     !enabled,
+		})
+	}
+}
+
+func findSchedule(schedules []common.Schedule, scheduleName string) *common.Schedule {
+	for idx := range schedules {
+		if strings.EqualFold(schedules[idx].Name, scheduleName) {
+			return &schedules[idx]
+		}
+	}
+	return nil
+}
+
+// scheduleNameOfStack returns the name of the schedule of a stack, from its tag or else from the stack name
+func scheduleNameOfStack(stack *common.Stack, namespace string, serviceName string, environmentName string) string {
+	if name := stack.Tags[SvcScheduleTagKey]; name != "" {
+		return name
+	}
+	prefix := common.CreateStackName(namespace, common.StackTypeSchedule, serviceName) + "-"
+	return strings.TrimSuffix(strings.TrimPrefix(stack.Name, prefix), "-"+environmentName)
+}
+
+// nextScheduleRuns formats the next fire times of an expression, rate expressions are counted from their last invocation
+func nextScheduleRuns(expression string, lastInvocation *time.Time) string {
+	after := time.Now()
+	if strings.HasPrefix(expression, "rate(") && lastInvocation != nil {
+		after = *lastInvocation
+	}
+
+	times, err := common.NextScheduleTimes(expression, time.UTC, after, SvcScheduleNextRuns)
+	if err != nil || len(times) == 0 {
+		return LineChar
+	}
+	runs := make([]string, len(times))
+	for idx, t := range times {
+		runs[idx] = t.Local().Format(LastUpdateTime)
+	}
+	return strings.Join(runs, NewLine)
+}
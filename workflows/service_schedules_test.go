@@ -0,0 +1,174 @@
+package workflows
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type mockedScheduleManager struct {
+	mock.Mock
+}
+
+func (m *mockedScheduleManager) GetScheduleState(ruleName string) (*common.ScheduleState, error) {
+	args := m.Called(ruleName)
+	return args.Get(0).(*common.ScheduleState), args.Error(1)
+}
+
+func (m *mockedScheduleManager) SetScheduleState(ruleName string, enabled bool) error {
+	args := m.Called(ruleName, enabled)
+	return args.Error(0)
+}
+
+func TestServiceSchedulesViewer(t *testing.T) {
+	assert := assert.New(t)
+
+	lastInvocation := time.Now().Add(-time.Hour)
+
+	stackLister := new(mockedStackListerForScaling)
+	stackLister.On("ListStacks", common.StackTypeSchedule, "mu").Return([]*common.Stack{
+		{
+			Name:       "mu-schedule-foo-nightly-dev",
+			Tags:       map[string]string{"service": "foo", "environment": "dev", "schedule": "Nightly"},
+			Parameters: map[string]string{"ScheduleExpression": "cron(0 2 * * ? *)"},
+			Outputs:    map[string]string{"ScheduledRuleName": "mu-schedule-foo-nightly-ScheduledRule-1"},
+		},
+		{
+			Name:       "mu-schedule-foo-hourly-dev",
+			Tags:       map[string]string{"service": "foo", "environment": "dev"},
+			Parameters: map[string]string{"ScheduleExpression": "rate(1 hour)"},
+		},
+		{
+			Name: "mu-schedule-foo-weekly-prod",
+			Tags: map[string]string{"service": "foo", "environment": "prod", "schedule": "Weekly"},
+		},
+	}, nil)
+
+	scheduleManager := new(mockedScheduleManager)
+	scheduleManager.On("GetScheduleState", "mu-schedule-foo-nightly-ScheduledRule-1").Return(&common.ScheduleState{
+		Expression:     "cron(0 2 * * ? *)",
+		Enabled:        false,
+		LastInvocation: &lastInvocation,
+	}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+
+	var out bytes.Buffer
+	err := workflow.serviceSchedulesViewer("mu", "dev", stackLister, scheduleManager, &out)()
+	assert.Nil(err)
+	assert.Contains(out.String(), "Nightly")
+	assert.Contains(out.String(), "DISABLED")
+	assert.Contains(out.String(), lastInvocation.Local().Format(LastUpdateTime))
+	assert.Contains(out.String(), "hourly")
+	assert.Contains(out.String(), "rate(1 hour)")
+	assert.NotContains(out.String(), "Weekly")
+
+	stackLister.AssertExpectations(t)
+	scheduleManager.AssertExpectations(t)
+}
+
+func TestServiceScheduleStateUpdater(t *testing.T) {
+	assert := assert.New(t)
+
+	stackManager := new(mockedStackManager)
+	stackManager.On("GetStack").Return(&common.Stack{
+		Name:    "mu-schedule-foo-nightly-dev",
+		Outputs: map[string]string{"ScheduledRuleName": "mu-schedule-foo-nightly-ScheduledRule-1"},
+	}, nil)
+
+	scheduleManager := new(mockedScheduleManager)
+	scheduleManager.On("SetScheduleState", "mu-schedule-foo-nightly-ScheduledRule-1", false).Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+
+	err := workflow.serviceScheduleStateUpdater("mu", "dev", "Nightly", false, stackManager, scheduleManager)()
+	assert.Nil(err)
+
+	stackManager.AssertExpectations(t)
+	scheduleManager.AssertExpectations(t)
+}
+
+func TestServiceEksScheduleStateUpdater(t *testing.T) {
+	assert := assert.New(t)
+
+	cronJob := unstructured.Unstructured{}
+	cronJob.SetName("foo-nightly")
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "batch/v1beta1", "CronJob", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{cronJob}}, nil)
+	kubernetesResourceManager.On("UpsertResources", common.TemplateK8sSuspend, map[string]interface{}{
+		"Namespace":   "mu-service-foo",
+		"CronJobName": "foo-nightly",
+		"Suspend":     true,
+	}).Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	err := workflow.serviceEksScheduleStateUpdater("Nightly", false)()
+	assert.Nil(err)
+
+	kubernetesResourceManager.AssertExpectations(t)
+}
+
+func TestServiceEksScheduleStateUpdater_NotDeployed(t *testing.T) {
+	assert := assert.New(t)
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "batch/v1beta1", "CronJob", "mu-service-foo").Return(&unstructured.UnstructuredList{}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	err := workflow.serviceEksScheduleStateUpdater("Nightly", true)()
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "not deployed")
+
+	kubernetesResourceManager.AssertNotCalled(t, "UpsertResources", mock.Anything, mock.Anything)
+}
+
+func TestServiceEksSchedulesViewer(t *testing.T) {
+	assert := assert.New(t)
+
+	cronJob := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"suspend": true},
+		"status": map[string]interface{}{"lastScheduleTime": "2018-06-01T02:00:00Z"},
+	}}
+	cronJob.SetName("foo-nightly")
+	cronJob.SetAnnotations(map[string]string{"mu/type": "schedule", "mu/schedule": "Nightly", "mu/expression": "cron(0 2 * * ? *)"})
+
+	kubernetesResourceManager := new(mockedScheduleKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "batch/v1beta1", "CronJob", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{cronJob}}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	workflow.kubernetesResourceManager = kubernetesResourceManager
+
+	var out bytes.Buffer
+	err := workflow.serviceEksSchedulesViewer(&out)()
+	assert.Nil(err)
+	assert.Contains(out.String(), "Nightly")
+	assert.Contains(out.String(), "DISABLED")
+	assert.Contains(out.String(), time.Date(2018, time.June, 1, 2, 0, 0, 0, time.UTC).Local().Format(LastUpdateTime))
+
+	kubernetesResourceManager.AssertExpectations(t)
+}
+
+func TestFindSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	schedules := []common.Schedule{{Name: "Nightly"}, {Name: "Hourly"}}
+	assert.Equal("Hourly", findSchedule(schedules, "hourly").Name)
+	assert.Nil(findSchedule(schedules, "weekly"))
+}
@@ -0,0 +1,115 @@
+package workflows
+
+import (
+	"fmt"
+
+	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewServiceShell create a new workflow for opening an interactive session in a running task of a service
+func NewServiceShell(ctx *common.Context, environmentName string, serviceName string, taskName string, containerName string, command []string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
+
+	workflow := new(serviceWorkflow)
+
+	return newPipelineExecutor(
+		workflow.serviceInput(ctx, serviceName),
+		workflow.serviceEnvironmentLoader(ctx.Config.Namespace, environmentName, ctx.StackManager),
+		newConditionalExecutor(workflow.isEksProvider(),
+			newPipelineExecutor(
+				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
+				workflow.serviceEksShell(environmentName, taskName, containerName, command),
+			),
+			workflow.serviceShell(ctx.Config.Namespace, ctx.TaskManager, environmentName, taskName, containerName, command)),
+	)
+}
+
+func (workflow *serviceWorkflow) serviceShell(namespace string, taskManager common.TaskManager, environmentName string,
+	taskName string, containerName string, command []string) Executor {
+	return func() error {
+		tasks, err := taskManager.ListTasks(namespace, environmentName, workflow.serviceName)
+		if err != nil {
+			return err
+		}
+
+		var task *common.Task
+		for idx := range tasks {
+			if tasks[idx].Status != "RUNNING" {
+				continue
+			}
+			if taskName == "" || tasks[idx].Name == taskName {
+				task = &tasks[idx]
+				break
+			}
+		}
+		if task == nil {
+			if taskName != "" {
+				return fmt.Errorf("Unable to find running task %s for service %s in environment %s", taskName, workflow.serviceName, environmentName)
+			}
+			return fmt.Errorf("Unable to find a running task for service %s in environment %s", workflow.serviceName, environmentName)
+		}
+
+		log.Noticef(SvcShellOpeningLog, task.Name, workflow.serviceName, environmentName)
+		return taskManager.OpenShell(namespace, environmentName, task.Name, shellContainer(workflow.serviceName, containerName), shellCommand(command))
+	}
+}
+
+func (workflow *serviceWorkflow) serviceEksShell(environmentName string, podName string, containerName string, command []string) Executor {
+	return func() error {
+		namespace := fmt.Sprintf("mu-service-%s", workflow.serviceName)
+
+		pods, err := workflow.kubernetesResourceManager.ListResources("v1", "Pod", namespace)
+		if err != nil {
+			return err
+		}
+
+		pod := findKubernetesServicePod(pods, workflow.serviceName, podName)
+		if pod == "" {
+			if podName != "" {
+				return fmt.Errorf("Unable to find running pod %s for service %s", podName, workflow.serviceName)
+			}
+			return fmt.Errorf("Unable to find a running pod for service %s", workflow.serviceName)
+		}
+
+		log.Noticef(SvcShellOpeningLog, pod, workflow.serviceName, environmentName)
+		return workflow.kubernetesResourceManager.ExecPod(namespace, pod, shellContainer(workflow.serviceName, containerName), shellCommand(command))
+	}
+}
+
+// findKubernetesServicePod returns the name of a running pod of the service deployment, skipping pods of exec jobs
+func findKubernetesServicePod(pods *unstructured.UnstructuredList, serviceName string, podName string) string {
+	if pods == nil {
+		return ""
+	}
+	for _, pod := range pods.Items {
+		if pod.GetLabels()["app.kubernetes.io/name"] != fmt.Sprintf("%s-deployment", serviceName) {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		if phase != "Running" {
+			continue
+		}
+		if podName == "" || pod.GetName() == podName {
+			return pod.GetName()
+		}
+	}
+	return ""
+}
+
+func shellContainer(serviceName string, containerName string) string {
+	if containerName == "" {
+		return serviceName
+	}
+	return containerName
+}
+
+func shellCommand(command []string) []string {
+	if len(command) == 0 {
+		return []string{DefaultShellCommand}
+	}
+	return command
+}
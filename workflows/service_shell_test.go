@@ -0,0 +1,101 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stelligent/mu/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type mockedShellTaskManager struct {
+	mock.Mock
+	common.TaskManager
+}
+
+func (m *mockedShellTaskManager) ListTasks(namespace string, environment string, serviceName string) ([]common.Task, error) {
+	args := m.Called(namespace, environment, serviceName)
+	return args.Get(0).([]common.Task), args.Error(1)
+}
+
+func (m *mockedShellTaskManager) OpenShell(namespace string, environment string, taskName string, containerName string, command []string) error {
+	args := m.Called(namespace, environment, taskName, containerName, command)
+	return args.Error(0)
+}
+
+func TestNewServiceShell(t *testing.T) {
+	assert := assert.New(t)
+	ctx := common.NewContext()
+	shell := NewServiceShell(ctx, "dev", "foo", "", "", nil)
+	assert.NotNil(shell)
+}
+
+func TestServiceShell_FirstRunningTask(t *testing.T) {
+	assert := assert.New(t)
+
+	taskManager := new(mockedShellTaskManager)
+	taskManager.On("ListTasks", "mu", "dev", "foo").Return([]common.Task{{Name: "t1", Status: "PENDING"}, {Name: "t2", Status: "RUNNING"}}, nil)
+	taskManager.On("OpenShell", "mu", "dev", "t2", "foo", []string{"/bin/sh"}).Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceShell("mu", taskManager, "dev", "", "", nil)()
+	assert.Nil(err)
+
+	taskManager.AssertExpectations(t)
+}
+
+func TestServiceShell_TaskAndCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	taskManager := new(mockedShellTaskManager)
+	taskManager.On("ListTasks", "mu", "dev", "foo").Return([]common.Task{{Name: "t1", Status: "RUNNING"}, {Name: "t2", Status: "RUNNING"}}, nil)
+	taskManager.On("OpenShell", "mu", "dev", "t2", "envoy", []string{"ls", "-l"}).Return(nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceShell("mu", taskManager, "dev", "t2", "envoy", []string{"ls", "-l"})()
+	assert.Nil(err)
+
+	taskManager.AssertExpectations(t)
+}
+
+func TestServiceShell_NoRunningTask(t *testing.T) {
+	assert := assert.New(t)
+
+	taskManager := new(mockedShellTaskManager)
+	taskManager.On("ListTasks", "mu", "dev", "foo").Return([]common.Task{{Name: "t1", Status: "STOPPED"}}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+	err := workflow.serviceShell("mu", taskManager, "dev", "", "", nil)()
+	assert.NotNil(err)
+
+	taskManager.AssertNotCalled(t, "OpenShell", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestFindKubernetesServicePod(t *testing.T) {
+	assert := assert.New(t)
+
+	newPod := func(name string, app string, phase string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": map[string]interface{}{"app.kubernetes.io/name": app},
+			},
+			"status": map[string]interface{}{"phase": phase},
+		}}
+	}
+	pods := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		newPod("foo-exec-1", "foo", "Running"),
+		newPod("foo-deployment-a", "foo-deployment", "Pending"),
+		newPod("foo-deployment-b", "foo-deployment", "Running"),
+		newPod("foo-deployment-c", "foo-deployment", "Running"),
+	}}
+
+	assert.Equal("foo-deployment-b", findKubernetesServicePod(pods, "foo", ""))
+	assert.Equal("foo-deployment-c", findKubernetesServicePod(pods, "foo", "foo-deployment-c"))
+	assert.Equal("", findKubernetesServicePod(pods, "foo", "foo-deployment-a"))
+	assert.Equal("", findKubernetesServicePod(nil, "foo", ""))
+}
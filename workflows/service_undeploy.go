@@ -9,6 +9,10 @@ import (
 
 // NewServiceUndeployer create a new workflow for undeploying a service in an environment
 func NewServiceUndeployer(ctx *common.Context, serviceName string, environmentName string) Executor {
+	ctx, err := ctx.ForEnvironment(environmentName)
+	if err != nil {
+		return newErrorExecutor(err)
+	}
 
 	workflow := new(serviceWorkflow)
 
@@ -20,7 +24,10 @@ func NewServiceUndeployer(ctx *common.Context, serviceName string, environmentNa
 				workflow.connectKubernetes(ctx.KubernetesResourceManagerProvider),
 				workflow.serviceEksUndeployer(environmentName),
 			),
-			workflow.serviceUndeployer(ctx.Config.Namespace, environmentName, ctx.StackManager, ctx.StackManager),
+			newPipelineExecutor(
+				workflow.serviceScheduleTerminator(ctx.Config.Namespace, nil, environmentName, ctx.StackManager, ctx.StackManager, ctx.StackManager),
+				workflow.serviceUndeployer(ctx.Config.Namespace, environmentName, ctx.StackManager, ctx.StackManager),
+			),
 		),
 	)
 }
@@ -51,6 +58,8 @@ func (workflow *serviceWorkflow) serviceEksUndeployer(environmentName string) Ex
 	return func() error {
 		log.Noticef("Undeploying service '%s' from '%s'", workflow.serviceName, environmentName)
 
+		// deleting the namespace also deletes the CronJobs of the service schedules
+
 		return workflow.kubernetesResourceManager.DeleteResource("v1", "Namespace", "", fmt.Sprintf("mu-service-%s", workflow.serviceName))
 	}
 }
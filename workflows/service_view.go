@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/stelligent/mu/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // NewServiceViewer create a new workflow for showing an environment
@@ -19,6 +21,8 @@ func NewServiceViewer(ctx *common.Context, serviceName string, writer io.Writer,
 	return newPipelineExecutor(
 		workflow.serviceInput(ctx, serviceName),
 		workflow.serviceViewer(ctx.Config.Namespace, ctx.StackManager, ctx.StackManager, ctx.PipelineManager, ctx.TaskManager, ctx.Config, writer),
+		workflow.serviceImageViewer(ctx.Config.Namespace, ctx.StackManager, writer),
+		workflow.serviceScalingViewer(ctx.Config.Namespace, ctx.StackManager, ctx.AutoscalingManager, ctx.KubernetesResourceManagerProvider, writer),
 	)
 }
 
@@ -59,6 +63,162 @@ func (workflow *serviceWorkflow) serviceViewer(namespace string, stackLister com
 	}
 }
 
+// serviceImageViewer shows the image each environment runs, along with the tag it was deployed from
+func (workflow *serviceWorkflow) serviceImageViewer(namespace string, stackLister common.StackLister, writer io.Writer) Executor {
+
+	return func() error {
+		stacks, err := stackLister.ListStacks(common.StackTypeService, namespace)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(writer, NewLine)
+		fmt.Fprintf(writer, HeadNewlineHeader, Bold(SvcImagesLabel))
+
+		table := CreateTableSection(writer, SvcImageTableHeader)
+		for _, stack := range stacks {
+			if stack.Tags[SvcTagKey] != workflow.serviceName {
+				continue
+			}
+			image := stack.Parameters[SvcImageURLKey]
+			if image == "" {
+				continue
+			}
+
+			tag := stack.Parameters[SvcImageTagKey]
+			if tag == "" || tag == image {
+				// the image was deployed by its tag rather than a digest
+				tag = LineChar
+			}
+			table.Append([]string{
+				Bold(stack.Tags[EnvTagKey]),
+				tag,
+				image,
+			})
+		}
+		table.Render()
+
+		return nil
+	}
+}
+
+func (workflow *serviceWorkflow) serviceScalingViewer(namespace string, stackLister common.StackLister, scalingStateGetter common.ScalingStateGetter,
+	kubernetesResourceManagerProvider common.KubernetesResourceManagerProvider, writer io.Writer) Executor {
+
+	return func() error {
+		stacks, err := stackLister.ListStacks(common.StackTypeService, namespace)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(writer, NewLine)
+		fmt.Fprintf(writer, HeadNewlineHeader, Bold(SvcScalingLabel))
+
+		table := CreateTableSection(writer, SvcScalingTableHeader)
+		for _, stack := range stacks {
+			if stack.Tags[SvcTagKey] != workflow.serviceName {
+				continue
+			}
+			environmentName := stack.Tags[EnvTagKey]
+
+			// only ECS services are scaled through Application Auto Scaling
+			if stack.Outputs["EcsCluster"] == "" {
+				continue
+			}
+
+			ecsServiceName := fmt.Sprintf("%s-%s-%s", namespace, workflow.serviceName, environmentName)
+			state, err := scalingStateGetter.GetScalingState(stack.Outputs["EcsCluster"], ecsServiceName)
+			if err != nil {
+				log.Debugf("Unable to get scaling state for '%s': %v", ecsServiceName, err)
+				continue
+			}
+
+			table.Append(scalingStateRow(environmentName, state))
+		}
+
+		// EKS services are scaled by the HorizontalPodAutoscaler in the namespace of the service
+		envStacks, err := stackLister.ListStacks(common.StackTypeEnv, namespace)
+		if err != nil {
+			return err
+		}
+		for _, envStack := range envStacks {
+			provider := envStack.Tags["provider"]
+			if !strings.EqualFold(provider, string(common.EnvProviderEks)) && !strings.EqualFold(provider, string(common.EnvProviderEksFargate)) {
+				continue
+			}
+
+			kubernetesResourceManager, err := kubernetesResourceManagerProvider.GetResourceManager(envStack.Name)
+			if err != nil {
+				log.Debugf("Unable to connect to cluster '%s': %v", envStack.Name, err)
+				continue
+			}
+			state, err := kubernetesScalingState(kubernetesResourceManager, workflow.serviceName)
+			if err != nil {
+				log.Debugf("Unable to get scaling state for '%s' in '%s': %v", workflow.serviceName, envStack.Name, err)
+				continue
+			}
+			if state != nil {
+				table.Append(scalingStateRow(envStack.Tags[EnvTagKey], state))
+			}
+		}
+		table.Render()
+
+		return nil
+	}
+}
+
+func scalingStateRow(environmentName string, state *common.ScalingState) []string {
+	lastActivity := LineChar
+	if state.LastActivity != nil {
+		lastActivity = fmt.Sprintf(KeyValueFormat, state.LastActivity.StartTime.Local().Format(LastUpdateTime), state.LastActivity.Description)
+	}
+	return []string{
+		Bold(environmentName),
+		fmt.Sprintf("%d/%d", state.MinCapacity, state.MaxCapacity),
+		strings.Join(state.Policies, NewLine),
+		strings.Join(state.ScheduledActions, NewLine),
+		lastActivity,
+	}
+}
+
+// kubernetesScalingState reads the HorizontalPodAutoscaler of a service, or nil when it isn't autoscaled
+func kubernetesScalingState(kubernetesResourceManager common.KubernetesResourceManager, serviceName string) (*common.ScalingState, error) {
+	autoscalers, err := kubernetesResourceManager.ListResources("autoscaling/v2beta1", "HorizontalPodAutoscaler", fmt.Sprintf("mu-service-%s", serviceName))
+	if err != nil || autoscalers == nil {
+		return nil, err
+	}
+	for _, autoscaler := range autoscalers.Items {
+		if autoscaler.GetName() != fmt.Sprintf("%s-autoscaler", serviceName) {
+			continue
+		}
+
+		state := &common.ScalingState{}
+		state.MinCapacity, _, _ = unstructured.NestedInt64(autoscaler.Object, "spec", "minReplicas")
+		state.MaxCapacity, _, _ = unstructured.NestedInt64(autoscaler.Object, "spec", "maxReplicas")
+		metrics, _, _ := unstructured.NestedSlice(autoscaler.Object, "spec", "metrics")
+		for _, metric := range metrics {
+			metricMap, ok := metric.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(metricMap, "resource", "name")
+			target, _, _ := unstructured.NestedInt64(metricMap, "resource", "targetAverageUtilization")
+			state.Policies = append(state.Policies, fmt.Sprintf("%s-utilization: %d%%", name, target))
+		}
+
+		lastScaleTime, _, _ := unstructured.NestedString(autoscaler.Object, "status", "lastScaleTime")
+		if startTime, err := time.Parse(time.RFC3339, lastScaleTime); err == nil {
+			desiredReplicas, _, _ := unstructured.NestedInt64(autoscaler.Object, "status", "desiredReplicas")
+			state.LastActivity = &common.ScalingActivity{
+				Description: fmt.Sprintf("Scaled to %d pods", desiredReplicas),
+				StartTime:   startTime,
+			}
+		}
+		return state, nil
+	}
+	return nil, nil
+}
+
 func buildPipelineStateTable(writer io.Writer, stages []common.PipelineStageState) *tablewriter.Table {
 	table := CreateTableSection(writer, SvcPipelineTableHeader)
 
@@ -1,10 +1,13 @@
 package workflows
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stelligent/mu/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestNewServiceViewer(t *testing.T) {
@@ -13,3 +16,119 @@ func TestNewServiceViewer(t *testing.T) {
 	viewer := NewServiceViewer(ctx, "foo", nil, false)
 	assert.NotNil(viewer)
 }
+
+type mockedStackListerForScaling struct {
+	mock.Mock
+}
+
+func (m *mockedStackListerForScaling) ListStacks(stackType common.StackType, namespace string) ([]*common.Stack, error) {
+	args := m.Called(stackType, namespace)
+	return args.Get(0).([]*common.Stack), args.Error(1)
+}
+
+type mockedScalingStateGetter struct {
+	mock.Mock
+}
+
+func (m *mockedScalingStateGetter) GetScalingState(clusterName string, serviceName string) (*common.ScalingState, error) {
+	args := m.Called(clusterName, serviceName)
+	return args.Get(0).(*common.ScalingState), args.Error(1)
+}
+
+func TestServiceScalingViewer(t *testing.T) {
+	assert := assert.New(t)
+
+	stackLister := new(mockedStackListerForScaling)
+	stackLister.On("ListStacks", common.StackTypeService, "mu").Return([]*common.Stack{
+		{Name: "mu-service-foo-dev", Tags: map[string]string{"service": "foo", "environment": "dev"}, Outputs: map[string]string{"EcsCluster": "mu-cluster-dev"}},
+		{Name: "mu-service-foo-eks", Tags: map[string]string{"service": "foo", "environment": "eks"}, Outputs: map[string]string{}},
+		{Name: "mu-service-bar-dev", Tags: map[string]string{"service": "bar", "environment": "dev"}, Outputs: map[string]string{"EcsCluster": "mu-cluster-dev"}},
+	}, nil)
+
+	stackLister.On("ListStacks", common.StackTypeEnv, "mu").Return([]*common.Stack{
+		{Name: "mu-environment-dev", Tags: map[string]string{"environment": "dev", "provider": "ecs"}},
+		{Name: "mu-environment-eks", Tags: map[string]string{"environment": "eks", "provider": "eks"}},
+	}, nil)
+
+	scalingStateGetter := new(mockedScalingStateGetter)
+	scalingStateGetter.On("GetScalingState", "mu-cluster-dev", "mu-foo-dev").Return(&common.ScalingState{
+		MinCapacity: 1,
+		MaxCapacity: 4,
+		Policies:    []string{"mu-service-foo-dev-cpu-utilization"},
+	}, nil)
+
+	autoscaler := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"minReplicas": int64(2),
+			"maxReplicas": int64(6),
+			"metrics": []interface{}{
+				map[string]interface{}{"type": "Resource", "resource": map[string]interface{}{"name": "memory", "targetAverageUtilization": int64(70)}},
+			},
+		},
+		"status": map[string]interface{}{
+			"lastScaleTime":   "2020-05-01T10:00:00Z",
+			"desiredReplicas": int64(3),
+		},
+	}}
+	autoscaler.SetName("foo-autoscaler")
+	kubernetesResourceManager := new(mockKubernetesResourceManager)
+	kubernetesResourceManager.On("ListResources", "autoscaling/v2beta1", "HorizontalPodAutoscaler", "mu-service-foo").Return(
+		&unstructured.UnstructuredList{Items: []unstructured.Unstructured{autoscaler}}, nil)
+	kubernetesResourceManagerProvider := new(mockedKubernetesResourceManagerProvider)
+	kubernetesResourceManagerProvider.On("GetResourceManager", "mu-environment-eks").Return(kubernetesResourceManager, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+
+	var out bytes.Buffer
+	err := workflow.serviceScalingViewer("mu", stackLister, scalingStateGetter, kubernetesResourceManagerProvider, &out)()
+	assert.Nil(err)
+	assert.Contains(out.String(), "1/4")
+	assert.Contains(out.String(), "mu-service-foo-dev-cpu-utilization")
+	assert.Contains(out.String(), "2/6")
+	assert.Contains(out.String(), "memory-utilization: 70%")
+	assert.Contains(out.String(), "Scaled to 3 pods")
+
+	stackLister.AssertExpectations(t)
+	scalingStateGetter.AssertExpectations(t)
+	scalingStateGetter.AssertNumberOfCalls(t, "GetScalingState", 1)
+	kubernetesResourceManagerProvider.AssertExpectations(t)
+	kubernetesResourceManagerProvider.AssertNumberOfCalls(t, "GetResourceManager", 1)
+}
+
+type mockedKubernetesResourceManagerProvider struct {
+	mock.Mock
+	common.KubernetesResourceManagerProvider
+}
+
+func (m *mockedKubernetesResourceManagerProvider) GetResourceManager(name string) (common.KubernetesResourceManager, error) {
+	args := m.Called(name)
+	return args.Get(0).(common.KubernetesResourceManager), args.Error(1)
+}
+
+func TestServiceImageViewer(t *testing.T) {
+	assert := assert.New(t)
+
+	stackLister := new(mockedStackListerForScaling)
+	stackLister.On("ListStacks", common.StackTypeService, "mu").Return([]*common.Stack{
+		{Name: "mu-service-foo-dev", Tags: map[string]string{"service": "foo", "environment": "dev"}, Parameters: map[string]string{
+			"ImageUrl": "1234.dkr.ecr.us-west-2.amazonaws.com/mu-foo@sha256:abc",
+			"ImageTag": "1234.dkr.ecr.us-west-2.amazonaws.com/mu-foo:8b3c1d2",
+		}},
+		{Name: "mu-service-bar-dev", Tags: map[string]string{"service": "bar", "environment": "dev"}, Parameters: map[string]string{
+			"ImageUrl": "1234.dkr.ecr.us-west-2.amazonaws.com/mu-bar@sha256:def",
+		}},
+	}, nil)
+
+	workflow := new(serviceWorkflow)
+	workflow.serviceName = "foo"
+
+	var out bytes.Buffer
+	err := workflow.serviceImageViewer("mu", stackLister, &out)()
+	assert.Nil(err)
+	assert.Contains(out.String(), "mu-foo@sha256:abc")
+	assert.Contains(out.String(), "mu-foo:8b3c1d2")
+	assert.NotContains(out.String(), "mu-bar")
+
+	stackLister.AssertExpectations(t)
+}